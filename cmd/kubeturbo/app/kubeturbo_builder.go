@@ -7,7 +7,10 @@ import (
 	"net/http/pprof"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -19,12 +22,18 @@ import (
 
 	kubeturbo "github.com/turbonomic/kubeturbo/pkg"
 	"github.com/turbonomic/kubeturbo/pkg/action/executor"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/history"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/k8sconntrack"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
+	prommonitor "github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
 	"github.com/turbonomic/kubeturbo/pkg/turbostore"
 	"github.com/turbonomic/kubeturbo/test/flag"
 
@@ -37,6 +46,21 @@ const (
 	// The default port for vmt service server
 	KubeturboPort   = 10265
 	K8sCadvisorPort = 4194
+
+	// The sources of node and pod resource metrics.
+	KubeletMetricsSource       = "kubelet"
+	MetricsServerMetricsSource = "metrics-server"
+	BothMetricsSource          = "both"
+	PrometheusMetricsSource    = "prometheus"
+
+	// CPU frequency (MHz) assigned to nodes when kubelet is not used to get the real value.
+	DefaultNodeCPUFrequency = 2600.0
+
+	// The default number of discoveries whose usage is kept to report peak and percentile.
+	DefaultMetricHistorySize = 60
+
+	// The default interval to save the metric history snapshot.
+	DefaultMetricHistoryCheckpointInterval = 10 * time.Minute
 )
 
 // VMTServer has all the context and params needed to run a Scheduler
@@ -64,6 +88,42 @@ type VMTServer struct {
 	// Kubelet related config
 	KubeletPort        int
 	EnableKubeletHttps bool
+	EnableKubeletProxy bool
+
+	// Resource metrics related config
+	ResourceMetricsSource string
+	NodeCPUFrequency      float64
+
+	// Use the raw capacity of the nodes instead of their allocatable resources
+	UseNodeCapacity bool
+
+	// Discovery worker pool related config
+	MinDiscoveryWorkers int
+	MaxDiscoveryWorkers int
+	TargetDiscoveryTime time.Duration
+
+	// Node scraping related config
+	NodeScrapeConcurrency int
+	NodeScrapeTimeout     time.Duration
+
+	// The network throughput capacity (Mbit/s) of the nodes, which kubelet does not report
+	NodeNetworkCapacity float64
+
+	// Metric history related config
+	MetricHistorySize int
+	UsagePercentile   float64
+
+	// Metric history persistence related config
+	MetricHistoryFile               string
+	MetricHistoryConfigMap          string
+	MetricHistoryCheckpointInterval time.Duration
+
+	// Resync period of the informer cache of the cluster objects
+	ClusterCacheResyncPeriod time.Duration
+
+	// Prometheus related config
+	PrometheusServer    string
+	PrometheusQueryFile string
 
 	// for Move Action
 	K8sVersion        string
@@ -92,6 +152,24 @@ func (s *VMTServer) AddFlags(fs *pflag.FlagSet) {
 	fs.BoolVar(&s.UseVMWare, "usevmware", false, "If the underlying infrastructure is VMWare.")
 	fs.IntVar(&s.KubeletPort, "kubelet-port", kubelet.DefaultKubeletPort, "The port of the kubelet runs on")
 	fs.BoolVar(&s.EnableKubeletHttps, "kubelet-https", kubelet.DefaultKubeletHttps, "Indicate if Kubelet is running on https server")
+	fs.BoolVar(&s.EnableKubeletProxy, "kubelet-proxy", kubelet.DefaultKubeletProxy, "Access kubelet through the nodes/proxy endpoint of the API server, for clusters whose node IPs are not reachable from kubeturbo")
+	fs.StringVar(&s.ResourceMetricsSource, "resource-metrics-source", KubeletMetricsSource, "The source of node and pod resource metrics: kubelet, metrics-server, both or prometheus. With both, kubelet metrics take precedence over metrics-server ones. With prometheus, only the Prometheus server set by --prometheus-server is used")
+	fs.Float64Var(&s.NodeCPUFrequency, "node-cpu-frequency", DefaultNodeCPUFrequency, "The CPU frequency (MHz) of the nodes, used when the resource metrics source is metrics-server or prometheus")
+	fs.BoolVar(&s.UseNodeCapacity, "use-node-capacity", false, "Use the raw capacity of the nodes instead of their allocatable resources, and report the resources reserved for kubelet and system daemons as used node overhead")
+	fs.IntVar(&s.MinDiscoveryWorkers, "min-discovery-workers", worker.DefaultMinWorkerCount, "The minimum number of workers discovering the nodes in parallel")
+	fs.IntVar(&s.MaxDiscoveryWorkers, "max-discovery-workers", worker.DefaultMaxWorkerCount, "The maximum number of workers discovering the nodes in parallel")
+	fs.DurationVar(&s.TargetDiscoveryTime, "target-discovery-time", worker.DefaultTargetDiscoveryTime, "The time the discovery of the nodes should take, used to size the worker pool from the cluster size and the observed time per node; keep it below the discovery interval of the server")
+	fs.IntVar(&s.NodeScrapeConcurrency, "node-scrape-concurrency", metrics.DefaultNodeScrapeConcurrency, "The maximum number of nodes whose kubelet or k8sconntrack a monitoring worker scrapes at the same time")
+	fs.DurationVar(&s.NodeScrapeTimeout, "node-scrape-timeout", metrics.DefaultNodeScrapeTimeout, "The time given to scrape a node; the metrics of a node which doesn't respond in time are skipped")
+	fs.Float64Var(&s.NodeNetworkCapacity, "node-network-capacity", kubelet.DefaultNetworkThroughputCapacity, "The network throughput capacity (Mbit/s) of the nodes, used with the kubelet resource metrics source; the "+kubelet.NetworkThroughputCapacityAnnotation+" annotation of a node overrides it")
+	fs.IntVar(&s.MetricHistorySize, "metric-history-size", DefaultMetricHistorySize, "The number of discoveries whose CPU and memory usage is kept to report peak and percentile usage; 0 disables the history")
+	fs.Float64Var(&s.UsagePercentile, "usage-percentile", 0, "The percentile (0-100) of the usage history reported as the used value of CPU and memory; 0 reports the latest sample")
+	fs.StringVar(&s.MetricHistoryFile, "metric-history-file", s.MetricHistoryFile, "Path to the file, e.g. on a persistent volume, to save the metric history so that it survives restarts")
+	fs.StringVar(&s.MetricHistoryConfigMap, "metric-history-configmap", s.MetricHistoryConfigMap, "The namespace/name of the ConfigMap to save the metric history so that it survives restarts; suitable for small clusters")
+	fs.DurationVar(&s.MetricHistoryCheckpointInterval, "metric-history-checkpoint-interval", DefaultMetricHistoryCheckpointInterval, "The interval to save the metric history")
+	fs.DurationVar(&s.ClusterCacheResyncPeriod, "cluster-cache-resync-period", cluster.DefaultCacheResyncPeriod, "The interval for the informer cache of nodes, pods, services, endpoints and controllers to re-list them from the API server")
+	fs.StringVar(&s.PrometheusServer, "prometheus-server", s.PrometheusServer, "The address of the Prometheus server, e.g. http://prometheus:9090. If set, metrics are also retrieved from Prometheus, and Prometheus metrics take precedence over the kubelet and metrics-server ones. The transactions are retrieved from Prometheus instead of K8sConntrack only if a podTransaction or podResponseTime query is set in --prometheus-query-file")
+	fs.StringVar(&s.PrometheusQueryFile, "prometheus-query-file", s.PrometheusQueryFile, "Path to a json file overriding the default PromQL queries.")
 	fs.StringVar(&s.K8sVersion, "k8sVersion", executor.HigherK8sVersion, "the kubernetes server version; for openshift, it is the underlying Kubernetes' version.")
 	fs.StringVar(&s.NoneSchedulerName, "noneSchedulerName", executor.DefaultNoneExistSchedulerName, "a none-exist scheduler name, to prevent controller to create Running pods during move Action.")
 
@@ -137,6 +215,7 @@ func (s *VMTServer) createKubeletClientOrDie(kubeConfig *restclient.Config) *kub
 	kubeletClient, err := kubelet.NewKubeletConfig(kubeConfig).
 		WithPort(s.KubeletPort).
 		EnableHttps(s.EnableKubeletHttps).
+		EnableProxy(s.EnableKubeletProxy).
 		//Timeout(to).
 		Create()
 	if err != nil {
@@ -156,8 +235,39 @@ func (s *VMTServer) createProbeConfigOrDie(kubeConfig *restclient.Config, kubele
 		pType = stitching.UUID
 	}
 
-	// Create Kubelet monitoring
-	kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(kubeletClient)
+	// Create resource monitoring. As the metrics are merged in order, metrics-server goes before kubelet,
+	// so that kubelet metrics take precedence and metrics-server only fills the gaps; Prometheus goes last,
+	// so that its metrics take precedence over both.
+	monitoringConfigs := []monitoring.MonitorWorkerConfig{}
+	switch s.ResourceMetricsSource {
+	case KubeletMetricsSource, MetricsServerMetricsSource, BothMetricsSource:
+	case PrometheusMetricsSource:
+		if s.PrometheusServer == "" {
+			glog.Errorf("Resource metrics source %s requires --prometheus-server", s.ResourceMetricsSource)
+			os.Exit(1)
+		}
+	default:
+		glog.Errorf("Unsupported resource metrics source %s", s.ResourceMetricsSource)
+		os.Exit(1)
+	}
+	if s.ResourceMetricsSource == MetricsServerMetricsSource || s.ResourceMetricsSource == BothMetricsSource {
+		metricsServerMonitoringConfig, err := metricsserver.NewMetricsServerMonitorConfig(kubeConfig)
+		if err != nil {
+			glog.Errorf("Failed to build monitor-config for metrics-server monitor: %v", err)
+			os.Exit(1)
+		}
+		if s.ResourceMetricsSource == MetricsServerMetricsSource {
+			metricsServerMonitoringConfig.WithNodeCPUFrequency(s.NodeCPUFrequency)
+		}
+		monitoringConfigs = append(monitoringConfigs, metricsServerMonitoringConfig)
+	}
+	if s.ResourceMetricsSource == KubeletMetricsSource || s.ResourceMetricsSource == BothMetricsSource {
+		// Create Kubelet monitoring
+		kubeletMonitoringConfig := kubelet.NewKubeletMonitorConfig(kubeletClient).
+			WithNodeScrapeLimits(s.NodeScrapeConcurrency, s.NodeScrapeTimeout).
+			WithNetworkThroughputCapacity(s.NodeNetworkCapacity)
+		monitoringConfigs = append(monitoringConfigs, kubeletMonitoringConfig)
+	}
 
 	// Create cluster monitoring
 	masterMonitoringConfig, err := master.NewClusterMonitorConfig(kubeConfig)
@@ -165,22 +275,45 @@ func (s *VMTServer) createProbeConfigOrDie(kubeConfig *restclient.Config, kubele
 		glog.Errorf("Failed to build monitor-config for master topology mointor: %v", err)
 		os.Exit(1)
 	}
-
-	// TODO for now kubelet is the only monitoring source. As we have more sources, we should choose what to be added into the slice here.
-	monitoringConfigs := []monitoring.MonitorWorkerConfig{
-		kubeletMonitoringConfig,
-		masterMonitoringConfig,
+	masterMonitoringConfig.WithNodeCapacity(s.UseNodeCapacity)
+	monitoringConfigs = append(monitoringConfigs, masterMonitoringConfig)
+
+	useK8sConntrack := true
+	if s.PrometheusServer != "" {
+		// Create Prometheus monitoring, which also provides the transactions if their queries are configured.
+		prometheusMonitoringConfig := prommonitor.NewPrometheusMonitorConfig(s.PrometheusServer)
+		if s.PrometheusQueryFile != "" {
+			if _, err := prometheusMonitoringConfig.WithQueryFile(s.PrometheusQueryFile); err != nil {
+				glog.Errorf("Failed to build monitor-config for Prometheus monitor: %v", err)
+				os.Exit(1)
+			}
+		}
+		if s.ResourceMetricsSource == PrometheusMetricsSource {
+			prometheusMonitoringConfig.WithNodeCPUFrequency(s.NodeCPUFrequency)
+		}
+		monitoringConfigs = append(monitoringConfigs, prometheusMonitoringConfig)
+		useK8sConntrack = !prometheusMonitoringConfig.HasTransactionQueries()
+	}
+	if useK8sConntrack {
+		// Create K8sConntrack monitoring
+		// TODO, disable https by default. Change this when k8sconntrack supports https.
+		k8sConntrackMonitoringConfig := k8sconntrack.NewK8sConntrackMonitorConfig().
+			WithNodeScrapeLimits(s.NodeScrapeConcurrency, s.NodeScrapeTimeout)
+		monitoringConfigs = append(monitoringConfigs, k8sConntrackMonitoringConfig)
 	}
-
-	// Create K8sConntrack monitoring
-	// TODO, disable https by default. Change this when k8sconntrack supports https.
-	k8sConntrackMonitoringConfig := k8sconntrack.NewK8sConntrackMonitorConfig()
-	monitoringConfigs = append(monitoringConfigs, k8sConntrackMonitoringConfig)
 
 	probeConfig := &configs.ProbeConfig{
 		CadvisorPort:          s.CAdvisorPort,
 		StitchingPropertyType: pType,
 		MonitoringConfigs:     monitoringConfigs,
+		UsagePercentile:       s.UsagePercentile,
+		UseNodeCapacity:       s.UseNodeCapacity,
+		MinDiscoveryWorkers:   s.MinDiscoveryWorkers,
+		MaxDiscoveryWorkers:   s.MaxDiscoveryWorkers,
+		TargetDiscoveryTime:   s.TargetDiscoveryTime,
+	}
+	if s.MetricHistorySize > 0 {
+		probeConfig.MetricHistory = metrics.NewEntityMetricHistory(s.MetricHistorySize)
 	}
 
 	return probeConfig
@@ -212,6 +345,30 @@ func (s *VMTServer) checkFlag() error {
 		return fmt.Errorf("[KubeletPort[%d] should be bigger than 0.", s.KubeletPort)
 	}
 
+	if s.UsagePercentile < 0 || s.UsagePercentile > 100 {
+		return fmt.Errorf("UsagePercentile[%v] should be between 0 and 100.", s.UsagePercentile)
+	}
+
+	if s.NodeNetworkCapacity <= 0 {
+		return fmt.Errorf("NodeNetworkCapacity[%v] should be positive.", s.NodeNetworkCapacity)
+	}
+
+	if s.MetricHistoryFile != "" && s.MetricHistoryConfigMap != "" {
+		return fmt.Errorf("Only one of MetricHistoryFile and MetricHistoryConfigMap can be set.")
+	}
+
+	if s.MetricHistoryConfigMap != "" && len(strings.Split(s.MetricHistoryConfigMap, "/")) != 2 {
+		return fmt.Errorf("MetricHistoryConfigMap[%s] should be in the format of namespace/name.", s.MetricHistoryConfigMap)
+	}
+
+	if s.MetricHistoryCheckpointInterval <= 0 {
+		return fmt.Errorf("MetricHistoryCheckpointInterval[%v] should be positive.", s.MetricHistoryCheckpointInterval)
+	}
+
+	if s.ClusterCacheResyncPeriod <= 0 {
+		return fmt.Errorf("ClusterCacheResyncPeriod[%v] should be positive.", s.ClusterCacheResyncPeriod)
+	}
+
 	return nil
 }
 
@@ -233,12 +390,18 @@ func (s *VMTServer) Run(_ []string) error {
 	kubeClient := s.createKubeClientOrDie(kubeConfig)
 	kubeletClient := s.createKubeletClientOrDie(kubeConfig)
 	probeConfig := s.createProbeConfigOrDie(kubeConfig, kubeletClient)
+	s.startMetricHistoryCheckpointer(kubeClient, probeConfig.MetricHistory)
 	broker := turbostore.NewPodBroker()
 
+	// Discovery and actions fall back to listing from the API server until the cache is synced.
+	clusterCache := cluster.NewClusterCache(kubeClient, s.ClusterCacheResyncPeriod)
+	clusterCache.Run(wait.NeverStop)
+
 	vmtConfig := kubeturbo.NewVMTConfig2()
 	vmtConfig.WithTapSpec(k8sTAPSpec).
 		WithKubeClient(kubeClient).
 		WithKubeletClient(kubeletClient).
+		WithClusterCache(clusterCache).
 		WithProbeConfig(probeConfig).
 		WithBroker(broker).
 		WithK8sVersion(s.K8sVersion).
@@ -262,6 +425,27 @@ func (s *VMTServer) Run(_ []string) error {
 	panic("unreachable")
 }
 
+// Restore the metric history from the snapshot store if configured, and keep saving it periodically.
+func (s *VMTServer) startMetricHistoryCheckpointer(kubeClient *kubernetes.Clientset, metricHistory *metrics.EntityMetricHistory) {
+	if metricHistory == nil {
+		return
+	}
+
+	var store history.SnapshotStore
+	if s.MetricHistoryFile != "" {
+		store = history.NewFileSnapshotStore(s.MetricHistoryFile)
+	} else if s.MetricHistoryConfigMap != "" {
+		parts := strings.Split(s.MetricHistoryConfigMap, "/")
+		store = history.NewConfigMapSnapshotStore(kubeClient, parts[0], parts[1])
+	} else {
+		return
+	}
+
+	checkpointer := history.NewCheckpointer(metricHistory, store, s.MetricHistoryCheckpointInterval)
+	checkpointer.Restore()
+	go checkpointer.Run(wait.NeverStop)
+}
+
 func (s *VMTServer) startHttp() {
 	mux := http.NewServeMux()
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/action/executor"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 
@@ -29,6 +30,7 @@ const (
 
 type ActionHandlerConfig struct {
 	kubeClient     *client.Clientset
+	clusterCache   *cluster.ClusterCache
 	kubeletClient  *kubelet.KubeletClient
 	StopEverything chan struct{}
 
@@ -36,11 +38,15 @@ type ActionHandlerConfig struct {
 	k8sVersion        string
 	noneSchedulerName string
 	stitchType        stitching.StitchingPropertyType
+
+	// actions on the entities out of this scope are rejected.
+	scope *cluster.Scope
 }
 
-func NewActionHandlerConfig(kubeClient *client.Clientset, kubeletClient *kubelet.KubeletClient, k8sVersion, noneSchedulerName string, stype stitching.StitchingPropertyType) *ActionHandlerConfig {
+func NewActionHandlerConfig(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, kubeletClient *kubelet.KubeletClient, k8sVersion, noneSchedulerName string, stype stitching.StitchingPropertyType) *ActionHandlerConfig {
 	config := &ActionHandlerConfig{
 		kubeClient:    kubeClient,
+		clusterCache:  clusterCache,
 		kubeletClient: kubeletClient,
 
 		k8sVersion:        k8sVersion,
@@ -53,6 +59,12 @@ func NewActionHandlerConfig(kubeClient *client.Clientset, kubeletClient *kubelet
 	return config
 }
 
+// Limit the actions to the pods and nodes in the given scope; a nil scope allows actions on the whole cluster.
+func (c *ActionHandlerConfig) WithScope(scope *cluster.Scope) *ActionHandlerConfig {
+	c.scope = scope
+	return c
+}
+
 type ActionHandler struct {
 	config *ActionHandlerConfig
 
@@ -80,14 +92,14 @@ func NewActionHandler(config *ActionHandlerConfig) *ActionHandler {
 // As action executor is stateless, they can be safely reused.
 func (h *ActionHandler) registerActionExecutors() {
 	c := h.config
-	reScheduler := executor.NewReScheduler(c.kubeClient, c.k8sVersion, c.noneSchedulerName, h.lockMap, c.stitchType)
+	reScheduler := executor.NewReScheduler(c.kubeClient, c.clusterCache, c.scope, c.k8sVersion, c.noneSchedulerName, h.lockMap, c.stitchType)
 	h.actionExecutors[turboActionMove] = reScheduler
 
-	horizontalScaler := executor.NewHorizontalScaler(c.kubeClient, h.lockMap)
+	horizontalScaler := executor.NewHorizontalScaler(c.kubeClient, c.clusterCache, c.scope, h.lockMap)
 	h.actionExecutors[turboActionProvision] = horizontalScaler
 	h.actionExecutors[turboActionUnbind] = horizontalScaler
 
-	containerResizer := executor.NewContainerResizer(c.kubeClient, c.kubeletClient, c.k8sVersion, c.noneSchedulerName, h.lockMap)
+	containerResizer := executor.NewContainerResizer(c.kubeClient, c.clusterCache, c.scope, c.kubeletClient, c.k8sVersion, c.noneSchedulerName, h.lockMap)
 	h.actionExecutors[turboActionContainerResize] = containerResizer
 }
 
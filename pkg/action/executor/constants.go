@@ -14,6 +14,8 @@ const (
 	kindReplicationController     = "ReplicationController"
 	kindReplicaSet                = "ReplicaSet"
 	kindDeployment                = "Deployment"
+	kindStatefulSet               = "StatefulSet"
+	kindDaemonSet                 = "DaemonSet"
 
 	HigherK8sVersion = "1.6.0"
 
@@ -10,6 +10,8 @@ import (
 	api "k8s.io/client-go/pkg/api/v1"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
 	dutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	goutil "github.com/turbonomic/kubeturbo/pkg/util"
@@ -17,14 +19,18 @@ import (
 )
 
 type HorizontalScaler struct {
-	kubeClient *kclient.Clientset
-	lockmap    *util.ExpirationMap
+	kubeClient   *kclient.Clientset
+	clusterCache *cluster.ClusterCache
+	scope        *cluster.Scope
+	lockmap      *util.ExpirationMap
 }
 
-func NewHorizontalScaler(client *kclient.Clientset, lmap *util.ExpirationMap) *HorizontalScaler {
+func NewHorizontalScaler(client *kclient.Clientset, clusterCache *cluster.ClusterCache, scope *cluster.Scope, lmap *util.ExpirationMap) *HorizontalScaler {
 	return &HorizontalScaler{
-		kubeClient: client,
-		lockmap:    lmap,
+		kubeClient:   client,
+		clusterCache: clusterCache,
+		scope:        scope,
+		lockmap:      lmap,
 	}
 }
 
@@ -66,8 +72,9 @@ func (h *HorizontalScaler) preActionCheck(action *proto.ActionItemDTO) error {
 
 	targetSE := action.GetTargetSE()
 	targetEntityType := targetSE.GetEntityType()
-	if targetEntityType != proto.EntityDTO_CONTAINER_POD && targetEntityType != proto.EntityDTO_APPLICATION {
-		msg := fmt.Sprintf("The target type[%v] for scaling action is neither a Pod nor an Application.", targetEntityType.String())
+	if targetEntityType != proto.EntityDTO_CONTAINER_POD && targetEntityType != proto.EntityDTO_APPLICATION &&
+		targetEntityType != dtofactory.WorkloadControllerEntityType {
+		msg := fmt.Sprintf("The target type[%v] for scaling action is neither a Pod, an Application nor a controller.", targetEntityType.String())
 		glog.Errorf(msg)
 		return fmt.Errorf("unsupported target type")
 	}
@@ -75,6 +82,10 @@ func (h *HorizontalScaler) preActionCheck(action *proto.ActionItemDTO) error {
 }
 
 func (h *HorizontalScaler) prepareHelper(action *proto.ActionItemDTO) (*scaleHelper, error) {
+	if action.GetTargetSE().GetEntityType() == dtofactory.WorkloadControllerEntityType {
+		return h.prepareControllerHelper(action)
+	}
+
 	//1. get pod
 	pod, err := h.getProviderPod_hard(action)
 	if err != nil {
@@ -110,6 +121,55 @@ func (h *HorizontalScaler) prepareHelper(action *proto.ActionItemDTO) (*scaleHel
 	return helper, nil
 }
 
+// The controller to scale is the target of the action; its namespace, kind and name are in the entity properties.
+func (h *HorizontalScaler) prepareControllerHelper(action *proto.ActionItemDTO) (*scaleHelper, error) {
+	//1. get controller info
+	nameSpace, kind, name, err := property.GetWorkloadControllerInfoFromProperty(action.GetTargetSE().GetEntityProperties())
+	if err != nil {
+		glog.Errorf("Failed to get controller info of %s: %v", action.GetTargetSE().GetDisplayName(), err)
+		return nil, err
+	}
+	if !h.scope.ContainsNamespace(nameSpace) {
+		err = fmt.Errorf("%s %s/%s is out of the scope of kubeturbo", kind, nameSpace, name)
+		glog.Error(err)
+		return nil, err
+	}
+	helper, _ := NewScaleHelper(h.kubeClient, nameSpace, "")
+
+	//2. get replica diff
+	diff, err := h.getReplicaDiff(action)
+	if err != nil {
+		glog.Errorf("Failed to get ReplicaDiff: %v", err)
+		return nil, err
+	}
+	helper.diff = diff
+
+	//3. set the controller as the parent to scale
+	if err = helper.SetParent(kind, name); err != nil {
+		return nil, err
+	}
+
+	//4. the pods of the controller should be managed by this kubeturbo.
+	podLabels, err := helper.getPodTemplateLabels(h.kubeClient, nameSpace, name)
+	if err != nil {
+		glog.Errorf("Failed to get the pod template of %s %s/%s: %v", kind, nameSpace, name, err)
+		return nil, err
+	}
+	if !h.scope.ContainsPodLabels(nameSpace, podLabels) {
+		err = fmt.Errorf("the pods of %s %s/%s are out of the scope of kubeturbo", kind, nameSpace, name)
+		glog.Error(err)
+		return nil, err
+	}
+
+	//5. set lock info
+	if err = helper.SetupLock(h.lockmap); err != nil {
+		glog.Errorf("Failed to set lock: %v", err)
+		return nil, err
+	}
+
+	return helper, nil
+}
+
 func (h *HorizontalScaler) getReplicaDiff(action *proto.ActionItemDTO) (int32, error) {
 	atype := action.GetActionType()
 	if atype == proto.ActionItemDTO_PROVISION {
@@ -217,7 +277,15 @@ func (h *HorizontalScaler) getProviderPod_hard(action *proto.ActionItemDTO) (*ap
 		return nil, err
 	}
 
-	return util.GetPodFromUUID(h.kubeClient, podId)
+	pod, err := util.GetPodFromUUID(h.kubeClient, h.clusterCache, podId)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.CheckPodInScope(h.scope, pod); err != nil {
+		glog.Error(err)
+		return nil, err
+	}
+	return pod, nil
 }
 
 // getProviderPod, "easy" means that we will get Pod info from Entity properties
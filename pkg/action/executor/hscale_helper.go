@@ -12,12 +12,14 @@ import (
 
 type updateReplicaNumFunc func(client *kclient.Clientset, nameSpace, name string, diff int32) error
 
+type getPodTemplateLabelsFunc func(client *kclient.Clientset, nameSpace, name string) (map[string]string, error)
+
 type scaleHelper struct {
 	client    *kclient.Clientset
 	nameSpace string
 	podName   string
 
-	//parent controller's kind: ReplicationController/ReplicaSet
+	//parent controller's kind: ReplicationController/ReplicaSet/Deployment/StatefulSet
 	kind string
 	//parent controller's name
 	controllerName string
@@ -25,6 +27,8 @@ type scaleHelper struct {
 
 	// update number of Replicas of parent controller
 	updateReplicaNum updateReplicaNumFunc
+	// get the labels of the pods created by the parent controller
+	getPodTemplateLabels getPodTemplateLabelsFunc
 
 	//concurrent control lock.map
 	locker *util.LockHelper
@@ -48,10 +52,21 @@ func (helper *scaleHelper) SetParent(kind, name string) error {
 	switch kind {
 	case kindReplicationController:
 		helper.updateReplicaNum = updateRCReplicaNum
+		helper.getPodTemplateLabels = getRCPodTemplateLabels
 	case kindReplicaSet:
 		helper.updateReplicaNum = updateRSReplicaNum
+		helper.getPodTemplateLabels = getRSPodTemplateLabels
 	case kindDeployment:
 		helper.updateReplicaNum = updateDeploymentReplicaNum
+		helper.getPodTemplateLabels = getDeploymentPodTemplateLabels
+	case kindStatefulSet:
+		helper.updateReplicaNum = updateStatefulSetReplicaNum
+		helper.getPodTemplateLabels = getStatefulSetPodTemplateLabels
+	case kindDaemonSet:
+		// A DaemonSet runs one pod on each eligible node; its number of pods is not a replica count to scale.
+		err := fmt.Errorf("Cannot scale Pod of DaemonSet[%s/%s]: the number of its pods follows the nodes.", helper.nameSpace, name)
+		glog.Error(err)
+		return err
 	default:
 		err := fmt.Errorf("Unsupport ControllerType[%s] for scaling Pod.", kind)
 		glog.Errorf(err.Error())
@@ -210,3 +225,73 @@ func updateDeploymentReplicaNum(client *kclient.Clientset, namespace, name strin
 
 	return nil
 }
+
+// update the number of pod replicas for StatefulSet
+func updateStatefulSetReplicaNum(client *kclient.Clientset, namespace, name string, diff int32) error {
+	ssClient := client.AppsV1beta1().StatefulSets(namespace)
+
+	//1. get it
+	fullName := fmt.Sprintf("%s/%s", namespace, name)
+	getOption := metav1.GetOptions{}
+	ss, err := ssClient.Get(name, getOption)
+	if err != nil {
+		glog.Errorf("Failed to get StatefulSet: %s: %v", fullName, err)
+		return err
+	}
+
+	//2. modify it
+	num, err := setNum(*(ss.Spec.Replicas), diff)
+	if err != nil {
+		glog.Warningf("StatefulSet-%s resulting replica num[%v] less than 0. (diff=%v)", fullName, num, diff)
+		return fmt.Errorf("Aborted")
+	}
+	ss.Spec.Replicas = &num
+
+	//3. update it
+	_, err = ssClient.Update(ss)
+	if err != nil {
+		glog.Errorf("Failed to update StatefulSet[%s]: %v", fullName, err)
+		return fmt.Errorf("Failed")
+	}
+
+	return nil
+}
+
+// get the labels of the pod template of ReplicationController
+func getRCPodTemplateLabels(client *kclient.Clientset, namespace, name string) (map[string]string, error) {
+	rc, err := client.CoreV1().ReplicationControllers(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ReplicationController %s/%s: %v", namespace, name, err)
+	}
+	if rc.Spec.Template == nil {
+		return nil, nil
+	}
+	return rc.Spec.Template.Labels, nil
+}
+
+// get the labels of the pod template of ReplicaSet
+func getRSPodTemplateLabels(client *kclient.Clientset, namespace, name string) (map[string]string, error) {
+	rs, err := client.ExtensionsV1beta1().ReplicaSets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ReplicaSet %s/%s: %v", namespace, name, err)
+	}
+	return rs.Spec.Template.Labels, nil
+}
+
+// get the labels of the pod template of Deployment
+func getDeploymentPodTemplateLabels(client *kclient.Clientset, namespace, name string) (map[string]string, error) {
+	dep, err := client.AppsV1beta1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Deployment %s/%s: %v", namespace, name, err)
+	}
+	return dep.Spec.Template.Labels, nil
+}
+
+// get the labels of the pod template of StatefulSet
+func getStatefulSetPodTemplateLabels(client *kclient.Clientset, namespace, name string) (map[string]string, error) {
+	ss, err := client.AppsV1beta1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get StatefulSet %s/%s: %v", namespace, name, err)
+	}
+	return ss.Spec.Template.Labels, nil
+}
@@ -0,0 +1,34 @@
+package executor
+
+import (
+	"testing"
+)
+
+func TestScaleHelper_SetParent(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{kind: kindReplicationController},
+		{kind: kindReplicaSet},
+		{kind: kindDeployment},
+		{kind: kindStatefulSet},
+		{kind: kindDaemonSet, wantErr: true},
+		{kind: "Job", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		helper, _ := NewScaleHelper(nil, "default", "pod-1")
+		err := helper.SetParent(tt.kind, "parent")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetParent(%s): expected error %v, got %v", tt.kind, tt.wantErr, err)
+			continue
+		}
+		if !tt.wantErr && helper.updateReplicaNum == nil {
+			t.Errorf("SetParent(%s): no function to update the number of replicas", tt.kind)
+		}
+		if !tt.wantErr && helper.getPodTemplateLabels == nil {
+			t.Errorf("SetParent(%s): no function to get the labels of the pods", tt.kind)
+		}
+	}
+}
@@ -3,11 +3,13 @@ package executor
 import (
 	"fmt"
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	kclient "k8s.io/client-go/kubernetes"
 	api "k8s.io/client-go/pkg/api/v1"
 	"time"
 
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	dutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	goutil "github.com/turbonomic/kubeturbo/pkg/util"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
@@ -17,6 +19,8 @@ import (
 
 type ReScheduler struct {
 	kubeClient        *kclient.Clientset
+	clusterCache      *cluster.ClusterCache
+	scope             *cluster.Scope
 	k8sVersion        string
 	noneSchedulerName string
 	stitchType        stitching.StitchingPropertyType
@@ -24,9 +28,11 @@ type ReScheduler struct {
 	lockMap *util.ExpirationMap
 }
 
-func NewReScheduler(client *kclient.Clientset, k8sver, noschedulerName string, lmap *util.ExpirationMap, stype stitching.StitchingPropertyType) *ReScheduler {
+func NewReScheduler(client *kclient.Clientset, clusterCache *cluster.ClusterCache, scope *cluster.Scope, k8sver, noschedulerName string, lmap *util.ExpirationMap, stype stitching.StitchingPropertyType) *ReScheduler {
 	return &ReScheduler{
 		kubeClient:        client,
+		clusterCache:      clusterCache,
+		scope:             scope,
 		k8sVersion:        k8sver,
 		noneSchedulerName: noschedulerName,
 		lockMap:           lmap,
@@ -92,7 +98,7 @@ func (r *ReScheduler) getNode(action *proto.ActionItemDTO) (*api.Node, error) {
 	var node *api.Node = nil
 
 	if r.stitchType == stitching.UUID {
-		node, err = util.GetNodebyUUID(r.kubeClient, hostSE.GetId())
+		node, err = util.GetNodebyUUID(r.kubeClient, r.clusterCache, hostSE.GetId())
 	} else if r.stitchType == stitching.IP {
 		var machineIPs []string
 		if hostSE.GetEntityType() == proto.EntityDTO_VIRTUAL_MACHINE {
@@ -109,7 +115,7 @@ func (r *ReScheduler) getNode(action *proto.ActionItemDTO) (*api.Node, error) {
 			return nil, err
 		}
 
-		node, err = util.GetNodebyIP(r.kubeClient, machineIPs)
+		node, err = util.GetNodebyIP(r.kubeClient, r.clusterCache, machineIPs)
 	} else {
 		err = fmt.Errorf("Unknown stitching type: %v", r.stitchType)
 	}
@@ -133,7 +139,7 @@ func (r *ReScheduler) getPodNode(action *proto.ActionItemDTO) (*api.Pod, *api.No
 
 	//2. get pod from k8s
 	target := action.GetTargetSE()
-	pod, err := util.GetPodFromUUID(r.kubeClient, target.GetId())
+	pod, err := util.GetPodFromUUID(r.kubeClient, r.clusterCache, target.GetId())
 	if err != nil {
 		err = fmt.Errorf("Move Action aborted: failed to find pod(%v) in k8s: %v", target.GetDisplayName(), err)
 		glog.Errorf(err.Error())
@@ -148,6 +154,18 @@ func (r *ReScheduler) getPodNode(action *proto.ActionItemDTO) (*api.Pod, *api.No
 		return nil, nil, err
 	}
 
+	//4. both the pod and the new hosting node should be managed by this kubeturbo.
+	if err := util.CheckPodInScope(r.scope, pod); err != nil {
+		err = fmt.Errorf("Move action aborted: %v", err)
+		glog.Error(err.Error())
+		return nil, nil, err
+	}
+	if err := util.CheckNodeInScope(r.scope, node); err != nil {
+		err = fmt.Errorf("Move action aborted: %v", err)
+		glog.Error(err.Error())
+		return nil, nil, err
+	}
+
 	return pod, node, nil
 }
 
@@ -167,6 +185,17 @@ func (r *ReScheduler) preActionCheck(pod *api.Pod, node *api.Node) error {
 		glog.Errorf("Move action should be aborted: pod[%v]'s new destination host is not running: %v", fullName, node.Status.Phase)
 	}
 
+	//3. the pod is pinned by its persistent volumes to their zones.
+	pvs, err := util.GetPodPersistentVolumes(r.kubeClient, r.clusterCache, pod)
+	if err != nil {
+		return err
+	}
+	for _, pv := range pvs {
+		if err := dutil.CheckPersistentVolumeTopology(pv, node); err != nil {
+			return fmt.Errorf("pod[%v] cannot be moved to node[%v]: %v", fullName, node.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	k8sapi "k8s.io/client-go/pkg/api/v1"
 
 	"github.com/turbonomic/kubeturbo/pkg/action/util"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	idutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	goutil "github.com/turbonomic/kubeturbo/pkg/util"
@@ -19,12 +20,17 @@ type containerResizeSpec struct {
 	// the new capacity of the resources
 	NewCapacity k8sapi.ResourceList
 
+	// the new requests of the resources, resized independently of their capacity
+	NewRequest k8sapi.ResourceList
+
 	// index of Pod's containers
 	Index int
 }
 
 type ContainerResizer struct {
 	kubeClient        *kclient.Clientset
+	clusterCache      *cluster.ClusterCache
+	scope             *cluster.Scope
 	kubeletClient     *kubelet.KubeletClient
 	k8sVersion        string
 	noneSchedulerName string
@@ -34,9 +40,11 @@ type ContainerResizer struct {
 	lockMap *util.ExpirationMap
 }
 
-func NewContainerResizer(client *kclient.Clientset, kubeletClient *kubelet.KubeletClient, k8sver, noschedulerName string, lmap *util.ExpirationMap) *ContainerResizer {
+func NewContainerResizer(client *kclient.Clientset, clusterCache *cluster.ClusterCache, scope *cluster.Scope, kubeletClient *kubelet.KubeletClient, k8sver, noschedulerName string, lmap *util.ExpirationMap) *ContainerResizer {
 	return &ContainerResizer{
 		kubeClient:        client,
+		clusterCache:      clusterCache,
+		scope:             scope,
 		kubeletClient:     kubeletClient,
 		k8sVersion:        k8sver,
 		noneSchedulerName: noschedulerName,
@@ -49,7 +57,7 @@ func (r *ContainerResizer) getNodeCPUFrequency(host string) (uint64, error) {
 	return r.kubeletClient.GetMachineCpuFrequency(host)
 }
 
-func (r *ContainerResizer) setCPUCapacity(cpuMhz float64, host string, rlist k8sapi.ResourceList) error {
+func (r *ContainerResizer) setCPUQuantity(cpuMhz float64, host string, rlist k8sapi.ResourceList) error {
 	cpuFrequency, err := r.getNodeCPUFrequency(host)
 	if err != nil {
 		glog.Errorf("failed to get node[%s] cpu frequency: %v", host, err)
@@ -67,6 +75,8 @@ func (r *ContainerResizer) setCPUCapacity(cpuMhz float64, host string, rlist k8s
 }
 
 // get commodity type and new capacity, and convert it into a k8s.Quantity.
+// The capacity of VCPU and VMEM is the limit of the container; the capacity of CPUProvisioned and MemProvisioned
+// is its request.
 func (r *ContainerResizer) buildNewCapacity(pod *k8sapi.Pod, actionItem *proto.ActionItemDTO) (k8sapi.ResourceList, error) {
 	result := make(k8sapi.ResourceList)
 
@@ -74,28 +84,21 @@ func (r *ContainerResizer) buildNewCapacity(pod *k8sapi.Pod, actionItem *proto.A
 	ctype := comm.GetCommodityType()
 	amount := comm.GetCapacity()
 
-	//TODO: currently only support resizeCapacity; resizeReservation may be supported later
 	if amount < 1 {
-		msg := ""
-		if comm.GetReservation() > 0 {
-			msg = fmt.Sprintf("resizeReservation() is not supported yet.")
-		} else {
-			msg = fmt.Sprintf("new capacity should be bigger than zero (current=%.4f)", amount)
-		}
-
-		glog.Error(msg)
-		return result, fmt.Errorf(msg)
+		err := fmt.Errorf("new capacity should be bigger than zero (current=%.4f)", amount)
+		glog.Error(err)
+		return result, err
 	}
 
 	switch ctype {
-	case proto.CommodityDTO_VCPU:
+	case proto.CommodityDTO_VCPU, proto.CommodityDTO_CPU_PROVISIONED:
 		host := pod.Spec.NodeName
-		err := r.setCPUCapacity(amount, host, result)
+		err := r.setCPUQuantity(amount, host, result)
 		if err != nil {
 			glog.Errorf("failed to build cpu.Capacity: %v", err)
 			return result, err
 		}
-	case proto.CommodityDTO_VMEM:
+	case proto.CommodityDTO_VMEM, proto.CommodityDTO_MEM_PROVISIONED:
 		memory, err := genMemoryQuantity(amount)
 		if err != nil {
 			glog.Errorf("failed to build mem.Capacity: %v", err)
@@ -111,6 +114,11 @@ func (r *ContainerResizer) buildNewCapacity(pod *k8sapi.Pod, actionItem *proto.A
 	return result, nil
 }
 
+// Whether the commodity resized stands for the request of the container instead of its limit.
+func isRequestCommodity(ctype proto.CommodityDTO_CommodityType) bool {
+	return ctype == proto.CommodityDTO_CPU_PROVISIONED || ctype == proto.CommodityDTO_MEM_PROVISIONED
+}
+
 func (r *ContainerResizer) buildResizeAction(actionItem *proto.ActionItemDTO) (*containerResizeSpec, *k8sapi.Pod, error) {
 
 	//1. get hosting Pod and containerIndex
@@ -131,11 +139,15 @@ func (r *ContainerResizer) buildResizeAction(actionItem *proto.ActionItemDTO) (*
 		return nil, nil, err
 	}
 
-	pod, err := util.GetPodFromUUID(r.kubeClient, podId)
+	pod, err := util.GetPodFromUUID(r.kubeClient, r.clusterCache, podId)
 	if err != nil {
 		glog.Errorf("failed to get hosting Pod to build resizeAction: %v", err)
 		return nil, nil, err
 	}
+	if err := util.CheckPodInScope(r.scope, pod); err != nil {
+		glog.Errorf("failed to build resizeAction: %v", err)
+		return nil, nil, err
+	}
 
 	//2. build the new resource Capacity
 	newCapacity, err := r.buildNewCapacity(pod, actionItem)
@@ -146,8 +158,12 @@ func (r *ContainerResizer) buildResizeAction(actionItem *proto.ActionItemDTO) (*
 
 	//3. build the turboAction object
 	resizeSpec := &containerResizeSpec{
-		Index:       containerIndex,
-		NewCapacity: newCapacity,
+		Index: containerIndex,
+	}
+	if isRequestCommodity(actionItem.GetNewComm().GetCommodityType()) {
+		resizeSpec.NewRequest = newCapacity
+	} else {
+		resizeSpec.NewCapacity = newCapacity
 	}
 
 	return resizeSpec, pod, nil
@@ -187,7 +203,7 @@ func (r *ContainerResizer) Execute(actionItem *proto.ActionItemDTO) error {
 
 func (r *ContainerResizer) executeAction(resizeSpec *containerResizeSpec, pod *k8sapi.Pod) error {
 	//1. check
-	if len(resizeSpec.NewCapacity) < 1 {
+	if len(resizeSpec.NewCapacity) < 1 && len(resizeSpec.NewRequest) < 1 {
 		glog.Warningf("Resize specification is empty.")
 		return nil
 	}
@@ -201,9 +217,9 @@ func (r *ContainerResizer) executeAction(resizeSpec *containerResizeSpec, pod *k
 	}
 
 	if parentKind == "" {
-		err = r.resizeBarePodContainer(pod, resizeSpec.Index, resizeSpec.NewCapacity)
+		err = r.resizeBarePodContainer(pod, resizeSpec)
 	} else {
-		err = r.resizeControllerContainer(pod, parentKind, parentName, resizeSpec.Index, resizeSpec.NewCapacity)
+		err = r.resizeControllerContainer(pod, parentKind, parentName, resizeSpec)
 	}
 
 	if err != nil {
@@ -213,8 +229,8 @@ func (r *ContainerResizer) executeAction(resizeSpec *containerResizeSpec, pod *k
 	return nil
 }
 
-func (r *ContainerResizer) resizeControllerContainer(pod *k8sapi.Pod, parentKind, parentName string, index int, capacity k8sapi.ResourceList) error {
-	id := fmt.Sprintf("%s/%s-%d", pod.Namespace, pod.Name, index)
+func (r *ContainerResizer) resizeControllerContainer(pod *k8sapi.Pod, parentKind, parentName string, spec *containerResizeSpec) error {
+	id := fmt.Sprintf("%s/%s-%d", pod.Namespace, pod.Name, spec.Index)
 	glog.V(2).Infof("begin to resizeContainer[%s] parent=%s/%s.", id, parentKind, parentName)
 
 	//1. set up
@@ -263,7 +279,7 @@ func (r *ContainerResizer) resizeControllerContainer(pod *k8sapi.Pod, parentKind
 
 	//5.resize Container and restore parent's scheduler
 	helper.SetScheduler(preScheduler)
-	err = resizeContainer(r.kubeClient, pod, index, capacity, defaultRetryLess)
+	err = resizeContainer(r.kubeClient, pod, spec, defaultRetryLess)
 	if err != nil {
 		glog.Errorf("resizeContainer failed[%s]: %v", id, err)
 		return fmt.Errorf("TryLater")
@@ -272,7 +288,7 @@ func (r *ContainerResizer) resizeControllerContainer(pod *k8sapi.Pod, parentKind
 	return nil
 }
 
-func (r *ContainerResizer) resizeBarePodContainer(pod *k8sapi.Pod, index int, capacity k8sapi.ResourceList) error {
+func (r *ContainerResizer) resizeBarePodContainer(pod *k8sapi.Pod, spec *containerResizeSpec) error {
 	podkey := util.BuildIdentifier(pod.Namespace, pod.Name)
 	// 1. setup lockHelper
 	helper, err := util.NewLockHelper(podkey, r.lockMap)
@@ -292,7 +308,7 @@ func (r *ContainerResizer) resizeBarePodContainer(pod *k8sapi.Pod, index int, ca
 
 	// 3. resize Pod.container
 	helper.KeepRenewLock()
-	err = resizeContainer(r.kubeClient, pod, index, capacity, defaultRetryMore)
+	err = resizeContainer(r.kubeClient, pod, spec, defaultRetryMore)
 	return err
 }
 
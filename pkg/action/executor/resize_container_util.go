@@ -54,6 +54,44 @@ func updateCapacity(pod *k8sapi.Pod, index int, patchCapacity k8sapi.ResourceLis
 	return changed, nil
 }
 
+// update the Pod.Containers[index]'s Resources.Requests, leaving its Resources.Limits unchanged.
+// A request cannot exceed the limit of the resource.
+func updateRequest(pod *k8sapi.Pod, index int, patchRequest k8sapi.ResourceList) (bool, error) {
+	if len(patchRequest) < 1 {
+		return false, nil
+	}
+	if index >= len(pod.Spec.Containers) {
+		err := fmt.Errorf("Cannot find container[%d] in pod[%s]", index, pod.Name)
+		glog.Error(err)
+		return false, err
+	}
+	container := &(pod.Spec.Containers[index])
+
+	changed := false
+	requests := make(k8sapi.ResourceList)
+	for k, v := range container.Resources.Requests {
+		requests[k] = v
+	}
+	for k, v := range patchRequest {
+		if limit, exist := container.Resources.Limits[k]; exist && v.Cmp(limit) > 0 {
+			err := fmt.Errorf("new request of %s (%s) exceeds the limit (%s) of container[%d] in pod[%s]",
+				k, v.String(), limit.String(), index, pod.Name)
+			glog.Error(err)
+			return false, err
+		}
+		oldv, exist := requests[k]
+		if !exist || oldv.Cmp(v) != 0 {
+			requests[k] = v
+			changed = true
+		}
+	}
+
+	if changed {
+		container.Resources.Requests = requests
+	}
+	return changed, nil
+}
+
 func updateRequests(container *k8sapi.Container, limits k8sapi.ResourceList) error {
 	zero := resource.NewQuantity(0, resource.BinarySI)
 	glog.V(4).Infof("zero=%++v", zero)
@@ -114,8 +152,8 @@ func genMemoryQuantity(newValue float64) (resource.Quantity, error) {
 	return result, nil
 }
 
-func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, index int, capacity k8sapi.ResourceList, retryNum int) error {
-	id := fmt.Sprintf("%s/%s-%d", pod.Namespace, pod.Name, index)
+func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, spec *containerResizeSpec, retryNum int) error {
+	id := fmt.Sprintf("%s/%s-%d", pod.Namespace, pod.Name, spec.Index)
 	glog.V(2).Infof("begin to resize Pod container[%s].", id)
 
 	podClient := client.CoreV1().Pods(pod.Namespace)
@@ -130,11 +168,18 @@ func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, index int, capa
 	copyPodInfo(pod, npod)
 	npod.Spec.NodeName = pod.Spec.NodeName
 
-	//2. update resource capacity
-	if flag, err := updateCapacity(npod, index, capacity); err != nil {
+	//2. update resource capacity and requests
+	capacityChanged, err := updateCapacity(npod, spec.Index, spec.NewCapacity)
+	if err != nil {
 		glog.Errorf("resizeContainer failed [%s]: failed to update container Capacity: %v", id, err)
 		return err
-	} else if !flag {
+	}
+	requestChanged, err := updateRequest(npod, spec.Index, spec.NewRequest)
+	if err != nil {
+		glog.Errorf("resizeContainer failed [%s]: failed to update container Requests: %v", id, err)
+		return err
+	}
+	if !capacityChanged && !requestChanged {
 		glog.V(2).Infof("resizeContainer aborted [%s]: no need to resize.", id)
 		return nil
 	}
@@ -154,7 +199,7 @@ func resizeContainer(client *kclient.Clientset, pod *k8sapi.Pod, index int, capa
 
 	interval := defaultPodCreateSleep
 	timeout := interval*time.Duration(retryNum) + time.Second*10
-	err := util.RetryDuring(retryNum, timeout, interval, func() error {
+	err = util.RetryDuring(retryNum, timeout, interval, func() error {
 		_, inerr := podClient.Create(npod)
 		return inerr
 	})
@@ -150,3 +150,36 @@ func TestUpdateCapacity(t *testing.T) {
 	//printResourceList(container.Resources.Limits)
 	//printResourceList(container.Resources.Requests)
 }
+
+func TestUpdateRequest(t *testing.T) {
+	pod := &(k8sapi.Pod{})
+	pod.Spec.Containers = append(pod.Spec.Containers, k8sapi.Container{Name: "hello"})
+	container := &(pod.Spec.Containers[0])
+	setContainerResourceLimit(container, 300, 410)
+	container.Resources.Requests, _ = generateResourceList(100, 200)
+
+	patch, err := generateResourceList(250, 300)
+	if err != nil {
+		t.Errorf("unable to test: %v", err)
+	}
+	changed, err := updateRequest(pod, 0, patch)
+	if err != nil || !changed {
+		t.Errorf("failed to update requests: changed=%v, err=%v", changed, err)
+	}
+	if err := compareResourceList(container.Resources.Requests, 250, 300); err != nil {
+		t.Error(err)
+	}
+	// the limits are not touched.
+	if err := compareResourceList(container.Resources.Limits, 300, 410); err != nil {
+		t.Error(err)
+	}
+
+	// a request cannot exceed the limit.
+	patch, _ = generateResourceList(350, 300)
+	if _, err := updateRequest(pod, 0, patch); err == nil {
+		t.Errorf("expected an error for a request exceeding the limit")
+	}
+	if err := compareResourceList(container.Resources.Requests, 250, 300); err != nil {
+		t.Error(err)
+	}
+}
@@ -7,10 +7,13 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	client "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 	api "k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/pkg/apis/apps/v1beta1"
 
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	dutil "github.com/turbonomic/kubeturbo/pkg/discovery/util"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
@@ -135,8 +138,16 @@ func GetAllNodes(kubeClient *client.Clientset) ([]api.Node, error) {
 }
 
 // Iterate all nodes to find the name of the node which has the provided IP address.
+// The nodes are read from the cluster cache if it is available and synced.
 // TODO. We can also create a IP->NodeName map to save time. But it consumes space.
-func GetNodebyIP(kubeClient *client.Clientset, machineIPs []string) (*api.Node, error) {
+func GetNodebyIP(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, machineIPs []string) (*api.Node, error) {
+	if cacheSynced(clusterCache, cluster.NodeResource) {
+		node, err := clusterCache.GetNodeByIP(machineIPs)
+		if err != nil {
+			return nil, err
+		}
+		return copyNode(node)
+	}
 	ipAddresses := machineIPs
 	allNodes, err := GetAllNodes(kubeClient)
 	if err != nil {
@@ -156,8 +167,16 @@ func GetNodebyIP(kubeClient *client.Clientset, machineIPs []string) (*api.Node,
 	return nil, fmt.Errorf("Cannot find node with IPs %s", ipAddresses)
 }
 
-// Iterate all nodes to find the name of the node which has the provided IP address.
-func GetNodebyUUID(kubeClient *client.Clientset, uuid string) (*api.Node, error) {
+// Iterate all nodes to find the name of the node which has the provided UUID.
+// The nodes are read from the cluster cache if it is available and synced.
+func GetNodebyUUID(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, uuid string) (*api.Node, error) {
+	if cacheSynced(clusterCache, cluster.NodeResource) {
+		node, err := clusterCache.GetNodeByUUID(uuid)
+		if err != nil {
+			return nil, err
+		}
+		return copyNode(node)
+	}
 	allNodes, err := GetAllNodes(kubeClient)
 	if err != nil {
 		return nil, err
@@ -191,9 +210,17 @@ func GetPodFromProperties(kubeClient *client.Clientset, entityType proto.EntityD
 	return kubeClient.CoreV1().Pods(podNamespace).Get(podName, metav1.GetOptions{})
 }
 
-// Get a pod instance from the uuid of a pod. Since there is no support for uuid lookup, we have to get all the pods
-// and then find the correct pod based on uuid match.
-func GetPodFromUUID(kubeClient *client.Clientset, podUUID string) (*api.Pod, error) {
+// Get a pod instance from the uuid of a pod. The pod is looked up in the cluster cache if it is available and synced.
+// Otherwise, since there is no support for uuid lookup, we have to get all the pods and then find the correct pod
+// based on uuid match.
+func GetPodFromUUID(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, podUUID string) (*api.Pod, error) {
+	if cacheSynced(clusterCache, cluster.PodResource) {
+		pod, err := clusterCache.GetPodByUID(podUUID)
+		if err != nil {
+			return nil, err
+		}
+		return copyPod(pod)
+	}
 	namespace := api.NamespaceAll
 	podList, err := kubeClient.CoreV1().Pods(namespace).List(listOption)
 	if err != nil {
@@ -207,8 +234,79 @@ func GetPodFromUUID(kubeClient *client.Clientset, podUUID string) (*api.Pod, err
 	return nil, fmt.Errorf("cannot find pod based on given uuid: %s", podUUID)
 }
 
+// Get the persistent volumes mounted by the pod through its bound claims. The claims and volumes are read from
+// the cluster cache if it is available and synced.
+func GetPodPersistentVolumes(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache,
+	pod *api.Pod) ([]*api.PersistentVolume, error) {
+	var pvs []*api.PersistentVolume
+	if cacheSynced(clusterCache, cluster.PersistentVolumeClaimResource) &&
+		cacheSynced(clusterCache, cluster.PersistentVolumeResource) {
+		volumesByClaim := dutil.GetPersistentVolumesByClaim(clusterCache.GetPersistentVolumeClaims(pod.Namespace),
+			clusterCache.GetPersistentVolumes())
+		for _, podVolume := range dutil.GetPodPersistentVolumes(pod, volumesByClaim) {
+			pvs = append(pvs, podVolume.PersistentVolume)
+		}
+		return pvs, nil
+	}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(claimName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume claim %s/%s: %v", pod.Namespace, claimName, err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, err := kubeClient.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get persistent volume %s: %v", pvc.Spec.VolumeName, err)
+		}
+		pvs = append(pvs, pv)
+	}
+	return pvs, nil
+}
+
+func cacheSynced(clusterCache *cluster.ClusterCache, resource string) bool {
+	return clusterCache != nil && clusterCache.ResourceSynced(resource)
+}
+
+// Objects in the cluster cache are shared; copy them as the action executors may modify them.
+func copyNode(node *api.Node) (*api.Node, error) {
+	obj, err := scheme.Scheme.Copy(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy node %s: %v", node.Name, err)
+	}
+	return obj.(*api.Node), nil
+}
+
+func copyPod(pod *api.Pod) (*api.Pod, error) {
+	obj, err := scheme.Scheme.Copy(pod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	return obj.(*api.Pod), nil
+}
+
+// Actions are only executed on the pods and nodes within the scope of this kubeturbo.
+func CheckPodInScope(scope *cluster.Scope, pod *api.Pod) error {
+	if !scope.ContainsPod(pod) {
+		return fmt.Errorf("pod %s/%s is out of the scope of kubeturbo (%v)", pod.Namespace, pod.Name, scope)
+	}
+	return nil
+}
+
+func CheckNodeInScope(scope *cluster.Scope, node *api.Node) error {
+	if !scope.ContainsNode(node) {
+		return fmt.Errorf("node %s is out of the scope of kubeturbo (%v)", node.Name, scope)
+	}
+	return nil
+}
+
 // Find which pod is the app running based on the received action request.
-func FindApplicationPodProvider(kubeClient *client.Clientset, providers []*proto.ActionItemDTO_ProviderInfo) (*api.Pod, error) {
+func FindApplicationPodProvider(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, providers []*proto.ActionItemDTO_ProviderInfo) (*api.Pod, error) {
 	if providers == nil || len(providers) < 1 {
 		return nil, errors.New("Cannot find any provider.")
 	}
@@ -220,7 +318,7 @@ func FindApplicationPodProvider(kubeClient *client.Clientset, providers []*proto
 		if providerInfo.GetEntityType() == proto.EntityDTO_CONTAINER_POD {
 			providerIDs := providerInfo.GetIds()
 			for _, id := range providerIDs {
-				podProvider, err := GetPodFromUUID(kubeClient, id)
+				podProvider, err := GetPodFromUUID(kubeClient, clusterCache, id)
 				if err != nil {
 					glog.Errorf("Error getting pod provider from pod identifier %s", id)
 					continue
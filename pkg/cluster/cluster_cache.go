@@ -0,0 +1,427 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	client "k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+	apps "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	storage "k8s.io/client-go/pkg/apis/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// DefaultCacheResyncPeriod is how often the informers re-list their resources to correct any missed events.
+	DefaultCacheResyncPeriod = 10 * time.Minute
+
+	// Once an informer has not synced for this long, it is reported as an error until it syncs.
+	cacheSyncDeadline      = 5 * time.Minute
+	cacheSyncCheckInterval = time.Minute
+
+	podNodeNameIndex = "nodeName"
+	podUIDIndex      = "uid"
+)
+
+// The resources kept in the cluster cache. Each of them is synced by its own informer.
+const (
+	NodeResource                  = "nodes"
+	PodResource                   = "pods"
+	ServiceResource               = "services"
+	EndpointsResource             = "endpoints"
+	ReplicationControllerResource = "replicationcontrollers"
+	ReplicaSetResource            = "replicasets"
+	DeploymentResource            = "deployments"
+	StatefulSetResource           = "statefulsets"
+	DaemonSetResource             = "daemonsets"
+	ResourceQuotaResource         = "resourcequotas"
+	PersistentVolumeResource      = "persistentvolumes"
+	PersistentVolumeClaimResource = "persistentvolumeclaims"
+	StorageClassResource          = "storageclasses"
+)
+
+// ClusterCache keeps a local, watch-driven copy of the cluster objects needed by discovery and action execution,
+// so that they do not have to be listed from the API server again and again.
+// The objects returned by the cache are shared, and must not be modified.
+type ClusterCache struct {
+	nodeInformer         cache.SharedIndexInformer
+	podInformer          cache.SharedIndexInformer
+	serviceInformer      cache.SharedIndexInformer
+	endpointsInformer    cache.SharedIndexInformer
+	rcInformer           cache.SharedIndexInformer
+	replicaSetInformer   cache.SharedIndexInformer
+	deploymentInformer   cache.SharedIndexInformer
+	statefulSetInformer  cache.SharedIndexInformer
+	daemonSetInformer    cache.SharedIndexInformer
+	quotaInformer        cache.SharedIndexInformer
+	pvInformer           cache.SharedIndexInformer
+	pvcInformer          cache.SharedIndexInformer
+	storageClassInformer cache.SharedIndexInformer
+
+	// Whether the informer of each resource has finished its initial list. key: resource; value: sync check.
+	synced map[string]cache.InformerSynced
+}
+
+func NewClusterCache(kubeClient *client.Clientset, resyncPeriod time.Duration) *ClusterCache {
+	coreClient := kubeClient.CoreV1().RESTClient()
+	extensionsClient := kubeClient.ExtensionsV1beta1().RESTClient()
+	appsClient := kubeClient.AppsV1beta1().RESTClient()
+	storageClient := kubeClient.StorageV1().RESTClient()
+	newInformer := func(c cache.Getter, resource string, objType runtime.Object, indexers cache.Indexers) cache.SharedIndexInformer {
+		lw := cache.NewListWatchFromClient(c, resource, api.NamespaceAll, fields.Everything())
+		indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
+		return cache.NewSharedIndexInformer(lw, objType, resyncPeriod, indexers)
+	}
+
+	c := &ClusterCache{
+		nodeInformer: newInformer(coreClient, NodeResource, &api.Node{}, cache.Indexers{}),
+		podInformer: newInformer(coreClient, PodResource, &api.Pod{}, cache.Indexers{
+			podNodeNameIndex: podNodeNameIndexFunc,
+			podUIDIndex:      podUIDIndexFunc,
+		}),
+		serviceInformer:      newInformer(coreClient, ServiceResource, &api.Service{}, cache.Indexers{}),
+		endpointsInformer:    newInformer(coreClient, EndpointsResource, &api.Endpoints{}, cache.Indexers{}),
+		rcInformer:           newInformer(coreClient, ReplicationControllerResource, &api.ReplicationController{}, cache.Indexers{}),
+		replicaSetInformer:   newInformer(extensionsClient, ReplicaSetResource, &extensions.ReplicaSet{}, cache.Indexers{}),
+		deploymentInformer:   newInformer(extensionsClient, DeploymentResource, &extensions.Deployment{}, cache.Indexers{}),
+		statefulSetInformer:  newInformer(appsClient, StatefulSetResource, &apps.StatefulSet{}, cache.Indexers{}),
+		daemonSetInformer:    newInformer(extensionsClient, DaemonSetResource, &extensions.DaemonSet{}, cache.Indexers{}),
+		quotaInformer:        newInformer(coreClient, ResourceQuotaResource, &api.ResourceQuota{}, cache.Indexers{}),
+		pvInformer:           newInformer(coreClient, PersistentVolumeResource, &api.PersistentVolume{}, cache.Indexers{}),
+		pvcInformer:          newInformer(coreClient, PersistentVolumeClaimResource, &api.PersistentVolumeClaim{}, cache.Indexers{}),
+		storageClassInformer: newInformer(storageClient, StorageClassResource, &storage.StorageClass{}, cache.Indexers{}),
+	}
+	c.synced = make(map[string]cache.InformerSynced)
+	for resource, informer := range c.informers() {
+		c.synced[resource] = informer.HasSynced
+	}
+	return c
+}
+
+func podNodeNameIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("object is not a pod: %v", obj)
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+func podUIDIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api.Pod)
+	if !ok {
+		return nil, fmt.Errorf("object is not a pod: %v", obj)
+	}
+	return []string{string(pod.UID)}, nil
+}
+
+func (c *ClusterCache) informers() map[string]cache.SharedIndexInformer {
+	return map[string]cache.SharedIndexInformer{
+		NodeResource:                  c.nodeInformer,
+		PodResource:                   c.podInformer,
+		ServiceResource:               c.serviceInformer,
+		EndpointsResource:             c.endpointsInformer,
+		ReplicationControllerResource: c.rcInformer,
+		ReplicaSetResource:            c.replicaSetInformer,
+		DeploymentResource:            c.deploymentInformer,
+		StatefulSetResource:           c.statefulSetInformer,
+		DaemonSetResource:             c.daemonSetInformer,
+		ResourceQuotaResource:         c.quotaInformer,
+		PersistentVolumeResource:      c.pvInformer,
+		PersistentVolumeClaimResource: c.pvcInformer,
+		StorageClassResource:          c.storageClassInformer,
+	}
+}
+
+// Start all the informers. It returns immediately; the informers stop when the stop channel is closed.
+func (c *ClusterCache) Run(stopCh <-chan struct{}) {
+	glog.V(2).Infof("Starting cluster cache.")
+	for _, informer := range c.informers() {
+		go informer.Run(stopCh)
+	}
+	go c.reportUnsynced(stopCh, time.Now())
+}
+
+// Block until all the informers have finished their initial list, or the stop channel is closed.
+func (c *ClusterCache) WaitForCacheSync(stopCh <-chan struct{}) bool {
+	var synced []cache.InformerSynced
+	for _, hasSynced := range c.synced {
+		synced = append(synced, hasSynced)
+	}
+	return cache.WaitForCacheSync(stopCh, synced...)
+}
+
+// HasSynced returns true once all the informers have finished their initial list.
+func (c *ClusterCache) HasSynced() bool {
+	return len(c.unsyncedResources()) == 0
+}
+
+// ResourceSynced returns true once the informer of the given resource has finished its initial list.
+// The resources are synced independently, so one resource which cannot be listed does not keep the others
+// from being read from the cache.
+func (c *ClusterCache) ResourceSynced(resource string) bool {
+	hasSynced, exist := c.synced[resource]
+	return exist && hasSynced()
+}
+
+// Get the resources whose informer has not finished its initial list, sorted by name.
+func (c *ClusterCache) unsyncedResources() []string {
+	var unsynced []string
+	for resource, hasSynced := range c.synced {
+		if !hasSynced() {
+			unsynced = append(unsynced, resource)
+		}
+	}
+	sort.Strings(unsynced)
+	return unsynced
+}
+
+// Periodically check the informers which have not synced yet, and report them as errors once they have not synced
+// by the deadline, e.g. because kubeturbo is not allowed to list them; their resources are listed from the API
+// server directly meanwhile. It returns once all the informers have synced, or the stop channel is closed.
+func (c *ClusterCache) reportUnsynced(stopCh <-chan struct{}, start time.Time) {
+	ticker := time.NewTicker(cacheSyncCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+		unsynced := c.unsyncedResources()
+		if len(unsynced) == 0 {
+			glog.V(2).Infof("Cluster cache has synced.")
+			return
+		}
+		if elapsed := time.Since(start); elapsed >= cacheSyncDeadline {
+			glog.Errorf("Cluster cache of %s has not synced after %v; they are listed from the API server instead.",
+				strings.Join(unsynced, ", "), elapsed)
+		} else {
+			glog.V(3).Infof("Cluster cache of %s has not synced yet.", strings.Join(unsynced, ", "))
+		}
+	}
+}
+
+func (c *ClusterCache) GetAllNodes() []*api.Node {
+	objs := c.nodeInformer.GetStore().List()
+	nodes := make([]*api.Node, 0, len(objs))
+	for _, obj := range objs {
+		if node, ok := obj.(*api.Node); ok {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Find the node which has any of the given IP addresses.
+func (c *ClusterCache) GetNodeByIP(machineIPs []string) (*api.Node, error) {
+	for _, node := range c.GetAllNodes() {
+		for _, nodeAddress := range node.Status.Addresses {
+			for _, machineIP := range machineIPs {
+				if nodeAddress.Address == machineIP {
+					return node, nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot find node with IPs %s", machineIPs)
+}
+
+// Find the node by its system UUID.
+func (c *ClusterCache) GetNodeByUUID(uuid string) (*api.Node, error) {
+	for _, node := range c.GetAllNodes() {
+		if strings.EqualFold(uuid, node.Status.NodeInfo.SystemUUID) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find node with UUID %s", uuid)
+}
+
+// Get the pods in the given namespace; api.NamespaceAll returns the pods in all the namespaces.
+func (c *ClusterCache) GetPods(namespace string) []*api.Pod {
+	pods := []*api.Pod{}
+	appendPod := func(obj interface{}) {
+		if pod, ok := obj.(*api.Pod); ok {
+			pods = append(pods, pod)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.podInformer.GetIndexer(), namespace, labels.Everything(), appendPod); err != nil {
+		glog.Errorf("Failed to list pods in namespace %q from cache: %v", namespace, err)
+	}
+	return pods
+}
+
+// Get the running pods which are scheduled on the given node.
+func (c *ClusterCache) GetRunningPodsOnNode(nodeName string) ([]*api.Pod, error) {
+	objs, err := c.podInformer.GetIndexer().ByIndex(podNodeNameIndex, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	pods := []*api.Pod{}
+	for _, obj := range objs {
+		pod, ok := obj.(*api.Pod)
+		if ok && pod.Status.Phase == api.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func (c *ClusterCache) GetPodByUID(uid string) (*api.Pod, error) {
+	objs, err := c.podInformer.GetIndexer().ByIndex(podUIDIndex, uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if pod, ok := obj.(*api.Pod); ok {
+			return pod, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find pod based on given uuid: %s", uid)
+}
+
+func (c *ClusterCache) GetServices(namespace string) []*api.Service {
+	services := []*api.Service{}
+	appendService := func(obj interface{}) {
+		if svc, ok := obj.(*api.Service); ok {
+			services = append(services, svc)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.serviceInformer.GetIndexer(), namespace, labels.Everything(), appendService); err != nil {
+		glog.Errorf("Failed to list services in namespace %q from cache: %v", namespace, err)
+	}
+	return services
+}
+
+func (c *ClusterCache) GetEndpoints(namespace string) []*api.Endpoints {
+	endpoints := []*api.Endpoints{}
+	appendEndpoints := func(obj interface{}) {
+		if ep, ok := obj.(*api.Endpoints); ok {
+			endpoints = append(endpoints, ep)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.endpointsInformer.GetIndexer(), namespace, labels.Everything(), appendEndpoints); err != nil {
+		glog.Errorf("Failed to list endpoints in namespace %q from cache: %v", namespace, err)
+	}
+	return endpoints
+}
+
+func (c *ClusterCache) GetReplicationControllers(namespace string) []*api.ReplicationController {
+	rcs := []*api.ReplicationController{}
+	appendRC := func(obj interface{}) {
+		if rc, ok := obj.(*api.ReplicationController); ok {
+			rcs = append(rcs, rc)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.rcInformer.GetIndexer(), namespace, labels.Everything(), appendRC); err != nil {
+		glog.Errorf("Failed to list replication controllers in namespace %q from cache: %v", namespace, err)
+	}
+	return rcs
+}
+
+func (c *ClusterCache) GetReplicaSets(namespace string) []*extensions.ReplicaSet {
+	replicaSets := []*extensions.ReplicaSet{}
+	appendReplicaSet := func(obj interface{}) {
+		if rs, ok := obj.(*extensions.ReplicaSet); ok {
+			replicaSets = append(replicaSets, rs)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.replicaSetInformer.GetIndexer(), namespace, labels.Everything(), appendReplicaSet); err != nil {
+		glog.Errorf("Failed to list replica sets in namespace %q from cache: %v", namespace, err)
+	}
+	return replicaSets
+}
+
+func (c *ClusterCache) GetDeployments(namespace string) []*extensions.Deployment {
+	deployments := []*extensions.Deployment{}
+	appendDeployment := func(obj interface{}) {
+		if deploy, ok := obj.(*extensions.Deployment); ok {
+			deployments = append(deployments, deploy)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.deploymentInformer.GetIndexer(), namespace, labels.Everything(), appendDeployment); err != nil {
+		glog.Errorf("Failed to list deployments in namespace %q from cache: %v", namespace, err)
+	}
+	return deployments
+}
+
+func (c *ClusterCache) GetStatefulSets(namespace string) []*apps.StatefulSet {
+	statefulSets := []*apps.StatefulSet{}
+	appendStatefulSet := func(obj interface{}) {
+		if ss, ok := obj.(*apps.StatefulSet); ok {
+			statefulSets = append(statefulSets, ss)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.statefulSetInformer.GetIndexer(), namespace, labels.Everything(), appendStatefulSet); err != nil {
+		glog.Errorf("Failed to list stateful sets in namespace %q from cache: %v", namespace, err)
+	}
+	return statefulSets
+}
+
+func (c *ClusterCache) GetDaemonSets(namespace string) []*extensions.DaemonSet {
+	daemonSets := []*extensions.DaemonSet{}
+	appendDaemonSet := func(obj interface{}) {
+		if ds, ok := obj.(*extensions.DaemonSet); ok {
+			daemonSets = append(daemonSets, ds)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.daemonSetInformer.GetIndexer(), namespace, labels.Everything(), appendDaemonSet); err != nil {
+		glog.Errorf("Failed to list daemon sets in namespace %q from cache: %v", namespace, err)
+	}
+	return daemonSets
+}
+
+func (c *ClusterCache) GetResourceQuotas(namespace string) []*api.ResourceQuota {
+	quotas := []*api.ResourceQuota{}
+	appendQuota := func(obj interface{}) {
+		if quota, ok := obj.(*api.ResourceQuota); ok {
+			quotas = append(quotas, quota)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.quotaInformer.GetIndexer(), namespace, labels.Everything(), appendQuota); err != nil {
+		glog.Errorf("Failed to list resource quotas in namespace %q from cache: %v", namespace, err)
+	}
+	return quotas
+}
+
+func (c *ClusterCache) GetPersistentVolumes() []*api.PersistentVolume {
+	objs := c.pvInformer.GetStore().List()
+	pvs := make([]*api.PersistentVolume, 0, len(objs))
+	for _, obj := range objs {
+		if pv, ok := obj.(*api.PersistentVolume); ok {
+			pvs = append(pvs, pv)
+		}
+	}
+	return pvs
+}
+
+func (c *ClusterCache) GetPersistentVolumeClaims(namespace string) []*api.PersistentVolumeClaim {
+	pvcs := []*api.PersistentVolumeClaim{}
+	appendPVC := func(obj interface{}) {
+		if pvc, ok := obj.(*api.PersistentVolumeClaim); ok {
+			pvcs = append(pvcs, pvc)
+		}
+	}
+	if err := cache.ListAllByNamespace(c.pvcInformer.GetIndexer(), namespace, labels.Everything(), appendPVC); err != nil {
+		glog.Errorf("Failed to list persistent volume claims in namespace %q from cache: %v", namespace, err)
+	}
+	return pvcs
+}
+
+func (c *ClusterCache) GetStorageClasses() []*storage.StorageClass {
+	objs := c.storageClassInformer.GetStore().List()
+	storageClasses := make([]*storage.StorageClass, 0, len(objs))
+	for _, obj := range objs {
+		if sc, ok := obj.(*storage.StorageClass); ok {
+			storageClasses = append(storageClasses, sc)
+		}
+	}
+	return storageClasses
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	client "k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+	restclient "k8s.io/client-go/rest"
+)
+
+func newTestClusterCache(t *testing.T) *ClusterCache {
+	kubeClient, err := client.NewForConfig(&restclient.Config{Host: "localhost"})
+	if err != nil {
+		t.Fatalf("Failed to create kubeClient: %v", err)
+	}
+	return NewClusterCache(kubeClient, DefaultCacheResyncPeriod)
+}
+
+func newTestPod(namespace, name, uid, nodeName string, phase api.PodPhase) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(uid),
+		},
+		Spec:   api.PodSpec{NodeName: nodeName},
+		Status: api.PodStatus{Phase: phase},
+	}
+}
+
+func TestClusterCachePods(t *testing.T) {
+	c := newTestClusterCache(t)
+	indexer := c.podInformer.GetIndexer()
+	indexer.Add(newTestPod("default", "pod-1", "uid-1", "node-1", api.PodRunning))
+	indexer.Add(newTestPod("default", "pod-2", "uid-2", "node-1", api.PodPending))
+	indexer.Add(newTestPod("kube-system", "pod-3", "uid-3", "node-2", api.PodRunning))
+
+	pods, err := c.GetRunningPodsOnNode("node-1")
+	if err != nil || len(pods) != 1 || pods[0].Name != "pod-1" {
+		t.Errorf("Expected only pod-1 running on node-1, got %v, %v", pods, err)
+	}
+
+	if pods := c.GetPods(api.NamespaceAll); len(pods) != 3 {
+		t.Errorf("Expected 3 pods in all namespaces, got %d", len(pods))
+	}
+	if pods := c.GetPods("kube-system"); len(pods) != 1 || pods[0].Name != "pod-3" {
+		t.Errorf("Expected only pod-3 in kube-system, got %v", pods)
+	}
+
+	if pod, err := c.GetPodByUID("uid-2"); err != nil || pod.Name != "pod-2" {
+		t.Errorf("Expected pod-2 for uid-2, got %v, %v", pod, err)
+	}
+	if _, err := c.GetPodByUID("uid-4"); err == nil {
+		t.Errorf("Expected error for unknown pod uid")
+	}
+}
+
+func TestClusterCacheNodes(t *testing.T) {
+	c := newTestClusterCache(t)
+	c.nodeInformer.GetIndexer().Add(&api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Addresses: []api.NodeAddress{{Type: api.NodeInternalIP, Address: "10.0.0.1"}},
+			NodeInfo:  api.NodeSystemInfo{SystemUUID: "ABCD-1234"},
+		},
+	})
+
+	if node, err := c.GetNodeByIP([]string{"10.0.0.2", "10.0.0.1"}); err != nil || node.Name != "node-1" {
+		t.Errorf("Expected node-1 for IP 10.0.0.1, got %v, %v", node, err)
+	}
+	if _, err := c.GetNodeByIP([]string{"10.0.0.3"}); err == nil {
+		t.Errorf("Expected error for unknown node IP")
+	}
+	if node, err := c.GetNodeByUUID("abcd-1234"); err != nil || node.Name != "node-1" {
+		t.Errorf("Expected node-1 for UUID abcd-1234, got %v, %v", node, err)
+	}
+}
+
+func TestClusterCacheResourceSynced(t *testing.T) {
+	c := newTestClusterCache(t)
+	for resource := range c.synced {
+		if resource != PodResource {
+			c.synced[resource] = func() bool { return true }
+		}
+	}
+
+	if !c.ResourceSynced(NodeResource) {
+		t.Errorf("Expected %s to be synced", NodeResource)
+	}
+	if c.ResourceSynced(PodResource) {
+		t.Errorf("Expected %s not to be synced", PodResource)
+	}
+	if c.ResourceSynced("unknown") {
+		t.Errorf("Expected an unknown resource not to be synced")
+	}
+	if c.HasSynced() {
+		t.Errorf("Expected the cache not to be synced while %s is not", PodResource)
+	}
+	if unsynced := c.unsyncedResources(); len(unsynced) != 1 || unsynced[0] != PodResource {
+		t.Errorf("Expected only %s unsynced, got %v", PodResource, unsynced)
+	}
+
+	c.synced[PodResource] = func() bool { return true }
+	if !c.HasSynced() {
+		t.Errorf("Expected the cache to be synced")
+	}
+}
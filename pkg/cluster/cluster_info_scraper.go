@@ -1,6 +1,7 @@
 package cluster
 
 import (
+	"context"
 	"fmt"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -9,6 +10,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 	client "k8s.io/client-go/kubernetes"
 	api "k8s.io/client-go/pkg/api/v1"
+	apps "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	storage "k8s.io/client-go/pkg/apis/storage/v1"
 	restclient "k8s.io/client-go/rest"
 
 	"github.com/golang/glog"
@@ -18,6 +22,9 @@ const (
 	k8sDefaultNamespace = "default"
 
 	kubernetesServiceName = "kubernetes"
+
+	// The reason of the events recorded by the scheduler when a pod cannot be scheduled.
+	failedSchedulingReason = "FailedScheduling"
 )
 
 var (
@@ -27,6 +34,12 @@ var (
 
 type ClusterScraper struct {
 	*client.Clientset
+
+	// When set and synced, the cluster objects are read from the cache instead of being listed from the API server.
+	cache *ClusterCache
+
+	// Only the objects in scope are returned by the GetAll* functions. Nil if everything is in scope.
+	scope *Scope
 }
 
 func NewClusterInfoScraper(kubeConfig *restclient.Config) (*ClusterScraper, error) {
@@ -40,7 +53,35 @@ func NewClusterInfoScraper(kubeConfig *restclient.Config) (*ClusterScraper, erro
 	}, nil
 }
 
+func (s *ClusterScraper) WithClusterCache(cache *ClusterCache) *ClusterScraper {
+	s.cache = cache
+	return s
+}
+
+func (s *ClusterScraper) WithScope(scope *Scope) *ClusterScraper {
+	s.scope = scope
+	return s
+}
+
+// The cache of a resource is only used once its initial list is complete; before that the API server is queried
+// directly for that resource.
+func (s *ClusterScraper) cacheSynced(resource string) bool {
+	return s.cache != nil && s.cache.ResourceSynced(resource)
+}
+
+// Get all the nodes in scope.
 func (s *ClusterScraper) GetAllNodes() ([]*api.Node, error) {
+	nodes, err := s.getAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	return s.scope.FilterNodes(nodes), nil
+}
+
+func (s *ClusterScraper) getAllNodes() ([]*api.Node, error) {
+	if s.cacheSynced(NodeResource) {
+		return s.cache.GetAllNodes(), nil
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -60,7 +101,19 @@ func (s *ClusterScraper) GetNodes(opts metav1.ListOptions) ([]*api.Node, error)
 	return nodes, nil
 }
 
+// Get all the pods in scope.
 func (s *ClusterScraper) GetAllPods() ([]*api.Pod, error) {
+	pods, err := s.getAllPods()
+	if err != nil {
+		return nil, err
+	}
+	return s.scope.FilterPods(pods), nil
+}
+
+func (s *ClusterScraper) getAllPods() ([]*api.Pod, error) {
+	if s.cacheSynced(PodResource) {
+		return s.cache.GetPods(api.NamespaceAll), nil
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 		FieldSelector: fieldSelectEverything,
@@ -81,7 +134,19 @@ func (s *ClusterScraper) GetPods(namespaces string, opts metav1.ListOptions) ([]
 	return pods, nil
 }
 
+// Get all the services in scope.
 func (s *ClusterScraper) GetAllServices() ([]*api.Service, error) {
+	services, err := s.getAllServices()
+	if err != nil {
+		return nil, err
+	}
+	return s.scope.FilterServices(services), nil
+}
+
+func (s *ClusterScraper) getAllServices() ([]*api.Service, error) {
+	if s.cacheSynced(ServiceResource) {
+		return s.cache.GetServices(api.NamespaceAll), nil
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
@@ -115,15 +180,250 @@ func (s *ClusterScraper) GetEndpoints(namespaces string, opts metav1.ListOptions
 	return endpoints, nil
 }
 
+// Get all the endpoints in scope.
 func (s *ClusterScraper) GetAllEndpoints() ([]*api.Endpoints, error) {
+	endpoints, err := s.getAllEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	return s.scope.FilterEndpoints(endpoints), nil
+}
+
+func (s *ClusterScraper) getAllEndpoints() ([]*api.Endpoints, error) {
+	if s.cacheSynced(EndpointsResource) {
+		return s.cache.GetEndpoints(api.NamespaceAll), nil
+	}
 	listOption := metav1.ListOptions{
 		LabelSelector: labelSelectEverything,
 	}
 	return s.GetEndpoints(api.NamespaceAll, listOption)
 }
 
+// Get all the resource quotas in the namespaces in scope.
+func (s *ClusterScraper) GetAllResourceQuotas() ([]*api.ResourceQuota, error) {
+	quotas, err := s.getAllResourceQuotas()
+	if err != nil {
+		return nil, err
+	}
+	return s.scope.FilterResourceQuotas(quotas), nil
+}
+
+func (s *ClusterScraper) getAllResourceQuotas() ([]*api.ResourceQuota, error) {
+	if s.cacheSynced(ResourceQuotaResource) {
+		return s.cache.GetResourceQuotas(api.NamespaceAll), nil
+	}
+	quotaList, err := s.CoreV1().ResourceQuotas(api.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas in the cluster: %s", err)
+	}
+	quotas := make([]*api.ResourceQuota, len(quotaList.Items))
+	for i := 0; i < len(quotaList.Items); i++ {
+		quotas[i] = &quotaList.Items[i]
+	}
+	return quotas, nil
+}
+
+// Get all the deployments in the namespaces in scope.
+func (s *ClusterScraper) GetAllDeployments() ([]*extensions.Deployment, error) {
+	var deployments []*extensions.Deployment
+	if s.cacheSynced(DeploymentResource) {
+		deployments = s.cache.GetDeployments(api.NamespaceAll)
+	} else {
+		deployList, err := s.ExtensionsV1beta1().Deployments(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments in the cluster: %s", err)
+		}
+		for i := range deployList.Items {
+			deployments = append(deployments, &deployList.Items[i])
+		}
+	}
+	result := []*extensions.Deployment{}
+	for _, deploy := range deployments {
+		if s.scope.ContainsNamespace(deploy.Namespace) {
+			result = append(result, deploy)
+		}
+	}
+	return result, nil
+}
+
+// Get all the replica sets in the namespaces in scope.
+func (s *ClusterScraper) GetAllReplicaSets() ([]*extensions.ReplicaSet, error) {
+	var replicaSets []*extensions.ReplicaSet
+	if s.cacheSynced(ReplicaSetResource) {
+		replicaSets = s.cache.GetReplicaSets(api.NamespaceAll)
+	} else {
+		rsList, err := s.ExtensionsV1beta1().ReplicaSets(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list replica sets in the cluster: %s", err)
+		}
+		for i := range rsList.Items {
+			replicaSets = append(replicaSets, &rsList.Items[i])
+		}
+	}
+	result := []*extensions.ReplicaSet{}
+	for _, rs := range replicaSets {
+		if s.scope.ContainsNamespace(rs.Namespace) {
+			result = append(result, rs)
+		}
+	}
+	return result, nil
+}
+
+// Get all the replication controllers in the namespaces in scope.
+func (s *ClusterScraper) GetAllReplicationControllers() ([]*api.ReplicationController, error) {
+	var rcs []*api.ReplicationController
+	if s.cacheSynced(ReplicationControllerResource) {
+		rcs = s.cache.GetReplicationControllers(api.NamespaceAll)
+	} else {
+		rcList, err := s.CoreV1().ReplicationControllers(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list replication controllers in the cluster: %s", err)
+		}
+		for i := range rcList.Items {
+			rcs = append(rcs, &rcList.Items[i])
+		}
+	}
+	result := []*api.ReplicationController{}
+	for _, rc := range rcs {
+		if s.scope.ContainsNamespace(rc.Namespace) {
+			result = append(result, rc)
+		}
+	}
+	return result, nil
+}
+
+// Get all the stateful sets in the namespaces in scope.
+func (s *ClusterScraper) GetAllStatefulSets() ([]*apps.StatefulSet, error) {
+	var statefulSets []*apps.StatefulSet
+	if s.cacheSynced(StatefulSetResource) {
+		statefulSets = s.cache.GetStatefulSets(api.NamespaceAll)
+	} else {
+		ssList, err := s.AppsV1beta1().StatefulSets(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stateful sets in the cluster: %s", err)
+		}
+		for i := range ssList.Items {
+			statefulSets = append(statefulSets, &ssList.Items[i])
+		}
+	}
+	result := []*apps.StatefulSet{}
+	for _, ss := range statefulSets {
+		if s.scope.ContainsNamespace(ss.Namespace) {
+			result = append(result, ss)
+		}
+	}
+	return result, nil
+}
+
+// Get all the daemon sets in the namespaces in scope.
+func (s *ClusterScraper) GetAllDaemonSets() ([]*extensions.DaemonSet, error) {
+	var daemonSets []*extensions.DaemonSet
+	if s.cacheSynced(DaemonSetResource) {
+		daemonSets = s.cache.GetDaemonSets(api.NamespaceAll)
+	} else {
+		dsList, err := s.ExtensionsV1beta1().DaemonSets(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemon sets in the cluster: %s", err)
+		}
+		for i := range dsList.Items {
+			daemonSets = append(daemonSets, &dsList.Items[i])
+		}
+	}
+	result := []*extensions.DaemonSet{}
+	for _, ds := range daemonSets {
+		if s.scope.ContainsNamespace(ds.Namespace) {
+			result = append(result, ds)
+		}
+	}
+	return result, nil
+}
+
+// Get all the persistent volumes. They are not namespaced, so they are not limited by the scope.
+func (s *ClusterScraper) GetAllPersistentVolumes() ([]*api.PersistentVolume, error) {
+	if s.cacheSynced(PersistentVolumeResource) {
+		return s.cache.GetPersistentVolumes(), nil
+	}
+	pvList, err := s.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volumes in the cluster: %s", err)
+	}
+	pvs := make([]*api.PersistentVolume, len(pvList.Items))
+	for i := 0; i < len(pvList.Items); i++ {
+		pvs[i] = &pvList.Items[i]
+	}
+	return pvs, nil
+}
+
+// Get all the persistent volume claims in the namespaces in scope.
+func (s *ClusterScraper) GetAllPersistentVolumeClaims() ([]*api.PersistentVolumeClaim, error) {
+	var pvcs []*api.PersistentVolumeClaim
+	if s.cacheSynced(PersistentVolumeClaimResource) {
+		pvcs = s.cache.GetPersistentVolumeClaims(api.NamespaceAll)
+	} else {
+		pvcList, err := s.CoreV1().PersistentVolumeClaims(api.NamespaceAll).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list persistent volume claims in the cluster: %s", err)
+		}
+		for i := range pvcList.Items {
+			pvcs = append(pvcs, &pvcList.Items[i])
+		}
+	}
+	result := []*api.PersistentVolumeClaim{}
+	for _, pvc := range pvcs {
+		if s.scope.ContainsNamespace(pvc.Namespace) {
+			result = append(result, pvc)
+		}
+	}
+	return result, nil
+}
+
+// Get the persistent volumes bound to the claims in the namespaces in scope, indexed by "namespace/claim name".
+func (s *ClusterScraper) GetPersistentVolumesByClaim() (map[string]*api.PersistentVolume, error) {
+	pvs, err := s.GetAllPersistentVolumes()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*api.PersistentVolume)
+	for _, pv := range pvs {
+		claim := pv.Spec.ClaimRef
+		if claim == nil || pv.Status.Phase != api.VolumeBound || !s.scope.ContainsNamespace(claim.Namespace) {
+			continue
+		}
+		result[claim.Namespace+"/"+claim.Name] = pv
+	}
+	return result, nil
+}
+
+// Get all the storage classes. They are not namespaced, so they are not limited by the scope.
+func (s *ClusterScraper) GetAllStorageClasses() ([]*storage.StorageClass, error) {
+	if s.cacheSynced(StorageClassResource) {
+		return s.cache.GetStorageClasses(), nil
+	}
+	scList, err := s.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes in the cluster: %s", err)
+	}
+	storageClasses := make([]*storage.StorageClass, len(scList.Items))
+	for i := 0; i < len(scList.Items); i++ {
+		storageClasses[i] = &scList.Items[i]
+	}
+	return storageClasses, nil
+}
+
 func (s *ClusterScraper) GetKubernetesServiceID() (svcID string, err error) {
-	svc, err := s.CoreV1().Services(k8sDefaultNamespace).Get(kubernetesServiceName, metav1.GetOptions{})
+	return s.GetKubernetesServiceIDWithContext(context.Background())
+}
+
+// Get the UID of the kubernetes service; the request is aborted once the context is cancelled.
+func (s *ClusterScraper) GetKubernetesServiceIDWithContext(ctx context.Context) (svcID string, err error) {
+	svc := &api.Service{}
+	err = s.CoreV1().RESTClient().Get().
+		Context(ctx).
+		Namespace(k8sDefaultNamespace).
+		Resource("services").
+		Name(kubernetesServiceName).
+		Do().
+		Into(svc)
 	if err != nil {
 		return
 	}
@@ -131,7 +431,74 @@ func (s *ClusterScraper) GetKubernetesServiceID() (svcID string, err error) {
 	return
 }
 
+// Get the pods in scope which are pending because the scheduler cannot find a node for them.
+// The pods which are just created and not yet considered by the scheduler are not included.
+func (s *ClusterScraper) GetPendingPods() ([]*api.Pod, error) {
+	pods, err := s.GetAllPods()
+	if err != nil {
+		return nil, err
+	}
+	result := []*api.Pod{}
+	for _, pod := range pods {
+		if pod.Status.Phase != api.PodPending || pod.Spec.NodeName != "" {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == api.PodScheduled && condition.Status == api.ConditionFalse &&
+				condition.Reason == api.PodReasonUnschedulable {
+				result = append(result, pod)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Get the reasons why the given pods failed to be scheduled, from the latest FailedScheduling event of each pod,
+// indexed by pod UID. Only the events in the namespaces of the pods are listed.
+func (s *ClusterScraper) GetPodSchedulingFailures(pods []*api.Pod) (map[string]string, error) {
+	podsByNamespace := make(map[string]map[string]struct{})
+	for _, pod := range pods {
+		if _, exist := podsByNamespace[pod.Namespace]; !exist {
+			podsByNamespace[pod.Namespace] = make(map[string]struct{})
+		}
+		podsByNamespace[pod.Namespace][string(pod.UID)] = struct{}{}
+	}
+	listOption := metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("reason", failedSchedulingReason),
+			fields.OneTermEqualSelector("involvedObject.kind", "Pod")).String(),
+	}
+	result := make(map[string]string)
+	latest := make(map[string]metav1.Time)
+	for namespace, uids := range podsByNamespace {
+		eventList, err := s.CoreV1().Events(namespace).List(listOption)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scheduling failure events in namespace %s: %s", namespace, err)
+		}
+		for i := range eventList.Items {
+			event := &eventList.Items[i]
+			uid := string(event.InvolvedObject.UID)
+			if _, exist := uids[uid]; !exist {
+				continue
+			}
+			if t, exist := latest[uid]; exist && event.LastTimestamp.Before(t) {
+				continue
+			}
+			latest[uid] = event.LastTimestamp
+			result[uid] = event.Message
+		}
+	}
+	return result, nil
+}
+
+// Get the running pods in scope on the given nodes.
 func (s *ClusterScraper) GetRunningPodsOnNodes(nodeList []*api.Node) []*api.Pod {
+	return s.FilterPods(s.GetAllRunningPodsOnNodes(nodeList))
+}
+
+// Get all the running pods on the given nodes, including the pods out of scope.
+func (s *ClusterScraper) GetAllRunningPodsOnNodes(nodeList []*api.Node) []*api.Pod {
 	pods := []*api.Pod{}
 	for _, node := range nodeList {
 		nodeRunningPodsList, err := s.findRunningPodsOnNode(node.Name)
@@ -144,8 +511,15 @@ func (s *ClusterScraper) GetRunningPodsOnNodes(nodeList []*api.Node) []*api.Pod
 	return pods
 }
 
-// TODO, create a local pod, node cache to avoid too many API request.
+// Get the pods in scope among the given pods.
+func (s *ClusterScraper) FilterPods(pods []*api.Pod) []*api.Pod {
+	return s.scope.FilterPods(pods)
+}
+
 func (s *ClusterScraper) findRunningPodsOnNode(nodeName string) ([]*api.Pod, error) {
+	if s.cacheSynced(PodResource) {
+		return s.cache.GetRunningPodsOnNode(nodeName)
+	}
 	fieldSelector, err := fields.ParseSelector("spec.nodeName=" + nodeName + ",status.phase=" +
 		string(api.PodRunning))
 	if err != nil {
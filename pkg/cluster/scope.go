@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+// Scope limits the part of the cluster which is discovered and controlled by kubeturbo.
+// A nil Scope contains everything.
+type Scope struct {
+	// If not empty, only objects in these namespaces are in scope.
+	includeNamespaces map[string]struct{}
+	// Objects in these namespaces are never in scope.
+	excludeNamespaces map[string]struct{}
+
+	podSelector  labels.Selector
+	nodeSelector labels.Selector
+}
+
+// Build a scope from the namespace lists and the label selectors, e.g. "team=a,tier!=test".
+// An empty list or selector does not limit the scope.
+func NewScope(includeNamespaces, excludeNamespaces []string, podSelector, nodeSelector string) (*Scope, error) {
+	scope := &Scope{
+		includeNamespaces: make(map[string]struct{}),
+		excludeNamespaces: make(map[string]struct{}),
+		podSelector:       labels.Everything(),
+		nodeSelector:      labels.Everything(),
+	}
+	for _, ns := range includeNamespaces {
+		scope.includeNamespaces[ns] = struct{}{}
+	}
+	for _, ns := range excludeNamespaces {
+		scope.excludeNamespaces[ns] = struct{}{}
+	}
+
+	var err error
+	if podSelector != "" {
+		if scope.podSelector, err = labels.Parse(podSelector); err != nil {
+			return nil, fmt.Errorf("invalid pod selector %q: %v", podSelector, err)
+		}
+	}
+	if nodeSelector != "" {
+		if scope.nodeSelector, err = labels.Parse(nodeSelector); err != nil {
+			return nil, fmt.Errorf("invalid node selector %q: %v", nodeSelector, err)
+		}
+	}
+	return scope, nil
+}
+
+func (s *Scope) ContainsNamespace(namespace string) bool {
+	if s == nil {
+		return true
+	}
+	if _, excluded := s.excludeNamespaces[namespace]; excluded {
+		return false
+	}
+	if len(s.includeNamespaces) == 0 {
+		return true
+	}
+	_, included := s.includeNamespaces[namespace]
+	return included
+}
+
+func (s *Scope) ContainsPod(pod *api.Pod) bool {
+	if s == nil {
+		return true
+	}
+	return s.ContainsPodLabels(pod.Namespace, pod.Labels)
+}
+
+// Whether the pods with the given labels in the namespace are in scope, e.g. the pods created from the template of
+// a controller.
+func (s *Scope) ContainsPodLabels(namespace string, podLabels map[string]string) bool {
+	if s == nil {
+		return true
+	}
+	return s.ContainsNamespace(namespace) && s.podSelector.Matches(labels.Set(podLabels))
+}
+
+func (s *Scope) ContainsNode(node *api.Node) bool {
+	if s == nil {
+		return true
+	}
+	return s.nodeSelector.Matches(labels.Set(node.Labels))
+}
+
+func (s *Scope) FilterNodes(nodes []*api.Node) []*api.Node {
+	if s == nil {
+		return nodes
+	}
+	result := []*api.Node{}
+	for _, node := range nodes {
+		if s.ContainsNode(node) {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+func (s *Scope) FilterPods(pods []*api.Pod) []*api.Pod {
+	if s == nil {
+		return pods
+	}
+	result := []*api.Pod{}
+	for _, pod := range pods {
+		if s.ContainsPod(pod) {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+func (s *Scope) FilterServices(services []*api.Service) []*api.Service {
+	if s == nil {
+		return services
+	}
+	result := []*api.Service{}
+	for _, svc := range services {
+		if s.ContainsNamespace(svc.Namespace) {
+			result = append(result, svc)
+		}
+	}
+	return result
+}
+
+func (s *Scope) FilterEndpoints(endpoints []*api.Endpoints) []*api.Endpoints {
+	if s == nil {
+		return endpoints
+	}
+	result := []*api.Endpoints{}
+	for _, ep := range endpoints {
+		if s.ContainsNamespace(ep.Namespace) {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+func (s *Scope) FilterResourceQuotas(quotas []*api.ResourceQuota) []*api.ResourceQuota {
+	if s == nil {
+		return quotas
+	}
+	result := []*api.ResourceQuota{}
+	for _, quota := range quotas {
+		if s.ContainsNamespace(quota.Namespace) {
+			result = append(result, quota)
+		}
+	}
+	return result
+}
+
+func (s *Scope) String() string {
+	if s == nil {
+		return "everything"
+	}
+	return fmt.Sprintf("includeNamespaces=%v, excludeNamespaces=%v, podSelector=%q, nodeSelector=%q",
+		setToList(s.includeNamespaces), setToList(s.excludeNamespaces), s.podSelector, s.nodeSelector)
+}
+
+func setToList(set map[string]struct{}) []string {
+	list := []string{}
+	for k := range set {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
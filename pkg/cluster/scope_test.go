@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+)
+
+func TestScopeContainsPod(t *testing.T) {
+	scope, err := NewScope([]string{"team-a", "shared"}, []string{"shared"}, "tier!=test", "")
+	if err != nil {
+		t.Fatalf("Failed to create scope: %v", err)
+	}
+
+	table := []struct {
+		namespace string
+		labels    map[string]string
+		expected  bool
+	}{
+		{"team-a", nil, true},
+		{"team-a", map[string]string{"tier": "web"}, true},
+		{"team-a", map[string]string{"tier": "test"}, false},
+		{"team-b", nil, false},
+		{"shared", nil, false},
+	}
+	for _, item := range table {
+		pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: item.namespace, Name: "pod", Labels: item.labels}}
+		if scope.ContainsPod(pod) != item.expected {
+			t.Errorf("Expected ContainsPod of pod in %s with labels %v to be %v", item.namespace, item.labels,
+				item.expected)
+		}
+		if scope.ContainsPodLabels(item.namespace, item.labels) != item.expected {
+			t.Errorf("Expected ContainsPodLabels in %s with labels %v to be %v", item.namespace, item.labels,
+				item.expected)
+		}
+	}
+}
+
+func TestScopeFilterNodes(t *testing.T) {
+	scope, err := NewScope(nil, nil, "", "pool=team-a")
+	if err != nil {
+		t.Fatalf("Failed to create scope: %v", err)
+	}
+	nodes := []*api.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"pool": "team-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"pool": "team-b"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}},
+	}
+	filtered := scope.FilterNodes(nodes)
+	if len(filtered) != 1 || filtered[0].Name != "node-1" {
+		t.Errorf("Expected only node-1 in scope, got %v", filtered)
+	}
+
+	var nilScope *Scope
+	if len(nilScope.FilterNodes(nodes)) != 3 {
+		t.Errorf("Expected all nodes in nil scope")
+	}
+}
+
+func TestNewScopeInvalidSelector(t *testing.T) {
+	if _, err := NewScope(nil, nil, "tier in (web", ""); err == nil {
+		t.Errorf("Expected error for invalid pod selector")
+	}
+}
@@ -1,6 +1,9 @@
 package configs
 
 import (
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 )
@@ -12,4 +15,19 @@ type ProbeConfig struct {
 	StitchingPropertyType stitching.StitchingPropertyType
 
 	MonitoringConfigs []monitoring.MonitorWorkerConfig
+
+	// The usage history kept across discoveries, to report peak and percentile usage. Nil if it is disabled.
+	MetricHistory *metrics.EntityMetricHistory
+
+	// The percentile of the usage history reported as the used value; 0 means the latest sample is reported.
+	UsagePercentile float64
+
+	// Use the raw capacity of the nodes instead of their allocatable resources.
+	UseNodeCapacity bool
+
+	// The range of the number of workers discovering the nodes, sized from the cluster so that the nodes are
+	// discovered within the target time. Zero values use the defaults.
+	MinDiscoveryWorkers int
+	MaxDiscoveryWorkers int
+	TargetDiscoveryTime time.Duration
 }
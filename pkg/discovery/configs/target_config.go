@@ -1,6 +1,10 @@
 package configs
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+)
 
 const (
 	// When user doesn't specify username and password, the default username and password will be used.
@@ -14,6 +18,17 @@ type K8sTargetConfig struct {
 	TargetIdentifier string `json:"address,omitempty"`
 	TargetUsername   string `json:"username,omitempty"`
 	TargetPassword   string `json:"password,omitempty"`
+
+	// Limit what kubeturbo discovers and acts on, so that several kubeturbos can share a cluster.
+	// If IncludeNamespaces is empty, all the namespaces except the ExcludeNamespaces are in scope.
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+	// Label selectors of the pods and nodes in scope, e.g. "team=a,tier!=test".
+	PodSelector  string `json:"podSelector,omitempty"`
+	NodeSelector string `json:"nodeSelector,omitempty"`
+
+	// Parsed from the fields above during validation; nil if everything is in scope.
+	scope *cluster.Scope
 }
 
 func NewK8sTargetConfig(probeCategory, targetType, id, username, password string) *K8sTargetConfig {
@@ -39,5 +54,19 @@ func (config *K8sTargetConfig) ValidateK8sTargetConfig() error {
 	if config.TargetPassword == "" {
 		config.TargetPassword = defaultPassword
 	}
+	if len(config.IncludeNamespaces) > 0 || len(config.ExcludeNamespaces) > 0 || config.PodSelector != "" ||
+		config.NodeSelector != "" {
+		scope, err := cluster.NewScope(config.IncludeNamespaces, config.ExcludeNamespaces, config.PodSelector,
+			config.NodeSelector)
+		if err != nil {
+			return err
+		}
+		config.scope = scope
+	}
 	return nil
 }
+
+// The scope of discovery and actions; nil means the whole cluster.
+func (config *K8sTargetConfig) Scope() *cluster.Scope {
+	return config.scope
+}
@@ -67,8 +67,11 @@ func (dc *K8sDiscoveryClient) discTimeCompare() {
 			discFunc: dc.discoveryWithOldFramework,
 		},
 		{
-			name:     "New Framework",
-			discFunc: dc.discoverWithNewFramework,
+			name: "New Framework",
+			discFunc: func() ([]*proto.EntityDTO, error) {
+				entityDTOs, _, err := dc.discoverWithNewFramework()
+				return entityDTOs, err
+			},
 		},
 		{
 			name:     "New Framework Without Compliance",
@@ -102,7 +105,7 @@ func (dc *K8sDiscoveryClient) discoverWithNewFrameworkWithoutCompliance() ([]*pr
 	}
 
 	workerCount := dc.dispatcher.Dispatch(nodes)
-	entityDTOs := dc.resultCollector.Collect(workerCount)
+	entityDTOs, _, _ := dc.resultCollector.Collect(workerCount)
 	glog.V(3).Infof("Discovery workers have finished discovery work with %d entityDTOs built. Now performing service discovery...", len(entityDTOs))
 
 	svcWorkerConfig := worker.NewK8sServiceDiscoveryWorkerConfig(dc.config.k8sClusterScraper)
@@ -17,8 +17,9 @@ import (
 )
 
 const (
-	AppPrefix                  string  = "App-"
-	defaultTransactionCapacity float64 = 500.0
+	AppPrefix                   string  = "App-"
+	defaultTransactionCapacity  float64 = 500.0
+	defaultResponseTimeCapacity float64 = 2000.0
 )
 
 var (
@@ -137,6 +138,20 @@ func (builder *applicationEntityDTOBuilder) getTransactionUsedValue(pod *api.Pod
 	return usedMetric.GetValue().(float64)
 }
 
+// get Pod.ResponseTime.used; it is only available if the monitoring source provides it.
+func (builder *applicationEntityDTOBuilder) getResponseTimeUsedValue(pod *api.Pod) (float64, bool) {
+	key := util.PodKeyFunc(pod)
+	metricsId := metrics.GenerateEntityResourceMetricUID(task.PodType, key, metrics.ResponseTime, metrics.Used)
+
+	usedMetric, err := builder.metricsSink.GetMetric(metricsId)
+	if err != nil {
+		glog.V(4).Infof("failed to get Pod[%s] response time: %v", key, err)
+		return 0.0, false
+	}
+
+	return usedMetric.GetValue().(float64), true
+}
+
 // equally distribute Pod.Transaction.used to the hosted containers.
 func (builder *applicationEntityDTOBuilder) getAppTransactionUsage(index int, pod *api.Pod) float64 {
 	podTransactionUsage := builder.getTransactionUsedValue(pod)
@@ -162,7 +177,7 @@ func (builder *applicationEntityDTOBuilder) getAppTransactionUsage(index int, po
 	return share
 }
 
-// applicationEntity only sells transaction
+// applicationEntity sells transaction, and response time if it is monitored.
 func (builder *applicationEntityDTOBuilder) getCommoditiesSold(appId string, index int, pod *api.Pod) ([]*proto.CommodityDTO, error) {
 	var result []*proto.CommodityDTO
 
@@ -178,6 +193,18 @@ func (builder *applicationEntityDTOBuilder) getCommoditiesSold(appId string, ind
 	}
 	result = append(result, tranCommodity)
 
+	if responseTime, exist := builder.getResponseTimeUsedValue(pod); exist {
+		rtCommodity, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_RESPONSE_TIME).Key(appId).
+			Capacity(defaultResponseTimeCapacity).
+			Used(responseTime).
+			Create()
+		if err != nil {
+			glog.Errorf("Failed to get application(%s) commodities sold:%v", appId, err)
+			return nil, err
+		}
+		result = append(result, rtCommodity)
+	}
+
 	return result, nil
 }
 
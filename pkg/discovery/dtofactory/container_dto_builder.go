@@ -19,10 +19,23 @@ var (
 		metrics.Memory,
 	}
 
+	// The requests, i.e. cpuProvisioned and memProvisioned, are also bought, see requestCommoditySold.
 	commodityBought = []metrics.ResourceType{
 		metrics.CPU,
 		metrics.Memory,
 	}
+
+	// The requests of a container are sold as the provisioned resources, next to its limits sold as vCPU and vMem,
+	// so that they are resized independently.
+	requestCommoditySold = map[metrics.ResourceType]metrics.ResourceType{
+		metrics.CPU:    metrics.CPUProvisioned,
+		metrics.Memory: metrics.MemoryProvisioned,
+	}
+
+	// Only bought when the used value is available.
+	optionalCommodityBought = []metrics.ResourceType{
+		metrics.EphemeralStorage,
+	}
 )
 
 type containerDTOBuilder struct {
@@ -107,7 +120,7 @@ func (builder *containerDTOBuilder) BuildDTOs(pods []*api.Pod) ([]*proto.EntityD
 	return result, nil
 }
 
-//vCPU, vMem, Application are sold by Container to Application
+//vCPU, vMem, cpuProvisioned, memProvisioned, Application are sold by Container to Application
 func (builder *containerDTOBuilder) getCommoditiesSold(containerName, containerKey string, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var result []*proto.CommodityDTO
 
@@ -128,7 +141,14 @@ func (builder *containerDTOBuilder) getCommoditiesSold(containerName, containerK
 	}
 	result = append(result, commodities...)
 
-	//2. Application
+	//2. cpuProvisioned & memProvisioned, for the requests
+	requestCommodities, err := builder.getRequestCommoditiesSold(containerKey, converter)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, requestCommodities...)
+
+	//3. Application
 	appCommodity, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_APPLICATION).
 		Key(containerKey).
 		Capacity(applicationCommodityDefaultCapacity).
@@ -141,7 +161,85 @@ func (builder *containerDTOBuilder) getCommoditiesSold(containerName, containerK
 	return result, nil
 }
 
-// vCPU, vMem and VMPMAccess are bought by Container from Pod;
+// Build the commodities standing for the requests of the container. Their capacity is the request, and their used
+// value the usage of the resource, as for the request commodities bought from the pod. The capacity of the container,
+// i.e. its limit or the capacity of the pod, stands for a request which is not set.
+func (builder *containerDTOBuilder) getRequestCommoditiesSold(containerKey string, converter *converter) ([]*proto.CommodityDTO, error) {
+	var result []*proto.CommodityDTO
+	for _, rType := range commoditySold {
+		requestType, exist := requestCommoditySold[rType]
+		if !exist {
+			continue
+		}
+		requestUID := metrics.GenerateEntityResourceMetricUID(task.ContainerType, containerKey, rType, metrics.Reservation)
+		requestMetric, err := builder.metricsSink.GetMetric(requestUID)
+		if err != nil {
+			glog.V(4).Infof("Don't find %s request for container %s: %s", rType, containerKey, err)
+			continue
+		}
+		request := requestMetric.GetValue().(float64)
+		if request <= 0 {
+			capacityUID := metrics.GenerateEntityResourceMetricUID(task.ContainerType, containerKey, rType, metrics.Capacity)
+			capacityMetric, err := builder.metricsSink.GetMetric(capacityUID)
+			if err != nil {
+				glog.Errorf("Failed to get %s capacity for container %s without request: %s", rType, containerKey, err)
+				continue
+			}
+			request = capacityMetric.GetValue().(float64)
+		}
+		used, peak, hasPeak, err := builder.getRequestUsage(task.ContainerType, containerKey, rType, converter)
+		if err != nil {
+			glog.Errorf("Failed to get %s used for container %s: %s", rType, containerKey, err)
+			continue
+		}
+		if converter.Convertible(requestType) {
+			request = converter.Convert(requestType, request)
+		}
+		commodity, err := sdkbuilder.NewCommodityDTOBuilder(rTypeMapping[requestType]).
+			Capacity(request).
+			Used(used).
+			Resizable(true).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		if hasPeak {
+			commodity.Peak = &peak
+		}
+		result = append(result, commodity)
+	}
+	return result, nil
+}
+
+// Build the request commodities bought by the container from the pod. Their used value is the usage of the resource,
+// the same as the request commodities sold by the container.
+func (builder *containerDTOBuilder) getRequestCommoditiesBought(containerKey string, converter *converter) ([]*proto.CommodityDTO, error) {
+	var result []*proto.CommodityDTO
+	for _, rType := range commoditySold {
+		requestType, exist := requestCommoditySold[rType]
+		if !exist {
+			continue
+		}
+		used, peak, hasPeak, err := builder.getRequestUsage(task.ContainerType, containerKey, rType, converter)
+		if err != nil {
+			glog.Errorf("Failed to get %s used for container %s: %s", rType, containerKey, err)
+			continue
+		}
+		commodity, err := sdkbuilder.NewCommodityDTOBuilder(rTypeMapping[requestType]).
+			Used(used).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		if hasPeak {
+			commodity.Peak = &peak
+		}
+		result = append(result, commodity)
+	}
+	return result, nil
+}
+
+// vCPU, vMem, cpuProvisioned, memProvisioned, StorageAmount and VMPMAccess are bought by Container from Pod;
 // the VMPMAccess is to bind the container to the hosting pod.
 func (builder *containerDTOBuilder) getCommoditiesBought(podId, containerName, containerId string, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var result []*proto.CommodityDTO
@@ -156,14 +254,29 @@ func (builder *containerDTOBuilder) getCommoditiesBought(podId, containerName, c
 	if err != nil {
 		return nil, err
 	}
-	if len(commodities) != len(commodityBought) {
-		err = fmt.Errorf("mismatch num of commidities (%d Vs. %d) for container:%s, %s", len(commodities), len(commodityBought), containerName, containerId)
+
+	//2. cpuProvisioned & memProvisioned, for the requests
+	requestCommodities, err := builder.getRequestCommoditiesBought(containerId, converter)
+	if err != nil {
+		return nil, err
+	}
+	commodities = append(commodities, requestCommodities...)
+	if expected := len(commodityBought) + len(requestCommoditySold); len(commodities) != expected {
+		err = fmt.Errorf("mismatch num of commidities (%d Vs. %d) for container:%s, %s", len(commodities), expected, containerName, containerId)
 		glog.Error(err)
 		//return nil, err
 	}
 	result = append(result, commodities...)
 
-	//2. VMPMAccess
+	//3. optional resources, e.g. StorageAmount
+	optionalTypes := builder.getAvailableResourceTypes(task.ContainerType, containerId, optionalCommodityBought)
+	optionalCommodities, err := builder.getResourceCommoditiesBought(task.ContainerType, containerId, optionalTypes, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, optionalCommodities...)
+
+	//4. VMPMAccess
 	podAccessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
 		Key(podId).
 		Create()
@@ -0,0 +1,62 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestGetRequestCommoditiesSold(t *testing.T) {
+	containerKey := "pod-uid-0"
+	sink := metrics.NewEntityMetricSink()
+	sink.AddNewMetricEntries(
+		metrics.NewEntityResourceMetric(task.ContainerType, containerKey, metrics.CPU, metrics.Used, 0.2),
+		metrics.NewEntityResourceMetric(task.ContainerType, containerKey, metrics.CPU, metrics.Reservation, 0.5),
+		metrics.NewEntityResourceMetric(task.ContainerType, containerKey, metrics.Memory, metrics.Used, 1024),
+		metrics.NewEntityResourceMetric(task.ContainerType, containerKey, metrics.Memory, metrics.Capacity, 4096),
+		// no memory request.
+		metrics.NewEntityResourceMetric(task.ContainerType, containerKey, metrics.Memory, metrics.Reservation, 0))
+
+	converter := NewConverter().Set(func(input float64) float64 { return input * 2000 }, metrics.CPU, metrics.CPUProvisioned)
+	builder := NewContainerDTOBuilder(sink)
+	commodities, err := builder.getRequestCommoditiesSold(containerKey, converter)
+	if err != nil {
+		t.Fatalf("Failed to get request commodities: %v", err)
+	}
+	if len(commodities) != 2 {
+		t.Fatalf("Expected 2 request commodities, got %d", len(commodities))
+	}
+	expected := map[proto.CommodityDTO_CommodityType][2]float64{
+		proto.CommodityDTO_CPU_PROVISIONED: {1000, 400},
+		// the capacity of the container stands for the unset request.
+		proto.CommodityDTO_MEM_PROVISIONED: {4096, 1024},
+	}
+	for _, comm := range commodities {
+		values, exist := expected[comm.GetCommodityType()]
+		if !exist {
+			t.Errorf("Unexpected commodity %s", comm.GetCommodityType())
+			continue
+		}
+		if comm.GetCapacity() != values[0] || comm.GetUsed() != values[1] || !comm.GetResizable() {
+			t.Errorf("Expected %s capacity %v, used %v and resizable, got %v, %v and %v", comm.GetCommodityType(),
+				values[0], values[1], comm.GetCapacity(), comm.GetUsed(), comm.GetResizable())
+		}
+	}
+
+	// The request commodities bought from the pod have the same used values.
+	bought, err := builder.getRequestCommoditiesBought(containerKey, converter)
+	if err != nil {
+		t.Fatalf("Failed to get request commodities bought: %v", err)
+	}
+	if len(bought) != 2 {
+		t.Fatalf("Expected 2 request commodities bought, got %d", len(bought))
+	}
+	for _, comm := range bought {
+		if values := expected[comm.GetCommodityType()]; comm.GetUsed() != values[1] {
+			t.Errorf("Expected %s bought used %v, got %v", comm.GetCommodityType(), values[1], comm.GetUsed())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package dtofactory
+
+import (
+	"fmt"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// The entity type of workload controllers. The SDK has no dedicated type for them, so they are modeled as
+	// logical pools of pods.
+	WorkloadControllerEntityType = proto.EntityDTO_LOGICAL_POOL
+)
+
+var (
+	commodityTypeBetweenPodAndController map[proto.CommodityDTO_CommodityType]struct{} = map[proto.CommodityDTO_CommodityType]struct{}{
+		proto.CommodityDTO_VCPU: struct{}{},
+		proto.CommodityDTO_VMEM: struct{}{},
+	}
+)
+
+// Build the entityDTOs of workload controllers. A controller is layered over the pods it manages, and buys
+// the VCPU and VMEM they sell, so that the usage of all its replicas adds up in the controller.
+type WorkloadControllerEntityDTOBuilder struct{}
+
+func NewWorkloadControllerEntityDTOBuilder() *WorkloadControllerEntityDTOBuilder {
+	return &WorkloadControllerEntityDTOBuilder{}
+}
+
+func (builder *WorkloadControllerEntityDTOBuilder) BuildEntityDTO(controller *util.WorkloadController,
+	podDTOs []*proto.EntityDTO) (*proto.EntityDTO, error) {
+	ebuilder := sdkbuilder.NewEntityDTOBuilder(WorkloadControllerEntityType, controller.UID).
+		DisplayName(fmt.Sprintf("%s-%s/%s", controller.Kind, controller.Namespace, controller.Name)).
+		WithProperties(property.BuildWorkloadControllerProperties(controller))
+
+	for _, podDTO := range podDTOs {
+		bought, err := builder.getCommoditiesBought(podDTO)
+		if err != nil {
+			glog.Errorf("Failed to get commodities bought by %s from pod %s: %s", controller, podDTO.GetDisplayName(), err)
+			continue
+		}
+		provider := sdkbuilder.CreateProvider(proto.EntityDTO_CONTAINER_POD, podDTO.GetId())
+		ebuilder.Provider(provider).BuysCommodities(bought)
+	}
+
+	entityDTO, err := ebuilder.Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build entityDTO for %s: %s", controller, err)
+	}
+	return entityDTO, nil
+}
+
+func (builder *WorkloadControllerEntityDTOBuilder) getCommoditiesBought(podDTO *proto.EntityDTO) ([]*proto.CommodityDTO, error) {
+	var commoditiesBought []*proto.CommodityDTO
+	for _, commSold := range podDTO.GetCommoditiesSold() {
+		if _, exist := commodityTypeBetweenPodAndController[commSold.GetCommodityType()]; !exist {
+			continue
+		}
+		commBought, err := sdkbuilder.NewCommodityDTOBuilder(commSold.GetCommodityType()).
+			Key(commSold.GetKey()).
+			Used(commSold.GetUsed()).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, commBought)
+	}
+	if len(commoditiesBought) < 1 {
+		return nil, fmt.Errorf("no commodity found")
+	}
+	return commoditiesBought, nil
+}
@@ -1,6 +1,8 @@
 package dtofactory
 
 import (
+	"time"
+
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
@@ -19,6 +21,16 @@ var (
 		metrics.CPUProvisioned:    proto.CommodityDTO_CPU_PROVISIONED,
 		metrics.MemoryProvisioned: proto.CommodityDTO_MEM_PROVISIONED,
 		metrics.Transaction:       proto.CommodityDTO_TRANSACTION,
+		metrics.ResponseTime:      proto.CommodityDTO_RESPONSE_TIME,
+		metrics.NetworkThroughput: proto.CommodityDTO_NET_THROUGHPUT,
+		metrics.EphemeralStorage:  proto.CommodityDTO_STORAGE_AMOUNT,
+		metrics.VolumeStorage:     proto.CommodityDTO_STORAGE_AMOUNT,
+	}
+
+	// The resource types whose usage history is kept to report peak and percentile.
+	historicalResourceTypes = map[metrics.ResourceType]bool{
+		metrics.CPU:    true,
+		metrics.Memory: true,
 	}
 )
 
@@ -89,6 +101,13 @@ func (s *attributeSetter) Set(rType metrics.ResourceType, commBuilder *sdkbuilde
 
 type generalBuilder struct {
 	metricsSink *metrics.EntityMetricSink
+
+	// Optional history of the metrics across discoveries.
+	metricHistory *metrics.EntityMetricHistory
+	// The time of current discovery; the samples recorded with the same time are regarded as one sample.
+	discoveryTime time.Time
+	// The percentile of the usage history reported as used value. The latest sample is used if it is 0.
+	usagePercentile float64
 }
 
 func newGeneralBuilder(sink *metrics.EntityMetricSink) generalBuilder {
@@ -97,6 +116,65 @@ func newGeneralBuilder(sink *metrics.EntityMetricSink) generalBuilder {
 	}
 }
 
+// Keep the usage history of CPU and memory in the given history, and report the peak
+// and the given percentile of the history in the commodities.
+func (builder *generalBuilder) SetMetricHistory(history *metrics.EntityMetricHistory, discoveryTime time.Time,
+	usagePercentile float64) {
+	builder.metricHistory = history
+	builder.discoveryTime = discoveryTime
+	builder.usagePercentile = usagePercentile
+}
+
+// Record the used value of the metric in the history, and get the used value and the peak to report.
+func (builder generalBuilder) getHistoricalUsage(rType metrics.ResourceType, usedMetricUID string,
+	used float64) (usedValue, peakValue float64, hasPeak bool) {
+	if builder.metricHistory == nil || !historicalResourceTypes[rType] {
+		return used, 0, false
+	}
+	builder.metricHistory.Record(usedMetricUID, used, builder.discoveryTime)
+
+	usedValue = used
+	if builder.usagePercentile > 0 {
+		if percentileValue, exist := builder.metricHistory.GetPercentile(usedMetricUID, builder.usagePercentile); exist {
+			usedValue = percentileValue
+		}
+	}
+	peakValue, hasPeak = builder.metricHistory.GetPeak(usedMetricUID)
+	return usedValue, peakValue, hasPeak
+}
+
+// Get the resource types in the given list, which have used value in the sink.
+// It is for the optional commodities, e.g. network throughput is not available in the first discovery.
+func (builder generalBuilder) getAvailableResourceTypes(entityType task.DiscoveredEntityType, entityID string,
+	resourceTypesList []metrics.ResourceType) []metrics.ResourceType {
+	var result []metrics.ResourceType
+	for _, rType := range resourceTypesList {
+		usedMetricUID := metrics.GenerateEntityResourceMetricUID(entityType, entityID, rType, metrics.Used)
+		if _, err := builder.metricsSink.GetMetric(usedMetricUID); err == nil {
+			result = append(result, rType)
+		}
+	}
+	return result
+}
+
+// Get the usage of the resource to report as the used value of the commodity standing for its request, e.g.
+// CPUProvisioned for CPU. A request commodity carries the usage, the same as vCPU and vMem, so that the request
+// is sized to what is used.
+func (builder generalBuilder) getRequestUsage(entityType task.DiscoveredEntityType, entityID string,
+	rType metrics.ResourceType, converter *converter) (usedValue, peakValue float64, hasPeak bool, err error) {
+	usedMetricUID := metrics.GenerateEntityResourceMetricUID(entityType, entityID, rType, metrics.Used)
+	usedMetric, err := builder.metricsSink.GetMetric(usedMetricUID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	usedValue, peakValue, hasPeak = builder.getHistoricalUsage(rType, usedMetricUID, usedMetric.GetValue().(float64))
+	if converter != nil && converter.Convertible(rType) {
+		usedValue = converter.Convert(rType, usedValue)
+		peakValue = converter.Convert(rType, peakValue)
+	}
+	return usedValue, peakValue, hasPeak, nil
+}
+
 // TODO cpuFrequency is passed in as a parameter. We need special handling for cpu related metric as the value collected by Kubernetes is in number of cores. We need to convert it to MHz.
 func (builder generalBuilder) getResourceCommoditiesSold(entityType task.DiscoveredEntityType, entityID string,
 	resourceTypesList []metrics.ResourceType, converter *converter, commodityAttrSetter *attributeSetter) ([]*proto.CommodityDTO, error) {
@@ -117,10 +195,11 @@ func (builder generalBuilder) getResourceCommoditiesSold(entityType task.Discove
 			glog.Errorf("Failed to get %s used for %s %s: %s", rType, entityType, entityID, err)
 			continue
 		}
-		usedValue := usedMetric.GetValue().(float64)
+		usedValue, peakValue, hasPeak := builder.getHistoricalUsage(rType, usedMetricUID, usedMetric.GetValue().(float64))
 		if converter != nil && converter.Convertible(rType) {
 			oldValue := usedValue
 			usedValue = converter.Convert(rType, usedValue)
+			peakValue = converter.Convert(rType, peakValue)
 			glog.V(4).Infof("Convert %s used value from %f to %f for %s - %s", rType, oldValue, usedValue, entityType, entityID)
 		}
 		commBoughtBuilder.Used(usedValue)
@@ -151,6 +230,9 @@ func (builder generalBuilder) getResourceCommoditiesSold(entityType task.Discove
 			glog.Errorf("Failed to build commodity sold: %s", err)
 			continue
 		}
+		if hasPeak {
+			commSold.Peak = &peakValue
+		}
 		resourceCommoditiesSold = append(resourceCommoditiesSold, commSold)
 	}
 	return resourceCommoditiesSold, nil
@@ -174,9 +256,10 @@ func (builder generalBuilder) getResourceCommoditiesBought(entityType task.Disco
 			glog.Errorf("Failed to get %s used for %s %s: %s", rType, entityType, entityID, err)
 			continue
 		}
-		usedValue := usedMetric.GetValue().(float64)
+		usedValue, peakValue, hasPeak := builder.getHistoricalUsage(rType, usedMetricUID, usedMetric.GetValue().(float64))
 		if converter != nil && converter.Convertible(rType) {
 			usedValue = converter.Convert(rType, usedValue)
+			peakValue = converter.Convert(rType, peakValue)
 		}
 		commSoldBuilder.Used(usedValue)
 
@@ -207,6 +290,9 @@ func (builder generalBuilder) getResourceCommoditiesBought(entityType task.Disco
 			glog.Errorf("Failed to build commodity bought: %s", err)
 			continue
 		}
+		if hasPeak {
+			commSold.Peak = &peakValue
+		}
 		resourceCommoditiesSold = append(resourceCommoditiesSold, commSold)
 	}
 	return resourceCommoditiesSold, nil
@@ -0,0 +1,181 @@
+package dtofactory
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	podGroupPrefix       string = "Pods"
+	containerGroupPrefix string = "Containers"
+
+	namespaceGroupKind string = "Namespace"
+	serviceGroupKind   string = "Service"
+)
+
+// Build the groups of Kubernetes constructs from the discovered entityDTOs, so that policies can be set on them:
+//   - the pods of each workload controller, and the containers of the same spec in these pods;
+//   - the pods of each namespace;
+//   - the pods of each service.
+//
+// The containers of the same spec of a controller should be resized consistently, but the vendored SDK cannot
+// mark a group as such; the policy has to be set on the group in the server.
+type GroupDTOBuilder struct {
+	clusterID string
+}
+
+func NewGroupDTOBuilder(clusterID string) *GroupDTOBuilder {
+	return &GroupDTOBuilder{
+		clusterID: clusterID,
+	}
+}
+
+func (builder *GroupDTOBuilder) BuildGroupDTOs(entityDTOs []*proto.EntityDTO) []*proto.GroupDTO {
+	var podDTOs, containerDTOs, controllerDTOs, vAppDTOs []*proto.EntityDTO
+	for _, e := range entityDTOs {
+		switch e.GetEntityType() {
+		case proto.EntityDTO_CONTAINER_POD:
+			podDTOs = append(podDTOs, e)
+		case proto.EntityDTO_CONTAINER:
+			containerDTOs = append(containerDTOs, e)
+		case WorkloadControllerEntityType:
+			controllerDTOs = append(controllerDTOs, e)
+		case proto.EntityDTO_VIRTUAL_APPLICATION:
+			vAppDTOs = append(vAppDTOs, e)
+		}
+	}
+
+	var result []*proto.GroupDTO
+	result = append(result, builder.buildControllerGroups(controllerDTOs, containerDTOs)...)
+	result = append(result, builder.buildNamespaceGroups(podDTOs)...)
+	result = append(result, builder.buildServiceGroups(vAppDTOs)...)
+	glog.V(3).Infof("Built %d groups.", len(result))
+	return result
+}
+
+// The pods of a controller are the providers of the controller entity.
+func (builder *GroupDTOBuilder) buildControllerGroups(controllerDTOs, containerDTOs []*proto.EntityDTO) []*proto.GroupDTO {
+	// Index the containers by pod, and by their index in the pod.
+	podContainers := make(map[string]map[int]*proto.EntityDTO)
+	for _, containerDTO := range containerDTOs {
+		podID, index, err := util.ParseContainerId(containerDTO.GetId())
+		if err != nil {
+			continue
+		}
+		if _, exist := podContainers[podID]; !exist {
+			podContainers[podID] = make(map[int]*proto.EntityDTO)
+		}
+		podContainers[podID][index] = containerDTO
+	}
+
+	var result []*proto.GroupDTO
+	for _, controllerDTO := range controllerDTOs {
+		namespace, kind, name, err := property.GetWorkloadControllerInfoFromProperty(controllerDTO.GetEntityProperties())
+		if err != nil {
+			glog.Errorf("Failed to build groups of controller %s: %s", controllerDTO.GetDisplayName(), err)
+			continue
+		}
+		controllerName := fmt.Sprintf("%s-%s/%s", kind, namespace, name)
+
+		var podIDs []string
+		// The containers with the same index in the pods of a controller have the same spec.
+		containerIDs := make(map[int][]string)
+		containerNames := make(map[int]string)
+		for _, bought := range controllerDTO.GetCommoditiesBought() {
+			podID := bought.GetProviderId()
+			podIDs = append(podIDs, podID)
+			for index, containerDTO := range podContainers[podID] {
+				containerIDs[index] = append(containerIDs[index], containerDTO.GetId())
+				containerNames[index] = containerNameFromDisplayName(containerDTO.GetDisplayName())
+			}
+		}
+		if len(podIDs) == 0 {
+			continue
+		}
+
+		result = append(result, builder.newGroupDTO(proto.EntityDTO_CONTAINER_POD, podGroupPrefix, controllerName, podIDs))
+		for index, ids := range containerIDs {
+			containerGroupName := fmt.Sprintf("%s/%s", controllerName, containerNames[index])
+			result = append(result, builder.newGroupDTO(proto.EntityDTO_CONTAINER, containerGroupPrefix, containerGroupName, ids))
+		}
+	}
+	return result
+}
+
+func (builder *GroupDTOBuilder) buildNamespaceGroups(podDTOs []*proto.EntityDTO) []*proto.GroupDTO {
+	namespacePods := make(map[string][]string)
+	for _, podDTO := range podDTOs {
+		namespace, _, err := property.GetPodInfoFromProperty(podDTO.GetEntityProperties())
+		if err != nil {
+			glog.Errorf("Failed to get the namespace of pod %s: %s", podDTO.GetDisplayName(), err)
+			continue
+		}
+		namespacePods[namespace] = append(namespacePods[namespace], podDTO.GetId())
+	}
+
+	var result []*proto.GroupDTO
+	for namespace, podIDs := range namespacePods {
+		groupName := fmt.Sprintf("%s-%s", namespaceGroupKind, namespace)
+		result = append(result, builder.newGroupDTO(proto.EntityDTO_CONTAINER_POD, podGroupPrefix, groupName, podIDs))
+	}
+	return result
+}
+
+// The pods of a service host the applications which are the providers of the service entity.
+func (builder *GroupDTOBuilder) buildServiceGroups(vAppDTOs []*proto.EntityDTO) []*proto.GroupDTO {
+	var result []*proto.GroupDTO
+	for _, vAppDTO := range vAppDTOs {
+		podSet := make(map[string]struct{})
+		var podIDs []string
+		for _, bought := range vAppDTO.GetCommoditiesBought() {
+			podID, err := util.PodIdFromApp(bought.GetProviderId())
+			if err != nil {
+				glog.Errorf("Failed to get the pod of application %s: %s", bought.GetProviderId(), err)
+				continue
+			}
+			if _, exist := podSet[podID]; !exist {
+				podSet[podID] = struct{}{}
+				podIDs = append(podIDs, podID)
+			}
+		}
+		if len(podIDs) == 0 {
+			continue
+		}
+		serviceName := strings.TrimPrefix(vAppDTO.GetDisplayName(), vAppPrefix+"-")
+		groupName := fmt.Sprintf("%s-%s", serviceGroupKind, serviceName)
+		result = append(result, builder.newGroupDTO(proto.EntityDTO_CONTAINER_POD, podGroupPrefix, groupName, podIDs))
+	}
+	return result
+}
+
+// Build a static group of the given members. The display name is "<prefix>-<name>"; the group name also has the
+// cluster ID, so that it is unique among all the Kubernetes targets.
+func (builder *GroupDTOBuilder) newGroupDTO(entityType proto.EntityDTO_EntityType, prefix, name string, members []string) *proto.GroupDTO {
+	sort.Strings(members)
+	displayName := fmt.Sprintf("%s-%s", prefix, name)
+	return &proto.GroupDTO{
+		EntityType:  &entityType,
+		DisplayName: &displayName,
+		Info: &proto.GroupDTO_GroupName{
+			GroupName: fmt.Sprintf("%s-%s-%s", prefix, builder.clusterID, name),
+		},
+		Members: &proto.GroupDTO_MemberList{
+			MemberList: &proto.GroupDTO_MembersList{
+				Member: members,
+			},
+		},
+	}
+}
+
+// The display name of a container is "<namespace>/<pod name>/<container name>".
+func containerNameFromDisplayName(displayName string) string {
+	return displayName[strings.LastIndex(displayName, "/")+1:]
+}
@@ -0,0 +1,83 @@
+package dtofactory
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestBuildGroupDTOs(t *testing.T) {
+	var entityDTOs []*proto.EntityDTO
+	newEntityDTO := func(b *sdkbuilder.EntityDTOBuilder) *proto.EntityDTO {
+		e, err := b.Create()
+		if err != nil {
+			t.Fatalf("Failed to build entityDTO: %v", err)
+		}
+		entityDTOs = append(entityDTOs, e)
+		return e
+	}
+	vcpu, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).Create()
+
+	controllerBuilder := sdkbuilder.NewEntityDTOBuilder(WorkloadControllerEntityType, "deploy-uid").
+		WithProperties(property.BuildWorkloadControllerProperties(&util.WorkloadController{
+			Kind: util.Kind_Deployment, Namespace: "default", Name: "web"}))
+	for _, podID := range []string{"pod-1", "pod-2"} {
+		pod := &api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: podID, UID: types.UID(podID)}}
+		newEntityDTO(sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, podID).
+			WithProperties(property.BuildPodProperties(pod)))
+		containerID := util.ContainerIdFunc(podID, 0)
+		newEntityDTO(sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER, containerID).
+			DisplayName(util.ContainerNameFunc(pod, &api.Container{Name: "nginx"})))
+		controllerBuilder.Provider(sdkbuilder.CreateProvider(proto.EntityDTO_CONTAINER_POD, podID)).BuysCommodity(vcpu)
+	}
+	newEntityDTO(controllerBuilder)
+
+	appID := util.ApplicationIdFunc(util.ContainerIdFunc("pod-1", 0))
+	transaction, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_TRANSACTION).Create()
+	newEntityDTO(sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_APPLICATION, "svc-uid").
+		DisplayName("vApp-default/web").
+		Provider(sdkbuilder.CreateProvider(proto.EntityDTO_APPLICATION, appID)).
+		BuysCommodity(transaction))
+
+	groups := NewGroupDTOBuilder("cluster").BuildGroupDTOs(entityDTOs)
+
+	expected := map[string][]string{
+		"Pods-Deployment-default/web":             {"pod-1", "pod-2"},
+		"Containers-Deployment-default/web/nginx": {"pod-1-0", "pod-2-0"},
+		"Pods-Namespace-default":                  {"pod-1", "pod-2"},
+		"Pods-Service-default/web":                {"pod-1"},
+	}
+	if len(groups) != len(expected) {
+		t.Errorf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+	for _, group := range groups {
+		members, exist := expected[group.GetDisplayName()]
+		if !exist {
+			t.Errorf("Unexpected group %s", group.GetDisplayName())
+			continue
+		}
+		actual := group.GetMemberList().GetMember()
+		if len(actual) != len(members) {
+			t.Errorf("Expected members %v of group %s, got %v", members, group.GetDisplayName(), actual)
+			continue
+		}
+		for i := range members {
+			if actual[i] != members[i] {
+				t.Errorf("Expected members %v of group %s, got %v", members, group.GetDisplayName(), actual)
+				break
+			}
+		}
+		if group.GetGroupName() != strings.Replace(group.GetDisplayName(), "-", "-cluster-", 1) {
+			t.Errorf("Unexpected group name %s of group %s", group.GetGroupName(), group.GetDisplayName())
+		}
+	}
+}
@@ -2,6 +2,7 @@ package dtofactory
 
 import (
 	"fmt"
+	"math"
 
 	api "k8s.io/client-go/pkg/api/v1"
 
@@ -28,14 +29,24 @@ var (
 	nodeResourceCommoditiesSold = []metrics.ResourceType{
 		metrics.CPU,
 		metrics.Memory,
-		//metrics.CPUProvisioned,
-		//metrics.MemoryProvisioned,
+		metrics.CPUProvisioned,
+		metrics.MemoryProvisioned,
+	}
+
+	// Only sold when the used value is available.
+	nodeOptionalResourceCommoditiesSold = []metrics.ResourceType{
+		metrics.NetworkThroughput,
+		metrics.EphemeralStorage,
 	}
 )
 
 type nodeEntityDTOBuilder struct {
 	generalBuilder
 	stitchingManager *stitching.StitchingManager
+
+	// All the pods hosted by each node, indexed by node name, and the UIDs of the pods discovered among them.
+	nodePods       map[string][]*api.Pod
+	discoveredPods map[string]struct{}
 }
 
 func NewNodeEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *stitching.StitchingManager) *nodeEntityDTOBuilder {
@@ -46,6 +57,21 @@ func NewNodeEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *s
 	}
 }
 
+// The pods which are not monitored, i.e. mirror pods and the pods of DaemonSets, cannot be moved off their nodes, nor
+// can the pods out of scope, which are not discovered. Their usage, or their requests if larger, is reserved in the
+// CPU and memory sold by the nodes as a fixed overhead.
+// nodePods are all the pods running on the nodes, and discoveredPods the pods in scope among them.
+func (builder *nodeEntityDTOBuilder) SetPods(nodePods, discoveredPods []*api.Pod) {
+	builder.nodePods = make(map[string][]*api.Pod)
+	for _, pod := range nodePods {
+		builder.nodePods[pod.Spec.NodeName] = append(builder.nodePods[pod.Spec.NodeName], pod)
+	}
+	builder.discoveredPods = make(map[string]struct{})
+	for _, pod := range discoveredPods {
+		builder.discoveredPods[string(pod.UID)] = struct{}{}
+	}
+}
+
 // Build entityDTOs based on the given node list.
 func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node) ([]*proto.EntityDTO, error) {
 	var result []*proto.EntityDTO
@@ -99,7 +125,7 @@ func (builder *nodeEntityDTOBuilder) BuildEntityDTOs(nodes []*api.Node) ([]*prot
 }
 
 // Build the sold commodityDTO by each node. They are include:
-// VCPU, VMem, CPUProvisioned, MemProvisioned;
+// VCPU, VMem, CPUProvisioned, MemProvisioned, NetThroughput, StorageAmount;
 // VMPMAccessCommodity, ApplicationCommodity, ClusterCommodity.
 func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*proto.CommodityDTO, error) {
 	var commoditiesSold []*proto.CommodityDTO
@@ -122,10 +148,29 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*
 		metrics.CPU, metrics.CPUProvisioned)
 
 	// Resource Commodities
-	resourceCommoditiesSold, err := builder.getResourceCommoditiesSold(task.NodeType, key, nodeResourceCommoditiesSold, converter, nil)
+	resourceTypes := append(nodeResourceCommoditiesSold,
+		builder.getAvailableResourceTypes(task.NodeType, key, nodeOptionalResourceCommoditiesSold)...)
+	resourceCommoditiesSold, err := builder.getResourceCommoditiesSold(task.NodeType, key, resourceTypes, converter, nil)
 	if err != nil {
 		return nil, err
 	}
+	cpuOverhead, memOverhead := builder.getNodeOverhead(node)
+	cpuReserved, memReserved := builder.getNodeReserved(key, metrics.CPU), builder.getNodeReserved(key, metrics.Memory)
+	for _, comm := range resourceCommoditiesSold {
+		var overhead, reserved float64
+		switch comm.GetCommodityType() {
+		case proto.CommodityDTO_VCPU:
+			overhead = converter.Convert(metrics.CPU, cpuOverhead)
+			reserved = converter.Convert(metrics.CPU, cpuReserved)
+		case proto.CommodityDTO_VMEM:
+			overhead = memOverhead
+			reserved = memReserved
+		}
+		if overhead > 0 {
+			glog.V(4).Infof("Overhead of %s of node %s is %f", comm.GetCommodityType(), node.Name, overhead)
+			setNodeOverhead(comm, overhead, reserved)
+		}
+	}
 	commoditiesSold = append(commoditiesSold, resourceCommoditiesSold...)
 
 	// Access commodities: labels.
@@ -178,6 +223,60 @@ func (builder *nodeEntityDTOBuilder) getNodeCommoditiesSold(node *api.Node) ([]*
 	return commoditiesSold, nil
 }
 
+// Get the CPU, in cores, and memory, in KB, used or requested by the pods on the node which are not monitored or not
+// discovered, plus the resources reserved for the kubelet and the system daemons if they are part of the node capacity.
+func (builder *nodeEntityDTOBuilder) getNodeOverhead(node *api.Node) (cpuOverhead, memOverhead float64) {
+	key := util.NodeKeyFunc(node)
+	cpuOverhead = builder.getNodeReserved(key, metrics.CPU)
+	memOverhead = builder.getNodeReserved(key, metrics.Memory)
+	for _, pod := range builder.nodePods[node.Name] {
+		if _, discovered := builder.discoveredPods[string(pod.UID)]; discovered && util.Monitored(pod) {
+			continue
+		}
+		cpuRequest, memRequest, err := util.GetPodResourceRequest(pod)
+		if err != nil {
+			glog.Errorf("Failed to get resource requests of pod %s: %s", util.GetPodClusterID(pod), err)
+		}
+		cpuOverhead += math.Max(builder.getPodUsed(pod, metrics.CPU), cpuRequest)
+		memOverhead += math.Max(builder.getPodUsed(pod, metrics.Memory), memRequest)
+	}
+	return
+}
+
+// Reserve the overhead in the commodity sold by the node. The resources reserved for the kubelet and the system
+// daemons, which are part of the overhead, are not used by any pod and are not in the usage of the node either, so
+// they are also added to the used value; the usage of the pods in the overhead is already in there.
+func setNodeOverhead(comm *proto.CommodityDTO, overhead, reserved float64) {
+	comm.Reservation = &overhead
+	if reserved <= 0 {
+		return
+	}
+	used := comm.GetUsed() + reserved
+	comm.Used = &used
+	if comm.Peak != nil {
+		peak := comm.GetPeak() + reserved
+		comm.Peak = &peak
+	}
+}
+
+func (builder *nodeEntityDTOBuilder) getNodeReserved(key string, rType metrics.ResourceType) float64 {
+	reservedMetricUID := metrics.GenerateEntityResourceMetricUID(task.NodeType, key, rType, metrics.Reservation)
+	reservedMetric, err := builder.metricsSink.GetMetric(reservedMetricUID)
+	if err != nil {
+		return 0
+	}
+	return reservedMetric.GetValue().(float64)
+}
+
+func (builder *nodeEntityDTOBuilder) getPodUsed(pod *api.Pod, rType metrics.ResourceType) float64 {
+	usedMetricUID := metrics.GenerateEntityResourceMetricUID(task.PodType, util.PodKeyFunc(pod), rType, metrics.Used)
+	usedMetric, err := builder.metricsSink.GetMetric(usedMetricUID)
+	if err != nil {
+		return 0
+	}
+	return usedMetric.GetValue().(float64)
+}
+
 // Get the properties of the node. This includes property related to stitching process and node cluster property.
 func (builder *nodeEntityDTOBuilder) getNodeProperties(node *api.Node) ([]*proto.EntityDTO_EntityProperty, error) {
 	var properties []*proto.EntityDTO_EntityProperty
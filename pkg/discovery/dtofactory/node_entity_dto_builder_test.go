@@ -0,0 +1,113 @@
+package dtofactory
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+	kubelettypes "k8s.io/kubernetes/pkg/kubelet/types"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestGetNodeOverhead(t *testing.T) {
+	isController := true
+	newPod := func(name string, cpuRequest string) *api.Pod {
+		return &api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: name, UID: types.UID(name)},
+			Spec: api.PodSpec{
+				NodeName: "node-1",
+				Containers: []api.Container{{
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{api.ResourceCPU: resource.MustParse(cpuRequest)},
+					},
+				}},
+			},
+		}
+	}
+	// a DaemonSet pod using more than it requests.
+	dsPod := newPod("fluentd-abcde", "100m")
+	dsPod.OwnerReferences = []metav1.OwnerReference{{Kind: util.Kind_DaemonSet, Name: "fluentd", Controller: &isController}}
+	// a mirror pod using less than it requests.
+	mirrorPod := newPod("kube-proxy-node-1", "200m")
+	mirrorPod.Annotations = map[string]string{kubelettypes.ConfigMirrorAnnotationKey: "mirror"}
+	// a pod which can be moved.
+	appPod := newPod("web", "1")
+	// a pod out of scope, which is not discovered.
+	otherPod := newPod("batch", "100m")
+
+	sink := metrics.NewEntityMetricSink()
+	for pod, used := range map[*api.Pod]float64{dsPod: 0.3, mirrorPod: 0.1, appPod: 0.5, otherPod: 0.2} {
+		sink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(task.PodType, util.PodKeyFunc(pod), metrics.CPU, metrics.Used, used),
+			metrics.NewEntityResourceMetric(task.PodType, util.PodKeyFunc(pod), metrics.Memory, metrics.Used, 1024))
+	}
+
+	builder := NewNodeEntityDTOBuilder(sink, nil)
+	builder.SetPods([]*api.Pod{dsPod, mirrorPod, appPod, otherPod}, []*api.Pod{dsPod, mirrorPod, appPod})
+	cpuOverhead, memOverhead := builder.getNodeOverhead(&api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+	if cpuOverhead < 0.699 || cpuOverhead > 0.701 {
+		t.Errorf("Expected CPU overhead 0.7 core, got %f", cpuOverhead)
+	}
+	if memOverhead != 3072 {
+		t.Errorf("Expected memory overhead 3072 KB, got %f", memOverhead)
+	}
+}
+
+func TestGetNodeOverheadWithReservedResources(t *testing.T) {
+	node := &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: api.NodeStatus{
+			Capacity: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse("4"),
+				api.ResourceMemory: resource.MustParse("8Gi"),
+			},
+			Allocatable: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse("3500m"),
+				api.ResourceMemory: resource.MustParse("7Gi"),
+			},
+		},
+	}
+	cpuReserved, memReserved := util.GetNodeReservedResources(node)
+	key := util.NodeKeyFunc(node)
+	sink := metrics.NewEntityMetricSink()
+	sink.AddNewMetricEntries(
+		metrics.NewEntityResourceMetric(task.NodeType, key, metrics.CPU, metrics.Reservation, cpuReserved),
+		metrics.NewEntityResourceMetric(task.NodeType, key, metrics.Memory, metrics.Reservation, memReserved))
+
+	builder := NewNodeEntityDTOBuilder(sink, nil)
+	builder.SetPods(nil, nil)
+	cpuOverhead, memOverhead := builder.getNodeOverhead(node)
+	if cpuOverhead != 0.5 {
+		t.Errorf("Expected CPU overhead 0.5 core, got %f", cpuOverhead)
+	}
+	if memOverhead != 1024*1024 {
+		t.Errorf("Expected memory overhead 1048576 KB, got %f", memOverhead)
+	}
+}
+
+func TestSetNodeOverhead(t *testing.T) {
+	used, peak := 1000.0, 1500.0
+	comm := &proto.CommodityDTO{Used: &used, Peak: &peak}
+	setNodeOverhead(comm, 800, 500)
+	if comm.GetReservation() != 800 {
+		t.Errorf("Expected reservation 800, got %f", comm.GetReservation())
+	}
+	if comm.GetUsed() != 1500 || comm.GetPeak() != 2000 {
+		t.Errorf("Expected used 1500 and peak 2000, got %f and %f", comm.GetUsed(), comm.GetPeak())
+	}
+
+	// without reserved resources, the overhead is already in the used value.
+	used = 1000.0
+	comm = &proto.CommodityDTO{Used: &used}
+	setNodeOverhead(comm, 300, 0)
+	if comm.GetReservation() != 300 || comm.GetUsed() != 1000 {
+		t.Errorf("Expected reservation 300 and used 1000, got %f and %f", comm.GetReservation(), comm.GetUsed())
+	}
+}
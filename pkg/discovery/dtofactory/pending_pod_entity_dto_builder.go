@@ -0,0 +1,129 @@
+package dtofactory
+
+import (
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	// The provider ID of the commodities bought by an unplaced entity. The provider ID is required by the protocol;
+	// the purchase of an unplaced entity only has the type of the provider it is to be placed on.
+	UnplacedProviderID = ""
+)
+
+// Build the entityDTOs of the pods which cannot be scheduled to any node. Such a pod is unplaced: it buys its requested
+// resources from no node, so that the server looks for a node to place it, and recommends to provision one if there is
+// not enough capacity in the cluster. It also buys from the providers it is bound to regardless of its node, e.g. the
+// quota of its namespace added by the QuotaProcessor.
+type PendingPodEntityDTOBuilder struct {
+	// The CPU frequency in MHz used to convert the CPU requests of the pods from cores.
+	cpuFrequency float64
+	// The key of the cluster commodity sold by the nodes.
+	clusterKey string
+}
+
+func NewPendingPodEntityDTOBuilder(cpuFrequency float64, clusterKey string) *PendingPodEntityDTOBuilder {
+	return &PendingPodEntityDTOBuilder{
+		cpuFrequency: cpuFrequency,
+		clusterKey:   clusterKey,
+	}
+}
+
+// Build the entityDTO of the pending pod, with the reason why it failed to be scheduled if known.
+func (builder *PendingPodEntityDTOBuilder) BuildEntityDTO(pod *api.Pod, failure string) (*proto.EntityDTO, error) {
+	commoditiesBought, err := builder.getPodCommoditiesBought(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	properties := property.BuildPodProperties(pod)
+	if failure != "" {
+		properties = append(properties, property.BuildPodSchedulingFailureProperty(failure))
+	}
+
+	entityDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(pod.UID)).
+		DisplayName(util.GetPodClusterID(pod)).
+		WithProperties(properties).
+		Create()
+	if err != nil {
+		return nil, err
+	}
+	// The SDK builder only buys from placed providers.
+	entityDTO.CommoditiesBought = append(entityDTO.CommoditiesBought,
+		buildUnplacedCommoditiesBought(proto.EntityDTO_VIRTUAL_MACHINE, commoditiesBought))
+	return entityDTO, nil
+}
+
+// The pending pod buys the resources it requests, and the access commodities required by the nodes.
+func (builder *PendingPodEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod) ([]*proto.CommodityDTO, error) {
+	var commoditiesBought []*proto.CommodityDTO
+	cpuRequest, memRequest, err := util.GetPodResourceRequest(pod)
+	if err != nil {
+		return nil, err
+	}
+	requests := map[proto.CommodityDTO_CommodityType]float64{
+		proto.CommodityDTO_VCPU:            cpuRequest * builder.cpuFrequency,
+		proto.CommodityDTO_VMEM:            memRequest,
+		proto.CommodityDTO_CPU_PROVISIONED: cpuRequest * builder.cpuFrequency,
+		proto.CommodityDTO_MEM_PROVISIONED: memRequest,
+	}
+	for _, cType := range []proto.CommodityDTO_CommodityType{proto.CommodityDTO_VCPU, proto.CommodityDTO_VMEM,
+		proto.CommodityDTO_CPU_PROVISIONED, proto.CommodityDTO_MEM_PROVISIONED} {
+		comm, err := sdkbuilder.NewCommodityDTOBuilder(cType).
+			Used(requests[cType]).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, comm)
+	}
+
+	// Access commodities: selectors.
+	for key, value := range pod.Spec.NodeSelector {
+		accessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+			Key(key + "=" + value).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, accessComm)
+	}
+
+	// Access commodity: schedulable.
+	schedAccessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
+		Key(schedAccessCommodityKey).
+		Create()
+	if err != nil {
+		return nil, err
+	}
+	commoditiesBought = append(commoditiesBought, schedAccessComm)
+
+	// Cluster commodity.
+	if builder.clusterKey != "" {
+		clusterComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_CLUSTER).
+			Key(builder.clusterKey).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, clusterComm)
+	}
+
+	return commoditiesBought, nil
+}
+
+// Build the commodities bought by an unplaced entity from the given type of provider.
+func buildUnplacedCommoditiesBought(pType proto.EntityDTO_EntityType,
+	commodities []*proto.CommodityDTO) *proto.EntityDTO_CommodityBought {
+	providerID := UnplacedProviderID
+	return &proto.EntityDTO_CommodityBought{
+		ProviderId:   &providerID,
+		ProviderType: &pType,
+		Bought:       commodities,
+	}
+}
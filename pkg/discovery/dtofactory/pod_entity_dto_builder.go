@@ -22,17 +22,28 @@ const (
 )
 
 var (
+	// The requests, i.e. cpuProvisioned and memProvisioned, are also sold to the containers, see getPodRequestCommoditiesSold.
 	podResourceCommoditySold = []metrics.ResourceType{
 		metrics.CPU,
 		metrics.Memory,
 	}
 
+	// Only sold when the used value is available.
+	podOptionalResourceCommoditySold = []metrics.ResourceType{
+		metrics.EphemeralStorage,
+	}
+
 	podResourceCommodityBought = []metrics.ResourceType{
 		metrics.CPU,
 		metrics.Memory,
-		// TODO, add back provisioned commodity later.
-		//metrics.CPUProvisioned,
-		//metrics.MemoryProvisioned,
+		metrics.CPUProvisioned,
+		metrics.MemoryProvisioned,
+	}
+
+	// Only bought when the used value is available.
+	podOptionalResourceCommodityBought = []metrics.ResourceType{
+		metrics.NetworkThroughput,
+		metrics.EphemeralStorage,
 	}
 )
 
@@ -40,6 +51,9 @@ type podEntityDTOBuilder struct {
 	generalBuilder
 	stitchingManager *stitching.StitchingManager
 	nodeNameUIDMap   map[string]string
+
+	// The persistent volumes bound to the claims, indexed by "namespace/claim name".
+	persistentVolumes map[string]*api.PersistentVolume
 }
 
 func NewPodEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *stitching.StitchingManager, nodeNameUIDMap map[string]string) *podEntityDTOBuilder {
@@ -50,6 +64,11 @@ func NewPodEntityDTOBuilder(sink *metrics.EntityMetricSink, stitchingManager *st
 	}
 }
 
+// The pods buy the storage of the persistent volumes they mount through their claims.
+func (builder *podEntityDTOBuilder) SetPersistentVolumes(volumesByClaim map[string]*api.PersistentVolume) {
+	builder.persistentVolumes = volumesByClaim
+}
+
 // Build entityDTOs based on the given pod list.
 func (builder *podEntityDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.EntityDTO, error) {
 	var result []*proto.EntityDTO
@@ -92,12 +111,28 @@ func (builder *podEntityDTOBuilder) BuildEntityDTOs(pods []*api.Pod) ([]*proto.E
 		entityDTOBuilder = entityDTOBuilder.Provider(provider)
 		entityDTOBuilder.BuysCommodities(commoditiesBought)
 
+		// storage bought from the persistent volumes.
+		podVolumes := util.GetPodPersistentVolumes(pod, builder.persistentVolumes)
+		for _, podVolume := range podVolumes {
+			storageComm, err := builder.getVolumeCommodityBought(pod, podVolume)
+			if err != nil {
+				glog.Errorf("Failed to build commodity bought by pod %s from volume %s: %s", displayName,
+					podVolume.PersistentVolume.Name, err)
+				continue
+			}
+			storageProvider := sdkbuilder.CreateProvider(proto.EntityDTO_STORAGE, string(podVolume.PersistentVolume.UID))
+			entityDTOBuilder.Provider(storageProvider).BuysCommodity(storageComm)
+		}
+
 		// entities' properties.
 		properties, err := builder.getPodProperties(pod)
 		if err != nil {
 			glog.Errorf("Failed to get required pod properties: %s", err)
 			continue
 		}
+		if len(podVolumes) > 0 {
+			properties = append(properties, property.BuildPodVolumeProperties(podVolumes)...)
+		}
 		entityDTOBuilder = entityDTOBuilder.WithProperties(properties)
 
 		if !util.Monitored(pod) {
@@ -133,7 +168,7 @@ func (builder *podEntityDTOBuilder) getNodeCPUFrequency(pod *api.Pod) (float64,
 }
 
 // Build the sold commodityDTO by each pod. They are:
-// vCPU, vMem and VMPMAccess; VMPMAccess is used to bind container to the hosting pod (no move).
+// vCPU, vMem, cpuProvisioned, memProvisioned, StorageAmount and VMPMAccess; VMPMAccess is used to bind container to the hosting pod (no move).
 func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var commoditiesSold []*proto.CommodityDTO
 	key := util.PodKeyFunc(pod)
@@ -145,12 +180,21 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequ
 	attributeSetter.Add(func(commBuilder *sdkbuilder.CommodityDTOBuilder) { commBuilder.Resizable(false) }, metrics.CPU, metrics.Memory)
 
 	// Resource Commodities
-	resourceCommoditiesSold, err := builder.getResourceCommoditiesSold(task.PodType, key, podResourceCommoditySold, converter, attributeSetter)
+	resourceTypes := append(podResourceCommoditySold,
+		builder.getAvailableResourceTypes(task.PodType, key, podOptionalResourceCommoditySold)...)
+	resourceCommoditiesSold, err := builder.getResourceCommoditiesSold(task.PodType, key, resourceTypes, converter, attributeSetter)
 	if err != nil {
 		return nil, err
 	}
 	commoditiesSold = append(commoditiesSold, resourceCommoditiesSold...)
 
+	// Request Commodities
+	requestCommoditiesSold, err := builder.getPodRequestCommoditiesSold(key, converter)
+	if err != nil {
+		return nil, err
+	}
+	commoditiesSold = append(commoditiesSold, requestCommoditiesSold...)
+
 	// vmpmAccess commodity
 	podAccessComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMPM_ACCESS).
 		Key(string(pod.UID)).
@@ -164,8 +208,48 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesSold(pod *api.Pod, cpuFrequ
 	return commoditiesSold, nil
 }
 
+// Build the request commodities sold by the pod to its containers. Their capacity is the capacity of the pod, and
+// their used value the usage of the pod, as for the request commodities bought by the containers.
+func (builder *podEntityDTOBuilder) getPodRequestCommoditiesSold(key string, converter *converter) ([]*proto.CommodityDTO, error) {
+	var result []*proto.CommodityDTO
+	for _, rType := range podResourceCommoditySold {
+		requestType, exist := requestCommoditySold[rType]
+		if !exist {
+			continue
+		}
+		capacityUID := metrics.GenerateEntityResourceMetricUID(task.PodType, key, requestType, metrics.Capacity)
+		capacityMetric, err := builder.metricsSink.GetMetric(capacityUID)
+		if err != nil {
+			glog.Errorf("Failed to get %s capacity for pod %s: %s", requestType, key, err)
+			continue
+		}
+		capacity := capacityMetric.GetValue().(float64)
+		if converter.Convertible(requestType) {
+			capacity = converter.Convert(requestType, capacity)
+		}
+		used, peak, hasPeak, err := builder.getRequestUsage(task.PodType, key, rType, converter)
+		if err != nil {
+			glog.Errorf("Failed to get %s used for pod %s: %s", rType, key, err)
+			continue
+		}
+		commodity, err := sdkbuilder.NewCommodityDTOBuilder(rTypeMapping[requestType]).
+			Capacity(capacity).
+			Used(used).
+			Resizable(false).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		if hasPeak {
+			commodity.Peak = &peak
+		}
+		result = append(result, commodity)
+	}
+	return result, nil
+}
+
 // Build the bought commodityDTO by each pod. They are:
-// vCPU, vMem, cpuProvisioned, memProvisioned, access, cluster.
+// vCPU, vMem, cpuProvisioned, memProvisioned, netThroughput, storageAmount, access, cluster.
 func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod, cpuFrequency float64) ([]*proto.CommodityDTO, error) {
 	var commoditiesBought []*proto.CommodityDTO
 	key := util.PodKeyFunc(pod)
@@ -178,7 +262,9 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod, cpuFre
 	//attributeSetter.Add(func(commBuilder *sdkbuilder.CommodityDTOBuilder) { commBuilder.Resizable(true) }, metrics.CPU, metrics.Memory)
 
 	// Resource Commodities.
-	resourceCommoditiesBought, err := builder.getResourceCommoditiesBought(task.PodType, key, podResourceCommodityBought, converter, nil)
+	resourceTypes := append(podResourceCommodityBought,
+		builder.getAvailableResourceTypes(task.PodType, key, podOptionalResourceCommodityBought)...)
+	resourceCommoditiesBought, err := builder.getResourceCommoditiesBought(task.PodType, key, resourceTypes, converter, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -229,6 +315,20 @@ func (builder *podEntityDTOBuilder) getPodCommoditiesBought(pod *api.Pod, cpuFre
 	return commoditiesBought, nil
 }
 
+// Build the storage amount bought from the persistent volume mounted by the pod, in MB.
+// The used value is reported by kubelet; it is 0 if not available, e.g. for the volumes kubelet cannot measure.
+func (builder *podEntityDTOBuilder) getVolumeCommodityBought(pod *api.Pod, podVolume *util.PodVolume) (*proto.CommodityDTO, error) {
+	used := 0.0
+	usedMetricUID := metrics.GenerateEntityResourceMetricUID(task.VolumeType,
+		util.PodVolumeKeyFunc(pod, podVolume.VolumeName), metrics.VolumeStorage, metrics.Used)
+	if usedMetric, err := builder.metricsSink.GetMetric(usedMetricUID); err == nil {
+		used = usedMetric.GetValue().(float64)
+	}
+	return sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT).
+		Used(used).
+		Create()
+}
+
 // Get the properties of the pod. This includes property related to pod cluster property.
 func (builder *podEntityDTOBuilder) getPodProperties(pod *api.Pod) ([]*proto.EntityDTO_EntityProperty, error) {
 	var properties []*proto.EntityDTO_EntityProperty
@@ -0,0 +1,97 @@
+package property
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	k8sControllerKind          = "KubernetesControllerKind"
+	k8sControllerName          = "KubernetesControllerName"
+	k8sControllerOwner         = "KubernetesControllerOwner"
+	k8sControllerChain         = "KubernetesControllerChain"
+	k8sControllerReplicas      = "KubernetesControllerReplicas"
+	k8sControllerReadyReplicas = "KubernetesControllerReadyReplicas"
+	k8sTemplateCPURequest      = "KubernetesTemplateCPURequest"
+	k8sTemplateCPULimit        = "KubernetesTemplateCPULimit"
+	k8sTemplateMemoryRequest   = "KubernetesTemplateMemoryRequest"
+	k8sTemplateMemoryLimit     = "KubernetesTemplateMemoryLimit"
+)
+
+func newProperty(name, value string) *proto.EntityDTO_EntityProperty {
+	propertyNamespace := k8sPropertyNamespace
+	return &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &name,
+		Value:     &value,
+	}
+}
+
+// Build entity properties of a workload controller: its namespace, kind and name, the number of replicas,
+// the object which controls it if any, and the total resources of the pod template.
+// CPU is in millicores and memory is in Kb.
+func BuildWorkloadControllerProperties(controller *util.WorkloadController) []*proto.EntityDTO_EntityProperty {
+	properties := []*proto.EntityDTO_EntityProperty{
+		newProperty(k8sNamespace, controller.Namespace),
+		newProperty(k8sControllerKind, controller.Kind),
+		newProperty(k8sControllerName, controller.Name),
+		newProperty(k8sControllerReplicas, strconv.Itoa(int(controller.Replicas))),
+		newProperty(k8sControllerReadyReplicas, strconv.Itoa(int(controller.ReadyReplicas))),
+	}
+	if controller.Owner != nil {
+		properties = append(properties, newProperty(k8sControllerOwner,
+			fmt.Sprintf("%s/%s", controller.Owner.Kind, controller.Owner.Name)))
+	}
+
+	cpuRequest, cpuLimit, memRequest, memLimit := util.GetTemplateResources(controller.Template)
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	properties = append(properties,
+		newProperty(k8sTemplateCPURequest, formatFloat(cpuRequest)),
+		newProperty(k8sTemplateCPULimit, formatFloat(cpuLimit)),
+		newProperty(k8sTemplateMemoryRequest, formatFloat(memRequest)),
+		newProperty(k8sTemplateMemoryLimit, formatFloat(memLimit)))
+	return properties
+}
+
+// Build entity properties of a pod for its controllers. The kind and name are of the top controller, i.e. the one
+// modeled as an entity; the chain lists all the controllers from the owner of the pod to the top one,
+// e.g. "ReplicaSet/web-2401,Deployment/web".
+func BuildPodControllerProperties(controller *util.WorkloadController, chain []*util.WorkloadController) []*proto.EntityDTO_EntityProperty {
+	var links []string
+	for _, c := range chain {
+		links = append(links, fmt.Sprintf("%s/%s", c.Kind, c.Name))
+	}
+	return []*proto.EntityDTO_EntityProperty{
+		newProperty(k8sControllerKind, controller.Kind),
+		newProperty(k8sControllerName, controller.Name),
+		newProperty(k8sControllerChain, strings.Join(links, ",")),
+	}
+}
+
+// Get the namespace, kind and name of a workload controller from entity property.
+func GetWorkloadControllerInfoFromProperty(properties []*proto.EntityDTO_EntityProperty) (namespace, kind, name string, err error) {
+	for _, property := range properties {
+		if property.GetNamespace() != k8sPropertyNamespace {
+			continue
+		}
+		switch property.GetName() {
+		case k8sNamespace:
+			namespace = property.GetValue()
+		case k8sControllerKind:
+			kind = property.GetValue()
+		case k8sControllerName:
+			name = property.GetValue()
+		}
+	}
+	if namespace == "" || kind == "" || name == "" {
+		err = fmt.Errorf("incomplete controller properties: namespace=%q, kind=%q, name=%q", namespace, kind, name)
+	}
+	return
+}
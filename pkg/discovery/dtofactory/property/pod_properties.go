@@ -14,6 +14,9 @@ const (
 	k8sPodName           = "KubernetesPodName"
 	k8sNodeName          = "KubernetesNodeName"
 	k8sContainerIndex    = "Kubernetes-Container-Index"
+
+	// The reason why a pending pod cannot be scheduled to any node.
+	KubernetesSchedulingFailure = "KubernetesSchedulingFailure"
 )
 
 // Build entity properties of a pod. The properties are consisted of name and namespace of a pod.
@@ -41,6 +44,17 @@ func BuildPodProperties(pod *api.Pod) []*proto.EntityDTO_EntityProperty {
 	return properties
 }
 
+// Build the property of the reason why a pending pod cannot be scheduled.
+func BuildPodSchedulingFailureProperty(message string) *proto.EntityDTO_EntityProperty {
+	propertyNamespace := k8sPropertyNamespace
+	propertyName := KubernetesSchedulingFailure
+	return &proto.EntityDTO_EntityProperty{
+		Namespace: &propertyNamespace,
+		Name:      &propertyName,
+		Value:     &message,
+	}
+}
+
 // Get the namespace and name of a pod from entity property.
 func GetPodInfoFromProperty(properties []*proto.EntityDTO_EntityProperty) (string, string, error) {
 	podNamespace := ""
@@ -0,0 +1,58 @@
+package property
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	k8sPersistentVolumes     = "KubernetesPersistentVolumes"
+	k8sPersistentVolumeName  = "KubernetesPersistentVolumeName"
+	k8sPersistentVolumeClaim = "KubernetesPersistentVolumeClaim"
+	k8sPersistentVolumePods  = "KubernetesPersistentVolumePods"
+	k8sStorageClass          = "KubernetesStorageClass"
+	k8sStorageProvisioner    = "KubernetesStorageProvisioner"
+)
+
+// Build entity properties of a pod for the persistent volumes it mounts, as "<claim>=<volume>" pairs,
+// e.g. "data-db-0=pvc-2f1e,logs=pvc-8c3a".
+func BuildPodVolumeProperties(podVolumes []*util.PodVolume) []*proto.EntityDTO_EntityProperty {
+	var links []string
+	for _, podVolume := range podVolumes {
+		links = append(links, fmt.Sprintf("%s=%s", podVolume.ClaimName, podVolume.PersistentVolume.Name))
+	}
+	return []*proto.EntityDTO_EntityProperty{
+		newProperty(k8sPersistentVolumes, strings.Join(links, ",")),
+	}
+}
+
+// Build entity properties of a persistent volume: its name, the namespace and name of the claim bound to it,
+// the pods mounting it, and its storage class and provisioner if any.
+func BuildPersistentVolumeProperties(pv *api.PersistentVolume, provisioner string, podNames []string) []*proto.EntityDTO_EntityProperty {
+	properties := []*proto.EntityDTO_EntityProperty{
+		newProperty(k8sPersistentVolumeName, pv.Name),
+	}
+	if pv.Spec.ClaimRef != nil {
+		properties = append(properties,
+			newProperty(k8sNamespace, pv.Spec.ClaimRef.Namespace),
+			newProperty(k8sPersistentVolumeClaim, pv.Spec.ClaimRef.Name))
+	}
+	if len(podNames) > 0 {
+		sort.Strings(podNames)
+		properties = append(properties, newProperty(k8sPersistentVolumePods, strings.Join(podNames, ",")))
+	}
+	if class := util.GetPersistentVolumeClass(pv); class != "" {
+		properties = append(properties, newProperty(k8sStorageClass, class))
+	}
+	if provisioner != "" {
+		properties = append(properties, newProperty(k8sStorageProvisioner, provisioner))
+	}
+	return properties
+}
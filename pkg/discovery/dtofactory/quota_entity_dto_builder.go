@@ -0,0 +1,183 @@
+package dtofactory
+
+import (
+	"fmt"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	quotaPrefix string = "Quota"
+)
+
+// A resource which can be limited by a ResourceQuota, and the commodity it is modeled as.
+// CPU is in cores, as a quota applies to the pods on any node whatever their CPU frequency, and memory is in Kb,
+// for both the quota and the pods buying from it.
+type quotaResource struct {
+	commodityType proto.CommodityDTO_CommodityType
+	// The resource names in the quota; the first one found is used.
+	resourceNames []api.ResourceName
+	isCPU         bool
+}
+
+var (
+	quotaResources = []quotaResource{
+		{proto.CommodityDTO_CPU_ALLOCATION, []api.ResourceName{api.ResourceLimitsCPU}, true},
+		{proto.CommodityDTO_MEM_ALLOCATION, []api.ResourceName{api.ResourceLimitsMemory}, false},
+		{proto.CommodityDTO_CPU_PROVISIONED, []api.ResourceName{api.ResourceRequestsCPU, api.ResourceCPU}, true},
+		{proto.CommodityDTO_MEM_PROVISIONED, []api.ResourceName{api.ResourceRequestsMemory, api.ResourceMemory}, false},
+	}
+)
+
+// Build one entityDTO for each namespace with ResourceQuotas. It sells the quota of CPU and memory limits and
+// requests, which the pods in the namespace buy, so that the market respects the headroom of the quota.
+type QuotaEntityDTOBuilder struct {
+	clusterID string
+}
+
+func NewQuotaEntityDTOBuilder(clusterID string) *QuotaEntityDTOBuilder {
+	return &QuotaEntityDTOBuilder{
+		clusterID: clusterID,
+	}
+}
+
+// Build the quota entityDTOs of the given ResourceQuotas. The result is keyed by namespace.
+func (builder *QuotaEntityDTOBuilder) BuildEntityDTOs(quotas []*api.ResourceQuota) map[string]*proto.EntityDTO {
+	namespaceQuotas := make(map[string][]*api.ResourceQuota)
+	for _, quota := range quotas {
+		// A scoped quota only applies to some of the pods, e.g. the BestEffort ones, which can't be modeled by
+		// commodities bought by all the pods in the namespace.
+		if len(quota.Spec.Scopes) > 0 {
+			glog.V(3).Infof("Skip quota %s/%s with scopes %v.", quota.Namespace, quota.Name, quota.Spec.Scopes)
+			continue
+		}
+		namespaceQuotas[quota.Namespace] = append(namespaceQuotas[quota.Namespace], quota)
+	}
+
+	result := make(map[string]*proto.EntityDTO)
+	for namespace, quotaList := range namespaceQuotas {
+		id := builder.quotaEntityID(namespace)
+		commoditiesSold := builder.getQuotaCommoditiesSold(id, quotaList)
+		if len(commoditiesSold) == 0 {
+			glog.V(3).Infof("Quotas in namespace %s don't limit CPU or memory.", namespace)
+			continue
+		}
+
+		entityDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_DATACENTER, id).
+			DisplayName(fmt.Sprintf("%s-%s", quotaPrefix, namespace)).
+			SellsCommodities(commoditiesSold).
+			Create()
+		if err != nil {
+			glog.Errorf("Failed to build quota entityDTO for namespace %s: %s", namespace, err)
+			continue
+		}
+		result[namespace] = entityDTO
+	}
+	return result
+}
+
+func (builder *QuotaEntityDTOBuilder) quotaEntityID(namespace string) string {
+	return fmt.Sprintf("%s-%s-%s", quotaPrefix, builder.clusterID, namespace)
+}
+
+// A namespace may have several quotas limiting the same resource; the one with the least headroom is used.
+// The commodities are keyed by the quota entity ID, so that pods can only buy from the quota of their namespace.
+func (builder *QuotaEntityDTOBuilder) getQuotaCommoditiesSold(key string, quotas []*api.ResourceQuota) []*proto.CommodityDTO {
+	var commoditiesSold []*proto.CommodityDTO
+	for _, resource := range quotaResources {
+		found := false
+		var capacity, used float64
+		for _, quota := range quotas {
+			quotaHard, quotaUsed, exist := getQuotaValues(quota, resource)
+			if !exist {
+				continue
+			}
+			if !found || quotaHard-quotaUsed < capacity-used {
+				capacity, used = quotaHard, quotaUsed
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		commSold, err := sdkbuilder.NewCommodityDTOBuilder(resource.commodityType).
+			Key(key).
+			Capacity(capacity).
+			Used(used).
+			Resizable(false).
+			Create()
+		if err != nil {
+			glog.Errorf("Failed to build %s commodity sold by quota %s: %s", resource.commodityType, key, err)
+			continue
+		}
+		commoditiesSold = append(commoditiesSold, commSold)
+	}
+	return commoditiesSold
+}
+
+// Get the hard limit and used value of the resource in the quota. The hard limit in the status is the one enforced;
+// the spec is used before the quota controller has updated the status.
+func getQuotaValues(quota *api.ResourceQuota, resource quotaResource) (hard, used float64, exist bool) {
+	hardList := quota.Status.Hard
+	if len(hardList) == 0 {
+		hardList = quota.Spec.Hard
+	}
+	for _, name := range resource.resourceNames {
+		hardQuantity, found := hardList[name]
+		if !found {
+			continue
+		}
+		usedQuantity := quota.Status.Used[name]
+		if resource.isCPU {
+			return float64(hardQuantity.MilliValue()) / util.MilliToUnit, float64(usedQuantity.MilliValue()) / util.MilliToUnit, true
+		}
+		return float64(hardQuantity.Value()) / util.KilobytesToBytes, float64(usedQuantity.Value()) / util.KilobytesToBytes, true
+	}
+	return 0, 0, false
+}
+
+// Get the commodities bought by the pod from each commodity sold by the quota entity of its namespace.
+func (builder *QuotaEntityDTOBuilder) GetPodCommoditiesBought(pod *api.Pod, quotaDTO *proto.EntityDTO) ([]*proto.CommodityDTO, error) {
+	cpuLimit, memLimit, err := util.GetPodResourceLimits(pod)
+	if err != nil {
+		return nil, err
+	}
+	cpuRequest, memRequest, err := util.GetPodResourceRequest(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	var commoditiesBought []*proto.CommodityDTO
+	for _, commSold := range quotaDTO.GetCommoditiesSold() {
+		var used float64
+		switch commSold.GetCommodityType() {
+		case proto.CommodityDTO_CPU_ALLOCATION:
+			used = cpuLimit
+		case proto.CommodityDTO_MEM_ALLOCATION:
+			used = memLimit
+		case proto.CommodityDTO_CPU_PROVISIONED:
+			used = cpuRequest
+		case proto.CommodityDTO_MEM_PROVISIONED:
+			used = memRequest
+		default:
+			continue
+		}
+		commBought, err := sdkbuilder.NewCommodityDTOBuilder(commSold.GetCommodityType()).
+			Key(commSold.GetKey()).
+			Used(used).
+			Create()
+		if err != nil {
+			return nil, err
+		}
+		commoditiesBought = append(commoditiesBought, commBought)
+	}
+	return commoditiesBought, nil
+}
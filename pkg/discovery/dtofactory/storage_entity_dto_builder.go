@@ -0,0 +1,66 @@
+package dtofactory
+
+import (
+	"fmt"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+const (
+	persistentVolumePrefix string = "PersistentVolume"
+)
+
+// Build the entityDTOs of persistent volumes. A persistent volume sells the storage amount bought by the pods
+// mounting it. Its capacity is the capacity of the volume, and its used value is the largest amount reported
+// by these pods, as all of them see the same filesystem.
+type StorageEntityDTOBuilder struct{}
+
+func NewStorageEntityDTOBuilder() *StorageEntityDTOBuilder {
+	return &StorageEntityDTOBuilder{}
+}
+
+// Build the entityDTO of the persistent volume, provisioned by the given provisioner if any,
+// and mounted by the given pods.
+func (builder *StorageEntityDTOBuilder) BuildEntityDTO(pv *api.PersistentVolume, provisioner string,
+	podDTOs []*proto.EntityDTO) (*proto.EntityDTO, error) {
+	storageID := string(pv.UID)
+	used := 0.0
+	var podNames []string
+	for _, podDTO := range podDTOs {
+		podNames = append(podNames, podDTO.GetDisplayName())
+		for _, bought := range podDTO.GetCommoditiesBought() {
+			if bought.GetProviderId() != storageID {
+				continue
+			}
+			for _, comm := range bought.GetBought() {
+				if comm.GetCommodityType() == proto.CommodityDTO_STORAGE_AMOUNT && comm.GetUsed() > used {
+					used = comm.GetUsed()
+				}
+			}
+		}
+	}
+
+	storageComm, err := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT).
+		Capacity(util.GetPersistentVolumeCapacity(pv)).
+		Used(used).
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build storage amount of persistent volume %s: %s", pv.Name, err)
+	}
+
+	entityDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_STORAGE, storageID).
+		DisplayName(fmt.Sprintf("%s-%s", persistentVolumePrefix, pv.Name)).
+		SellsCommodity(storageComm).
+		WithProperties(property.BuildPersistentVolumeProperties(pv, provisioner, podNames)).
+		Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build entityDTO for persistent volume %s: %s", pv.Name, err)
+	}
+	return entityDTO, nil
+}
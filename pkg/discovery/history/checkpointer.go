@@ -0,0 +1,61 @@
+package history
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+
+	"github.com/golang/glog"
+)
+
+// Checkpointer periodically saves the metric history into a snapshot store, and restores it on startup.
+type Checkpointer struct {
+	history  *metrics.EntityMetricHistory
+	store    SnapshotStore
+	interval time.Duration
+}
+
+func NewCheckpointer(history *metrics.EntityMetricHistory, store SnapshotStore, interval time.Duration) *Checkpointer {
+	return &Checkpointer{
+		history:  history,
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Load the latest snapshot into the history. A missing or unreadable snapshot is not fatal: the history simply
+// starts empty, so a snapshot written by another version never prevents kubeturbo from starting.
+func (c *Checkpointer) Restore() {
+	snapshot, err := c.store.Load()
+	if err != nil {
+		glog.Warningf("Failed to load metric history from %s, starting with empty history: %v", c.store, err)
+		return
+	}
+	if snapshot == nil {
+		glog.V(2).Infof("No metric history is found in %s.", c.store)
+		return
+	}
+	if err := c.history.Restore(snapshot); err != nil {
+		glog.Warningf("Failed to restore metric history from %s, starting with empty history: %v", c.store, err)
+		return
+	}
+	glog.V(2).Infof("Restored metric history of %d metrics from %s, taken at %v.", len(snapshot.Series), c.store,
+		snapshot.Timestamp)
+}
+
+// Save a snapshot of the history.
+func (c *Checkpointer) Checkpoint() {
+	snapshot := c.history.Snapshot()
+	if err := c.store.Save(snapshot); err != nil {
+		glog.Errorf("Failed to save metric history to %s: %v", c.store, err)
+		return
+	}
+	glog.V(3).Infof("Saved metric history of %d metrics to %s.", len(snapshot.Series), c.store)
+}
+
+// Save the snapshots periodically until the stop channel is closed.
+func (c *Checkpointer) Run(stopCh <-chan struct{}) {
+	wait.Until(c.Checkpoint, c.interval, stopCh)
+}
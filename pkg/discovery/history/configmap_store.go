@@ -0,0 +1,94 @@
+package history
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+
+	"github.com/golang/glog"
+)
+
+const (
+	configMapDataKey string = "history.json"
+
+	// A ConfigMap cannot be larger than 1MB; warn before the limit is reached.
+	configMapSizeWarning = 900 * 1024
+)
+
+// ConfigMapSnapshotStore keeps the snapshot in a ConfigMap, which is enough for small clusters.
+type ConfigMapSnapshotStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+}
+
+func NewConfigMapSnapshotStore(kubeClient kubernetes.Interface, namespace, name string) *ConfigMapSnapshotStore {
+	return &ConfigMapSnapshotStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+	}
+}
+
+// Implement SnapshotStore interface.
+func (s *ConfigMapSnapshotStore) Save(snapshot *metrics.MetricHistorySnapshot) error {
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	if len(data) > configMapSizeWarning {
+		glog.Warningf("Metric history snapshot is %d bytes, which is close to the size limit of %s. "+
+			"Consider using a file store instead.", len(data), s)
+	}
+
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	configMap, err := configMaps.Get(s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		configMap = &api.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.name,
+			},
+			Data: map[string]string{configMapDataKey: string(data)},
+		}
+		if _, err := configMaps.Create(configMap); err != nil {
+			return fmt.Errorf("failed to create %s: %v", s, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s: %v", s, err)
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[configMapDataKey] = string(data)
+	if _, err := configMaps.Update(configMap); err != nil {
+		return fmt.Errorf("failed to update %s: %v", s, err)
+	}
+	return nil
+}
+
+// Implement SnapshotStore interface.
+func (s *ConfigMapSnapshotStore) Load() (*metrics.MetricHistorySnapshot, error) {
+	configMap, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", s, err)
+	}
+	data, exist := configMap.Data[configMapDataKey]
+	if !exist {
+		return nil, nil
+	}
+	return decodeSnapshot([]byte(data))
+}
+
+func (s *ConfigMapSnapshotStore) String() string {
+	return fmt.Sprintf("configmap %s/%s", s.namespace, s.name)
+}
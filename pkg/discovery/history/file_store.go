@@ -0,0 +1,63 @@
+package history
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+// FileSnapshotStore keeps the snapshot in a local file, e.g. on a mounted PersistentVolume.
+type FileSnapshotStore struct {
+	path string
+}
+
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{
+		path: path,
+	}
+}
+
+// Implement SnapshotStore interface.
+// The snapshot is written to a temporary file first and then renamed, so a crash never leaves a partial snapshot.
+func (s *FileSnapshotStore) Save(snapshot *metrics.MetricHistorySnapshot) error {
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %s: %v", s.path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("failed to write %s: %v", tmpFile.Name(), err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmpFile.Name(), err)
+	}
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", s.path, err)
+	}
+	return nil
+}
+
+// Implement SnapshotStore interface.
+func (s *FileSnapshotStore) Load() (*metrics.MetricHistorySnapshot, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", s.path, err)
+	}
+	return decodeSnapshot(data)
+}
+
+func (s *FileSnapshotStore) String() string {
+	return "file " + s.path
+}
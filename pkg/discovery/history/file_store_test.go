@@ -0,0 +1,58 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+func TestFileSnapshotStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	store := NewFileSnapshotStore(filepath.Join(dir, "history.json"))
+
+	// no snapshot yet
+	if snapshot, err := store.Load(); err != nil || snapshot != nil {
+		t.Errorf("Expected no snapshot, got %v, %v", snapshot, err)
+	}
+
+	uid := "Node-node-1-CPU-Used"
+	h := metrics.NewEntityMetricHistory(3)
+	t0 := time.Now()
+	for i, v := range []float64{1, 4, 2} {
+		h.Record(uid, v, t0.Add(time.Duration(i)*time.Minute))
+	}
+	checkpointer := NewCheckpointer(h, store, time.Minute)
+	checkpointer.Checkpoint()
+
+	restored := metrics.NewEntityMetricHistory(2)
+	NewCheckpointer(restored, store, time.Minute).Restore()
+	// only the latest 2 samples fit.
+	if peak, exist := restored.GetPeak(uid); !exist || peak != 4 {
+		t.Errorf("Expected peak 4 after restoring, got %f, %v", peak, exist)
+	}
+	restored.Record(uid, 3, t0.Add(10*time.Minute))
+	if p, _ := restored.GetPercentile(uid, 50); p != 2 {
+		t.Errorf("Expected 50th percentile 2 after restoring, got %f", p)
+	}
+}
+
+func TestDecodeSnapshotVersion(t *testing.T) {
+	if _, err := decodeSnapshot([]byte(`{"version":99,"series":{"a":{"samples":"unknown format"}}}`)); err == nil {
+		t.Errorf("Expected error for unsupported version")
+	}
+	if _, err := decodeSnapshot([]byte(`not json`)); err == nil {
+		t.Errorf("Expected error for malformed snapshot")
+	}
+	snapshot, err := decodeSnapshot([]byte(`{"version":1,"series":{"a":{"samples":[1,2]}}}`))
+	if err != nil || len(snapshot.Series["a"].Samples) != 2 {
+		t.Errorf("Failed to decode snapshot: %v, %v", snapshot, err)
+	}
+}
@@ -0,0 +1,45 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+)
+
+// SnapshotStore saves and loads the snapshots of the metric history.
+type SnapshotStore interface {
+	// Save the given snapshot, replacing the previous one.
+	Save(snapshot *metrics.MetricHistorySnapshot) error
+
+	// Load the latest snapshot; nil is returned if there is no snapshot yet.
+	Load() (*metrics.MetricHistorySnapshot, error)
+
+	// A description of the store, for logging.
+	String() string
+}
+
+func encodeSnapshot(snapshot *metrics.MetricHistorySnapshot) ([]byte, error) {
+	return json.Marshal(snapshot)
+}
+
+// Decode the snapshot. The version is checked first, so that a snapshot written in another format is rejected
+// with a clear error instead of being partially parsed.
+func decodeSnapshot(data []byte) (*metrics.MetricHistorySnapshot, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse metric history snapshot: %v", err)
+	}
+	if header.Version != metrics.MetricHistorySnapshotVersion {
+		return nil, fmt.Errorf("unsupported metric history snapshot version %d, expected %d", header.Version,
+			metrics.MetricHistorySnapshotVersion)
+	}
+
+	snapshot := &metrics.MetricHistorySnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse metric history snapshot: %v", err)
+	}
+	return snapshot, nil
+}
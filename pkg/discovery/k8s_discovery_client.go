@@ -9,6 +9,9 @@ import (
 
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/worker/compliance"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
@@ -20,8 +23,9 @@ import (
 )
 
 const (
-	// TODO make this number programmatically.
-	workerCount int = 4
+	// The notification sent when part of the topology is not discovered.
+	discoveryNotificationCategory = "Discovery"
+	partialDiscoveryEvent         = "Partial Discovery"
 )
 
 type DiscoveryClientConfig struct {
@@ -30,16 +34,24 @@ type DiscoveryClientConfig struct {
 	probeConfig *configs.ProbeConfig
 
 	targetConfig *configs.K8sTargetConfig
+
+	// Used to check the connectivity to the kubelets during validation. The check is skipped if it is nil.
+	kubeletClient *kubelet.KubeletClient
 }
 
-func NewDiscoveryConfig(kubeClient *kubeClient.Clientset, probeConfig *configs.ProbeConfig, targetConfig *configs.K8sTargetConfig) *DiscoveryClientConfig {
+func NewDiscoveryConfig(kubeClient *kubeClient.Clientset, clusterCache *cluster.ClusterCache, probeConfig *configs.ProbeConfig, targetConfig *configs.K8sTargetConfig) *DiscoveryClientConfig {
 	return &DiscoveryClientConfig{
-		k8sClusterScraper: &cluster.ClusterScraper{kubeClient},
+		k8sClusterScraper: (&cluster.ClusterScraper{Clientset: kubeClient}).WithClusterCache(clusterCache).WithScope(targetConfig.Scope()),
 		probeConfig:       probeConfig,
 		targetConfig:      targetConfig,
 	}
 }
 
+func (config *DiscoveryClientConfig) WithKubeletClient(kubeletClient *kubelet.KubeletClient) *DiscoveryClientConfig {
+	config.kubeletClient = kubeletClient
+	return config
+}
+
 type K8sDiscoveryClient struct {
 	config *DiscoveryClientConfig
 
@@ -50,10 +62,12 @@ type K8sDiscoveryClient struct {
 }
 
 func NewK8sDiscoveryClient(config *DiscoveryClientConfig) *K8sDiscoveryClient {
+	dispatcherConfig := worker.NewDispatcherConfig(config.k8sClusterScraper, config.probeConfig).
+		WithWorkerCount(config.probeConfig.MinDiscoveryWorkers, config.probeConfig.MaxDiscoveryWorkers).
+		WithTargetDiscoveryTime(config.probeConfig.TargetDiscoveryTime)
 	// make maxWorkerCount of result collector twice the worker count.
-	resultCollector := worker.NewResultCollector(workerCount * 2)
+	resultCollector := worker.NewResultCollector(dispatcherConfig.MaxWorkerCount() * 2)
 
-	dispatcherConfig := worker.NewDispatcherConfig(config.k8sClusterScraper, config.probeConfig, workerCount)
 	dispatcher := worker.NewDispatcher(dispatcherConfig)
 	dispatcher.Init(resultCollector)
 
@@ -94,26 +108,47 @@ func (dc *K8sDiscoveryClient) GetAccountValues() *sdkprobe.TurboTargetInfo {
 	return targetInfo
 }
 
-// Validate the Target
+// Validate the Target: check the connectivity to the cluster and the permissions of kubeturbo.
+// Each problem found is reported as an ErrorDTO in the response.
 func (dc *K8sDiscoveryClient) Validate(accountValues []*proto.AccountValue) (*proto.ValidationResponse, error) {
 	glog.V(2).Infof("Validating Kubernetes target...")
 
-	// TODO: connect to the client and get validation response
-	validationResponse := &proto.ValidationResponse{}
+	var kubeletClient *kubelet.KubeletClient
+	if monitorsKubelet(dc.config.probeConfig.MonitoringConfigs) {
+		kubeletClient = dc.config.kubeletClient
+	}
+	errorDTOs := validateK8sTarget(dc.config.k8sClusterScraper, kubeletClient)
+	if len(errorDTOs) == 0 {
+		glog.V(2).Infof("Kubernetes target is valid.")
+	}
+	validationResponse := &proto.ValidationResponse{
+		ErrorDTO: errorDTOs,
+	}
 
 	return validationResponse, nil
 }
 
 // DiscoverTopology receives a discovery request from server and start probing the k8s.
+// The errors which leave part of the topology out are reported in the response along with the entities discovered.
 func (dc *K8sDiscoveryClient) Discover(accountValues []*proto.AccountValue) (*proto.DiscoveryResponse, error) {
 	currentTime := time.Now()
-	newDiscoveryResultDTOs, err := dc.discoverWithNewFramework()
+	newDiscoveryResultDTOs, errorDTOs, err := dc.discoverWithNewFramework()
 	if err != nil {
 		glog.Errorf("Failed to use the new framework to discover current Kubernetes cluster: %s", err)
+		errorDTOs = append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_CRITICAL, err.Error()))
+	}
+
+	groupDTOs, err := dc.buildGroupDTOs(newDiscoveryResultDTOs)
+	if err != nil {
+		glog.Errorf("Failed to discover groups: %s", err)
+		errorDTOs = append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_WARNING, err.Error()))
 	}
 
 	discoveryResponse := &proto.DiscoveryResponse{
-		EntityDTO: newDiscoveryResultDTOs,
+		EntityDTO:       newDiscoveryResultDTOs,
+		DiscoveredGroup: groupDTOs,
+		ErrorDTO:        errorDTOs,
+		Notification:    buildDiscoveryNotifications(errorDTOs),
 	}
 
 	newFrameworkDiscTime := time.Now().Sub(currentTime).Seconds()
@@ -122,37 +157,143 @@ func (dc *K8sDiscoveryClient) Discover(accountValues []*proto.AccountValue) (*pr
 	return discoveryResponse, nil
 }
 
-func (dc *K8sDiscoveryClient) discoverWithNewFramework() ([]*proto.EntityDTO, error) {
+// Build the groups of pods and containers, by controller, namespace and service, from the discovered entityDTOs.
+func (dc *K8sDiscoveryClient) buildGroupDTOs(entityDTOs []*proto.EntityDTO) ([]*proto.GroupDTO, error) {
+	clusterID, err := dc.config.k8sClusterScraper.GetKubernetesServiceID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster ID, groups are not discovered: %s", err)
+	}
+	return dtofactory.NewGroupDTOBuilder(clusterID).BuildGroupDTOs(entityDTOs), nil
+}
+
+// Summarize the errors of the discovery in a notification, so that the operators know the topology is partial.
+func buildDiscoveryNotifications(errorDTOs []*proto.ErrorDTO) []*proto.NotificationDTO {
+	if len(errorDTOs) == 0 {
+		return nil
+	}
+	criticalCount := 0
+	for _, errorDTO := range errorDTOs {
+		if errorDTO.GetSeverity() == proto.ErrorDTO_CRITICAL {
+			criticalCount++
+		}
+	}
+	severity := proto.NotificationDTO_MINOR
+	if criticalCount > 0 {
+		severity = proto.NotificationDTO_MAJOR
+	}
+	event := partialDiscoveryEvent
+	category := discoveryNotificationCategory
+	description := fmt.Sprintf("Kubernetes discovery is partial, with %d errors and %d warnings: %s",
+		criticalCount, len(errorDTOs)-criticalCount, errorDTOs[0].GetDescription())
+	return []*proto.NotificationDTO{{
+		Event:       &event,
+		Category:    &category,
+		Description: &description,
+		Severity:    &severity,
+	}}
+}
+
+// Discover the entities of the cluster, and the errors which leave some of them out.
+func (dc *K8sDiscoveryClient) discoverWithNewFramework() ([]*proto.EntityDTO, []*proto.ErrorDTO, error) {
 	nodes, err := dc.config.k8sClusterScraper.GetAllNodes()
 	if err != nil {
-		return nil, fmt.Errorf("Failed to get all nodes in the cluster: %s", err)
+		return nil, nil, fmt.Errorf("Failed to get all nodes in the cluster: %s", err)
 	}
 
 	workerCount := dc.dispatcher.Dispatch(nodes)
-	entityDTOs := dc.resultCollector.Collect(workerCount)
+	entityDTOs, errorDTOs, nodeCPUFrequencies := dc.resultCollector.Collect(workerCount)
+	// The failures of the cluster level processing are reported as warnings.
+	warn := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		glog.Error(msg)
+		errorDTOs = append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_WARNING, msg))
+	}
 	glog.V(2).Infof("Discovery workers have finished discovery work with %d entityDTOs built. Now performing service discovery...", len(entityDTOs))
 
+	// The pending pods are discovered before the compliance processors run, so that they are also processed.
+	glog.V(2).Infof("begin to generate pending pod EntityDTOs.")
+	pendingPodWorkerConfig := worker.NewK8sPendingPodDiscoveryWorkerConfig(dc.config.k8sClusterScraper).
+		WithNodeCPUFrequencies(nodeCPUFrequencies)
+	pendingPodDiscWorker := worker.NewK8sPendingPodDiscoveryWorker(pendingPodWorkerConfig)
+	pendingPodDiscResult := pendingPodDiscWorker.Do(entityDTOs)
+	if pendingPodDiscResult.Err() != nil {
+		warn("Failed to discover pending pods from current Kubernetes cluster: %s", pendingPodDiscResult.Err())
+	} else {
+		entityDTOs = append(entityDTOs, pendingPodDiscResult.Content()...)
+	}
+
 	// affinity process
 	glog.V(2).Infof("begin to process affinity.")
 	affinityProcessorConfig := compliance.NewAffinityProcessorConfig(dc.config.k8sClusterScraper)
 	affinityProcessor, err := compliance.NewAffinityProcessor(affinityProcessorConfig)
 	if err != nil {
-		glog.Errorf("Failed during process affinity rules: %s", err)
+		warn("Failed during process affinity rules: %s", err)
 	} else {
 		entityDTOs = affinityProcessor.ProcessAffinityRules(entityDTOs)
 	}
 
+	// taint toleration process
+	glog.V(2).Infof("begin to process taints and tolerations.")
+	taintTolerationProcessorConfig := compliance.NewTaintTolerationProcessorConfig(dc.config.k8sClusterScraper)
+	taintTolerationProcessor, err := compliance.NewTaintTolerationProcessor(taintTolerationProcessorConfig)
+	if err != nil {
+		warn("Failed during process taints and tolerations: %s", err)
+	} else {
+		entityDTOs = taintTolerationProcessor.ProcessTaints(entityDTOs)
+	}
+
+	// zone process
+	glog.V(2).Infof("begin to process zones.")
+	zoneProcessorConfig := compliance.NewZoneProcessorConfig(dc.config.k8sClusterScraper)
+	zoneProcessor, err := compliance.NewZoneProcessor(zoneProcessorConfig)
+	if err != nil {
+		warn("Failed during process zones: %s", err)
+	} else {
+		entityDTOs = zoneProcessor.ProcessZones(entityDTOs)
+	}
+
+	// quota process
+	glog.V(2).Infof("begin to process resource quotas.")
+	quotaProcessorConfig := compliance.NewQuotaProcessorConfig(dc.config.k8sClusterScraper)
+	quotaProcessor, err := compliance.NewQuotaProcessor(quotaProcessorConfig)
+	if err != nil {
+		warn("Failed during process resource quotas: %s", err)
+	} else {
+		entityDTOs = quotaProcessor.ProcessQuotas(entityDTOs)
+	}
+
+	glog.V(2).Infof("begin to generate workload controller EntityDTOs.")
+	controllerWorkerConfig := worker.NewK8sControllerDiscoveryWorkerConfig(dc.config.k8sClusterScraper)
+	controllerDiscWorker := worker.NewK8sControllerDiscoveryWorker(controllerWorkerConfig)
+	controllerDiscResult := controllerDiscWorker.Do(entityDTOs)
+	if controllerDiscResult.Err() != nil {
+		warn("Failed to discover workload controllers from current Kubernetes cluster: %s", controllerDiscResult.Err())
+	} else {
+		entityDTOs = append(entityDTOs, controllerDiscResult.Content()...)
+	}
+
+	glog.V(2).Infof("begin to generate persistent volume EntityDTOs.")
+	storageWorkerConfig := worker.NewK8sStorageDiscoveryWorkerConfig(dc.config.k8sClusterScraper)
+	storageDiscWorker := worker.NewK8sStorageDiscoveryWorker(storageWorkerConfig)
+	storageDiscResult := storageDiscWorker.Do(entityDTOs)
+	if storageDiscResult.Err() != nil {
+		warn("Failed to discover persistent volumes from current Kubernetes cluster: %s", storageDiscResult.Err())
+	} else {
+		entityDTOs = append(entityDTOs, storageDiscResult.Content()...)
+	}
+
 	glog.V(2).Infof("begin to generate service EntityDTOs.")
 	svcWorkerConfig := worker.NewK8sServiceDiscoveryWorkerConfig(dc.config.k8sClusterScraper)
 	svcDiscWorker, err := worker.NewK8sServiceDiscoveryWorker(svcWorkerConfig)
-	svcDiscResult := svcDiscWorker.Do(entityDTOs)
-	if svcDiscResult.Err() != nil {
-		glog.Errorf("Failed to discover services from current Kubernetes cluster with the new discovery framework: %s", svcDiscResult.Err())
+	if err != nil {
+		warn("Failed to create the service discovery worker: %s", err)
+	} else if svcDiscResult := svcDiscWorker.Do(entityDTOs); svcDiscResult.Err() != nil {
+		warn("Failed to discover services from current Kubernetes cluster with the new discovery framework: %s", svcDiscResult.Err())
 	} else {
 		entityDTOs = append(entityDTOs, svcDiscResult.Content()...)
 	}
 
-	glog.V(2).Infof("There are %d entityDTOs.", len(entityDTOs))
+	glog.V(2).Infof("There are %d entityDTOs and %d discovery errors.", len(entityDTOs), len(errorDTOs))
 
-	return entityDTOs, nil
+	return entityDTOs, errorDTOs, nil
 }
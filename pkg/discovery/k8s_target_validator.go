@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	api "k8s.io/client-go/pkg/api/v1"
+	authorization "k8s.io/client-go/pkg/apis/authorization/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// The number of ready nodes whose kubelet is probed during validation.
+	kubeletSampleSize = 3
+	// The time given to the kubelet of a node to respond during validation.
+	kubeletProbeTimeout = 20 * time.Second
+)
+
+// A permission kubeturbo needs in the cluster, checked during the validation of the target.
+type k8sPermission struct {
+	verb        string
+	group       string
+	resource    string
+	subresource string
+
+	// Whether the permission is only needed to execute actions. Discovery still works without it.
+	actionOnly bool
+}
+
+func (p k8sPermission) String() string {
+	resource := p.resource
+	if p.group != "" {
+		resource = resource + "." + p.group
+	}
+	if p.subresource != "" {
+		resource = resource + "/" + p.subresource
+	}
+	return p.verb + " " + resource
+}
+
+// Get the permissions to use the given verbs on a resource.
+func newPermissions(group, resource string, actionOnly bool, verbs ...string) []k8sPermission {
+	var permissions []k8sPermission
+	for _, verb := range verbs {
+		permissions = append(permissions,
+			k8sPermission{verb: verb, group: group, resource: resource, actionOnly: actionOnly})
+	}
+	return permissions
+}
+
+// Concatenate the lists of permissions into one.
+func concatPermissions(permissionLists ...[]k8sPermission) []k8sPermission {
+	var permissions []k8sPermission
+	for _, list := range permissionLists {
+		permissions = append(permissions, list...)
+	}
+	return permissions
+}
+
+// The permissions on the resources kubeturbo reads and changes, with the verbs it calls on them.
+var requiredPermissions = concatPermissions(
+	// Discovery: the resources are cached by informers, which list and watch them.
+	newPermissions("", "nodes", false, "list", "watch"),
+	newPermissions("", "pods", false, "list", "watch"),
+	newPermissions("", "services", false, "list", "watch"),
+	newPermissions("", "endpoints", false, "list", "watch"),
+	newPermissions("", "replicationcontrollers", false, "list", "watch"),
+	newPermissions("", "resourcequotas", false, "list", "watch"),
+	newPermissions("", "persistentvolumes", false, "list", "watch"),
+	newPermissions("", "persistentvolumeclaims", false, "list", "watch"),
+	newPermissions("extensions", "replicasets", false, "list", "watch"),
+	newPermissions("extensions", "deployments", false, "list", "watch"),
+	newPermissions("extensions", "daemonsets", false, "list", "watch"),
+	newPermissions("apps", "statefulsets", false, "list", "watch"),
+	newPermissions("storage.k8s.io", "storageclasses", false, "list", "watch"),
+	// The kubernetes service identifies the cluster; the events tell why the pending pods are not scheduled.
+	newPermissions("", "services", false, "get"),
+	newPermissions("", "events", false, "list"),
+	// Actions: pods are moved and resized by recreating them, with their controller paused in the meantime,
+	// and controllers are scaled by updating their replicas.
+	newPermissions("", "pods", true, "get", "create", "delete"),
+	newPermissions("", "persistentvolumeclaims", true, "get"),
+	newPermissions("", "persistentvolumes", true, "get"),
+	newPermissions("", "replicationcontrollers", true, "get", "update"),
+	newPermissions("extensions", "replicasets", true, "get", "update"),
+	newPermissions("apps", "deployments", true, "get", "update"),
+	newPermissions("apps", "statefulsets", true, "get", "update"),
+)
+
+// The permission to reach the kubelets through the API server, needed in kubelet proxy mode.
+var kubeletProxyPermission = k8sPermission{verb: "get", resource: "nodes", subresource: "proxy"}
+
+// Get the permissions to check, including the access to the kubelets through the API server if it is used.
+func getRequiredPermissions(useKubeletProxy bool) []k8sPermission {
+	permissions := append([]k8sPermission{}, requiredPermissions...)
+	if useKubeletProxy {
+		permissions = append(permissions, kubeletProxyPermission)
+	}
+	return permissions
+}
+
+// Whether the kubelet is one of the configured monitoring sources, i.e. whether discovery needs to reach the kubelets.
+func monitorsKubelet(monitoringConfigs []monitoring.MonitorWorkerConfig) bool {
+	for _, mc := range monitoringConfigs {
+		if mc.GetMonitoringSource() == types.KubeletSource {
+			return true
+		}
+	}
+	return false
+}
+
+// Check that the target can be discovered: the API server is reachable, kubeturbo has the permissions it needs,
+// and the kubelets can be reached. The kubelets are only probed if the kubelet client is given, i.e. if the kubelet
+// is a monitoring source. Each problem found is reported as an ErrorDTO; those which prevent discovery are critical.
+func validateK8sTarget(scraper *cluster.ClusterScraper, kubeletClient *kubelet.KubeletClient) []*proto.ErrorDTO {
+	if _, err := scraper.Discovery().ServerVersion(); err != nil {
+		// Nothing else can be checked without the API server.
+		return []*proto.ErrorDTO{task.NewErrorDTO(proto.ErrorDTO_CRITICAL,
+			fmt.Sprintf("Failed to connect to the Kubernetes API server: %s", err))}
+	}
+
+	reviewAccess := func(p k8sPermission) (bool, string, error) {
+		review, err := scraper.AuthorizationV1().SelfSubjectAccessReviews().Create(&authorization.SelfSubjectAccessReview{
+			Spec: authorization.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorization.ResourceAttributes{
+					Verb:        p.verb,
+					Group:       p.group,
+					Resource:    p.resource,
+					Subresource: p.subresource,
+				},
+			},
+		})
+		if err != nil {
+			return false, "", err
+		}
+		return review.Status.Allowed, review.Status.Reason, nil
+	}
+	useKubeletProxy := kubeletClient != nil && kubeletClient.UsesProxy()
+	errorDTOs := validatePermissions(getRequiredPermissions(useKubeletProxy), reviewAccess)
+
+	if kubeletClient == nil {
+		return errorDTOs
+	}
+	nodes, err := scraper.GetAllNodes()
+	if err != nil {
+		// Already reported by the permission check if it is not allowed.
+		glog.Errorf("Failed to get nodes to validate the kubelet connectivity: %s", err)
+		return errorDTOs
+	}
+	probeKubelet := func(node *api.Node) error {
+		host, err := kubeletClient.GetNodeHost(node)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), kubeletProbeTimeout)
+		defer cancel()
+		_, err = kubeletClient.GetMachineInfo(ctx, host)
+		return err
+	}
+	return append(errorDTOs, validateKubeletConnectivity(nodes, probeKubelet)...)
+}
+
+// Report each permission which is not granted, as a critical error if discovery needs it, otherwise as a warning.
+func validatePermissions(permissions []k8sPermission,
+	reviewAccess func(k8sPermission) (bool, string, error)) []*proto.ErrorDTO {
+	var errorDTOs []*proto.ErrorDTO
+	for _, p := range permissions {
+		allowed, reason, err := reviewAccess(p)
+		if err != nil {
+			// Every review would fail the same way, e.g. when the authorization API is not available.
+			glog.Errorf("Failed to review the permission to %s: %s", p, err)
+			return append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_WARNING,
+				fmt.Sprintf("Failed to verify the permissions of kubeturbo: %s", err)))
+		}
+		if allowed {
+			continue
+		}
+		severity := proto.ErrorDTO_CRITICAL
+		description := fmt.Sprintf("kubeturbo is not allowed to %s in the cluster", p)
+		if p.actionOnly {
+			severity = proto.ErrorDTO_WARNING
+			description = description + "; the actions which need it will fail"
+		}
+		if reason != "" {
+			description = fmt.Sprintf("%s: %s", description, reason)
+		}
+		glog.Errorf("Validation failed: %s", description)
+		errorDTOs = append(errorDTOs, task.NewErrorDTO(severity, description))
+	}
+	return errorDTOs
+}
+
+// Probe the kubelet of a few ready nodes, and report each node which cannot be reached.
+// The failures are critical if no kubelet can be reached at all, as no resource usage could be discovered.
+func validateKubeletConnectivity(nodes []*api.Node, probeKubelet func(*api.Node) error) []*proto.ErrorDTO {
+	var sampled int
+	var failures []*api.Node
+	var errs []error
+	for _, node := range nodes {
+		if sampled >= kubeletSampleSize {
+			break
+		}
+		if !util.NodeIsReady(node) {
+			continue
+		}
+		sampled++
+		if err := probeKubelet(node); err != nil {
+			failures = append(failures, node)
+			errs = append(errs, err)
+		}
+	}
+
+	severity := proto.ErrorDTO_WARNING
+	if len(failures) == sampled {
+		severity = proto.ErrorDTO_CRITICAL
+	}
+	var errorDTOs []*proto.ErrorDTO
+	for i, node := range failures {
+		description := fmt.Sprintf("Failed to connect to the kubelet of node %s: %s", node.Name, errs[i])
+		glog.Errorf("Validation failed: %s", description)
+		errorDTOs = append(errorDTOs, task.NewEntityErrorDTO(severity, description, proto.EntityDTO_VIRTUAL_MACHINE,
+			string(node.UID)))
+	}
+	return errorDTOs
+}
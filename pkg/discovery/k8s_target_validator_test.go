@@ -0,0 +1,143 @@
+package discovery
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestValidatePermissions(t *testing.T) {
+	permissions := []k8sPermission{
+		{verb: "list", resource: "nodes"},
+		{verb: "list", resource: "pods"},
+		{verb: "create", resource: "pods", subresource: "binding", actionOnly: true},
+	}
+	denied := map[string]bool{"list pods": true, "create pods/binding": true}
+	reviewAccess := func(p k8sPermission) (bool, string, error) {
+		return !denied[p.String()], "", nil
+	}
+
+	errorDTOs := validatePermissions(permissions, reviewAccess)
+	if len(errorDTOs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errorDTOs), errorDTOs)
+	}
+	if errorDTOs[0].GetSeverity() != proto.ErrorDTO_CRITICAL {
+		t.Errorf("Expected the missing discovery permission to be critical, got %s", errorDTOs[0].GetSeverity())
+	}
+	if errorDTOs[1].GetSeverity() != proto.ErrorDTO_WARNING {
+		t.Errorf("Expected the missing action permission to be a warning, got %s", errorDTOs[1].GetSeverity())
+	}
+
+	// A failure of the review itself is reported once.
+	errorDTOs = validatePermissions(permissions, func(p k8sPermission) (bool, string, error) {
+		return false, "", errors.New("the server could not find the requested resource")
+	})
+	if len(errorDTOs) != 1 || errorDTOs[0].GetSeverity() != proto.ErrorDTO_WARNING {
+		t.Errorf("Expected 1 warning, got %v", errorDTOs)
+	}
+}
+
+func TestGetRequiredPermissions(t *testing.T) {
+	hasProxyPermission := func(permissions []k8sPermission) bool {
+		for _, p := range permissions {
+			if p == kubeletProxyPermission {
+				return true
+			}
+		}
+		return false
+	}
+	if hasProxyPermission(getRequiredPermissions(false)) {
+		t.Errorf("Expected no %s permission without the kubelet proxy", kubeletProxyPermission)
+	}
+	if !hasProxyPermission(getRequiredPermissions(true)) {
+		t.Errorf("Expected the %s permission with the kubelet proxy", kubeletProxyPermission)
+	}
+	if len(requiredPermissions) != len(getRequiredPermissions(false)) || hasProxyPermission(requiredPermissions) {
+		t.Errorf("Expected the required permissions to be left unchanged")
+	}
+
+	permissions := make(map[string]bool)
+	for _, p := range requiredPermissions {
+		permissions[p.String()] = p.actionOnly
+	}
+	for expected, actionOnly := range map[string]bool{
+		"watch pods":                          false,
+		"list deployments.extensions":         false,
+		"watch storageclasses.storage.k8s.io": false,
+		"update deployments.apps":             true,
+		"update statefulsets.apps":            true,
+	} {
+		if got, exists := permissions[expected]; !exists || got != actionOnly {
+			t.Errorf("Expected the %s permission with actionOnly %t", expected, actionOnly)
+		}
+	}
+}
+
+func TestMonitorsKubelet(t *testing.T) {
+	clusterConfig := &master.ClusterMonitorConfig{}
+	if monitorsKubelet([]monitoring.MonitorWorkerConfig{clusterConfig}) {
+		t.Errorf("Expected the kubelet not to be monitored")
+	}
+	if !monitorsKubelet([]monitoring.MonitorWorkerConfig{clusterConfig, kubelet.NewKubeletMonitorConfig(nil)}) {
+		t.Errorf("Expected the kubelet to be monitored")
+	}
+}
+
+func TestValidateKubeletConnectivity(t *testing.T) {
+	newNode := func(name string, ready api.ConditionStatus) *api.Node {
+		return &api.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name + "-uid")},
+			Status: api.NodeStatus{
+				Conditions: []api.NodeCondition{{Type: api.NodeReady, Status: ready}},
+			},
+		}
+	}
+	nodes := []*api.Node{
+		newNode("node-1", api.ConditionFalse),
+		newNode("node-2", api.ConditionTrue),
+		newNode("node-3", api.ConditionTrue),
+		newNode("node-4", api.ConditionTrue),
+		newNode("node-5", api.ConditionTrue),
+	}
+
+	var probed []string
+	errorDTOs := validateKubeletConnectivity(nodes, func(node *api.Node) error {
+		probed = append(probed, node.Name)
+		if node.Name == "node-3" {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	// Only the first ready nodes are probed.
+	if len(probed) != kubeletSampleSize || probed[0] != "node-2" {
+		t.Errorf("Expected %d ready nodes probed from node-2, got %v", kubeletSampleSize, probed)
+	}
+	if len(errorDTOs) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(errorDTOs))
+	}
+	if errorDTOs[0].GetSeverity() != proto.ErrorDTO_WARNING || errorDTOs[0].GetEntityUuid() != "node-3-uid" {
+		t.Errorf("Expected a warning about node-3, got %v", errorDTOs[0])
+	}
+
+	// No kubelet can be reached.
+	errorDTOs = validateKubeletConnectivity(nodes, func(node *api.Node) error {
+		return errors.New("connection refused")
+	})
+	if len(errorDTOs) != kubeletSampleSize {
+		t.Fatalf("Expected %d errors, got %d", kubeletSampleSize, len(errorDTOs))
+	}
+	for _, errorDTO := range errorDTOs {
+		if errorDTO.GetSeverity() != proto.ErrorDTO_CRITICAL {
+			t.Errorf("Expected critical errors, got %s", errorDTO.GetSeverity())
+		}
+	}
+}
@@ -10,6 +10,10 @@ const (
 	CPUProvisioned    ResourceType = "CPUProvisioned"
 	MemoryProvisioned ResourceType = "MemoryProvisioned"
 	Transaction       ResourceType = "Transaction"
+	ResponseTime      ResourceType = "ResponseTime"
+	NetworkThroughput ResourceType = "NetworkThroughput"
+	EphemeralStorage  ResourceType = "EphemeralStorage"
+	VolumeStorage     ResourceType = "VolumeStorage"
 
 	Access       ResourceType = "Access"
 	Cluster      ResourceType = "Cluster"
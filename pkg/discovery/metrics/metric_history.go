@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// The version of the snapshot format; it must be increased whenever the format changes.
+	MetricHistorySnapshotVersion = 1
+)
+
+// MetricHistorySnapshot is the serializable form of EntityMetricHistory.
+type MetricHistorySnapshot struct {
+	Version   int       `json:"version"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// key: metric UID; value: samples of the metric.
+	Series map[string]MetricSeriesSnapshot `json:"series"`
+}
+
+type MetricSeriesSnapshot struct {
+	// Samples from the oldest to the latest.
+	Samples    []float64 `json:"samples"`
+	LastUpdate time.Time `json:"lastUpdate"`
+}
+
+// A bounded ring buffer of the samples of one metric.
+type metricRing struct {
+	samples []float64
+	// index of the slot for the next sample.
+	next int
+	// number of valid samples.
+	count int
+
+	lastUpdate time.Time
+}
+
+func newMetricRing(size int) *metricRing {
+	return &metricRing{
+		samples: make([]float64, size),
+	}
+}
+
+func (r *metricRing) add(value float64, timestamp time.Time) {
+	if r.count > 0 && timestamp.Equal(r.lastUpdate) {
+		// The metric is read again in the same discovery, replace the latest sample.
+		r.samples[(r.next+len(r.samples)-1)%len(r.samples)] = value
+		return
+	}
+	r.samples[r.next] = value
+	r.next = (r.next + 1) % len(r.samples)
+	if r.count < len(r.samples) {
+		r.count++
+	}
+	r.lastUpdate = timestamp
+}
+
+// Get the samples from the oldest to the latest.
+func (r *metricRing) values() []float64 {
+	result := make([]float64, 0, r.count)
+	start := (r.next - r.count + len(r.samples)) % len(r.samples)
+	for i := 0; i < r.count; i++ {
+		result = append(result, r.samples[(start+i)%len(r.samples)])
+	}
+	return result
+}
+
+// EntityMetricHistory keeps the recent samples of the entity metrics across discoveries.
+// It is thread-safe, as it is shared by all the discovery workers.
+type EntityMetricHistory struct {
+	// max number of samples kept for each metric.
+	size int
+
+	// key: metric UID; value: samples of the metric.
+	series map[string]*metricRing
+
+	lock sync.RWMutex
+}
+
+func NewEntityMetricHistory(size int) *EntityMetricHistory {
+	if size < 1 {
+		size = 1
+	}
+	return &EntityMetricHistory{
+		size:   size,
+		series: make(map[string]*metricRing),
+	}
+}
+
+// Add a sample of the metric with the given UID. The samples recorded with the same timestamp are regarded as
+// the same sample, so that a metric read more than once in a discovery is only counted once.
+func (h *EntityMetricHistory) Record(metricUID string, value float64, timestamp time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	ring, exist := h.series[metricUID]
+	if !exist {
+		ring = newMetricRing(h.size)
+		h.series[metricUID] = ring
+	}
+	ring.add(value, timestamp)
+}
+
+// Get the max value in the history of the metric.
+func (h *EntityMetricHistory) GetPeak(metricUID string) (float64, bool) {
+	values := h.getValues(metricUID)
+	if len(values) == 0 {
+		return 0, false
+	}
+	peak := values[0]
+	for _, v := range values[1:] {
+		peak = math.Max(peak, v)
+	}
+	return peak, true
+}
+
+// Get the given percentile (0-100) of the history of the metric, using the nearest-rank method.
+func (h *EntityMetricHistory) GetPercentile(metricUID string, percentile float64) (float64, bool) {
+	values := h.getValues(metricUID)
+	if len(values) == 0 {
+		return 0, false
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(percentile / 100 * float64(len(values))))
+	if rank < 1 {
+		rank = 1
+	} else if rank > len(values) {
+		rank = len(values)
+	}
+	return values[rank-1], true
+}
+
+// Drop the history of the metrics which are not updated since the given time, e.g. the metrics of deleted pods.
+func (h *EntityMetricHistory) Prune(before time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for uid, ring := range h.series {
+		if ring.lastUpdate.Before(before) {
+			delete(h.series, uid)
+		}
+	}
+}
+
+func (h *EntityMetricHistory) getValues(metricUID string) []float64 {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	ring, exist := h.series[metricUID]
+	if !exist {
+		return nil
+	}
+	return ring.values()
+}
+
+// Take a snapshot of the whole history.
+func (h *EntityMetricHistory) Snapshot() *MetricHistorySnapshot {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	snapshot := &MetricHistorySnapshot{
+		Version:   MetricHistorySnapshotVersion,
+		Timestamp: time.Now(),
+		Series:    make(map[string]MetricSeriesSnapshot, len(h.series)),
+	}
+	for uid, ring := range h.series {
+		snapshot.Series[uid] = MetricSeriesSnapshot{
+			Samples:    ring.values(),
+			LastUpdate: ring.lastUpdate,
+		}
+	}
+	return snapshot
+}
+
+// Load the samples in the snapshot into the history. If the snapshot has more samples than the size of the history,
+// only the latest ones are kept.
+func (h *EntityMetricHistory) Restore(snapshot *MetricHistorySnapshot) error {
+	if snapshot.Version != MetricHistorySnapshotVersion {
+		return fmt.Errorf("unsupported metric history snapshot version %d, expected %d", snapshot.Version,
+			MetricHistorySnapshotVersion)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for uid, series := range snapshot.Series {
+		ring := newMetricRing(h.size)
+		for _, v := range series.Samples {
+			ring.samples[ring.next] = v
+			ring.next = (ring.next + 1) % len(ring.samples)
+			if ring.count < len(ring.samples) {
+				ring.count++
+			}
+		}
+		ring.lastUpdate = series.LastUpdate
+		h.series[uid] = ring
+	}
+	return nil
+}
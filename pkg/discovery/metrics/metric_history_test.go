@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntityMetricHistory(t *testing.T) {
+	h := NewEntityMetricHistory(4)
+	t0 := time.Now()
+	uid := "Pod-default/pod-1-CPU-Used"
+
+	if _, exist := h.GetPeak(uid); exist {
+		t.Errorf("Expected no peak without history")
+	}
+
+	for i, v := range []float64{5, 1, 3, 2, 4} {
+		h.Record(uid, v, t0.Add(time.Duration(i)*time.Minute))
+	}
+	// the oldest sample 5 is evicted.
+	if peak, _ := h.GetPeak(uid); peak != 4 {
+		t.Errorf("Expected peak 4, got %f", peak)
+	}
+	if p, _ := h.GetPercentile(uid, 50); p != 2 {
+		t.Errorf("Expected 50th percentile 2, got %f", p)
+	}
+	if p, _ := h.GetPercentile(uid, 95); p != 4 {
+		t.Errorf("Expected 95th percentile 4, got %f", p)
+	}
+
+	// a sample with the same timestamp replaces the latest one.
+	h.Record(uid, 10, t0.Add(4*time.Minute))
+	if p, _ := h.GetPercentile(uid, 50); p != 2 {
+		t.Errorf("Expected 50th percentile 2 after replacing the latest sample, got %f", p)
+	}
+	if peak, _ := h.GetPeak(uid); peak != 10 {
+		t.Errorf("Expected peak 10 after replacing the latest sample, got %f", peak)
+	}
+
+	h.Prune(t0.Add(10 * time.Minute))
+	if _, exist := h.GetPeak(uid); exist {
+		t.Errorf("Expected the history is pruned")
+	}
+}
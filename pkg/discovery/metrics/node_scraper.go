@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// The number of nodes scraped at the same time by a monitoring worker.
+	DefaultNodeScrapeConcurrency = 10
+	// The time given to scrape the metrics of a node.
+	DefaultNodeScrapeTimeout = time.Minute
+)
+
+// Scrape the metrics of the nodes in parallel, with at most concurrency nodes at a time, each one into a sink of its own.
+// The scrape of a node is given its own deadline: a node which is not scraped in time is cancelled and its metrics are
+// dropped, so that one unresponsive node neither holds back nor blanks out the metrics of the other nodes. No more node
+// is scraped once the context is cancelled.
+// Return the metrics of the nodes scraped in time, and the nodes which timed out.
+func ScrapeNodes(ctx context.Context, nodes []*api.Node, concurrency int, timeout time.Duration,
+	scrape func(ctx context.Context, node *api.Node) *EntityMetricSink) (*EntityMetricSink, []*api.Node) {
+	if concurrency <= 0 {
+		concurrency = len(nodes)
+	}
+	sink := NewEntityMetricSink()
+	var lock sync.Mutex
+	var timedOutNodes []*api.Node
+
+	// Taken by the node being scraped, and released when it finishes or times out.
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		select {
+		case <-ctx.Done():
+			glog.V(2).Infof("Scraping is stopped, %d nodes are skipped.", len(nodes)-i)
+			wg.Wait()
+			return sink, timedOutNodes
+		case slots <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(n *api.Node) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			nodeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			nodeSink := scrape(nodeCtx, n)
+			if err := nodeCtx.Err(); err != nil {
+				if err == context.DeadlineExceeded && ctx.Err() == nil {
+					glog.Errorf("Scraping node %s exceeds the time limit of %v; its metrics are dropped.", n.Name,
+						timeout)
+					lock.Lock()
+					timedOutNodes = append(timedOutNodes, n)
+					lock.Unlock()
+				}
+				return
+			}
+			lock.Lock()
+			sink.MergeSink(nodeSink, nil)
+			lock.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	return sink, timedOutNodes
+}
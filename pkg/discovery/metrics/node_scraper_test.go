@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+)
+
+func TestScrapeNodes(t *testing.T) {
+	var nodes []*api.Node
+	for _, name := range []string{"node-1", "node-2", "node-3", "node-4", "node-5"} {
+		nodes = append(nodes, &api.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	scrape := func(ctx context.Context, node *api.Node) *EntityMetricSink {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			running--
+			lock.Unlock()
+		}()
+
+		if node.Name == "node-2" {
+			// An unresponsive node, until the scrape is cancelled.
+			<-ctx.Done()
+		}
+		sink := NewEntityMetricSink()
+		sink.AddNewMetricEntries(NewEntityResourceMetric(task.NodeType, node.Name, CPU, Used, 1))
+		return sink
+	}
+
+	sink, timedOutNodes := ScrapeNodes(context.Background(), nodes, 2, 100*time.Millisecond, scrape)
+
+	if len(timedOutNodes) != 1 || timedOutNodes[0].Name != "node-2" {
+		t.Errorf("Expected node-2 to time out, got %v", timedOutNodes)
+	}
+	for _, node := range nodes {
+		_, err := sink.GetMetric(GenerateEntityResourceMetricUID(task.NodeType, node.Name, CPU, Used))
+		if node.Name == "node-2" && err == nil {
+			t.Errorf("Expected the metrics of node-2 to be dropped")
+		}
+		if node.Name != "node-2" && err != nil {
+			t.Errorf("Expected the metrics of %s to be kept: %v", node.Name, err)
+		}
+	}
+	// The scrape of node-2 is cancelled, so no scrape is left running.
+	lock.Lock()
+	defer lock.Unlock()
+	if running != 0 {
+		t.Errorf("Expected all the scrapes to return, got %d running", running)
+	}
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 nodes scraped at the same time, got %d", maxRunning)
+	}
+}
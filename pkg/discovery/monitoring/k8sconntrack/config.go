@@ -1,6 +1,9 @@
 package k8sconntrack
 
 import (
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 )
 
@@ -15,12 +18,18 @@ type K8sConntrackMonitorConfig struct {
 
 	// http or https.
 	enableHttps bool
+
+	// The number of nodes scraped at the same time, and the time given to each of them.
+	nodeScrapeConcurrency int
+	nodeScrapeTimeout     time.Duration
 }
 
 func NewK8sConntrackMonitorConfig() *K8sConntrackMonitorConfig {
 	return &K8sConntrackMonitorConfig{
-		port:        defaultK8sConntrackPort,
-		enableHttps: false,
+		port:                  defaultK8sConntrackPort,
+		enableHttps:           false,
+		nodeScrapeConcurrency: metrics.DefaultNodeScrapeConcurrency,
+		nodeScrapeTimeout:     metrics.DefaultNodeScrapeTimeout,
 	}
 }
 
@@ -35,6 +44,12 @@ func (kcm *K8sConntrackMonitorConfig) EnableHttps() *K8sConntrackMonitorConfig {
 	return kcm
 }
 
+func (kcm *K8sConntrackMonitorConfig) WithNodeScrapeLimits(concurrency int, timeout time.Duration) *K8sConntrackMonitorConfig {
+	kcm.nodeScrapeConcurrency = concurrency
+	kcm.nodeScrapeTimeout = timeout
+	return kcm
+}
+
 // Implement MonitoringWorkerConfig interface.
 func (kcm *K8sConntrackMonitorConfig) GetMonitorType() types.MonitorType {
 	return types.ResourceMonitor
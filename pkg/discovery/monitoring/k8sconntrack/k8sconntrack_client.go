@@ -1,6 +1,7 @@
 package k8sconntrack
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -44,8 +45,8 @@ func (c *K8sConntrackClient) GetPort() int64 {
 	return c.config.port
 }
 
-// Get transaction data from provided host.
-func (c *K8sConntrackClient) GetTransactionData(host Host) (transactions []Transaction, err error) {
+// Get transaction data from provided host. The request is aborted when the context is cancelled.
+func (c *K8sConntrackClient) GetTransactionData(ctx context.Context, host Host) (transactions []Transaction, err error) {
 	requestURL := url.URL{
 		Scheme: c.config.schema,
 		Host:   fmt.Sprintf("%s:%d", host.IP, host.Port),
@@ -55,6 +56,7 @@ func (c *K8sConntrackClient) GetTransactionData(host Host) (transactions []Trans
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	var transactionsList []Transaction
 	if err = httputil.PostRequestAndGetValue(c.client, req, &transactionsList); err != nil {
 		glog.Errorf("Error getting Json Data for transactions: %s", err)
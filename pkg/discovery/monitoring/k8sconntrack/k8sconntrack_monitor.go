@@ -1,8 +1,8 @@
 package k8sconntrack
 
 import (
+	"context"
 	"errors"
-	"sync"
 
 	api "k8s.io/client-go/pkg/api/v1"
 
@@ -15,7 +15,8 @@ import (
 )
 
 const (
-	defaultTransactionCapacity float64 = 50
+	// The capacity of the transactions of an application, shared by the monitors which provide transactions.
+	DefaultTransactionCapacity float64 = 50
 
 	zeroTransactionUsed float64 = 0
 )
@@ -32,9 +33,8 @@ type K8sConntrackMonitor struct {
 
 	metricSink *metrics.EntityMetricSink
 
-	wg sync.WaitGroup
-
-	stopCh chan struct{}
+	// The nodes which didn't respond in time during the last task.
+	timedOutNodes []*api.Node
 }
 
 func NewK8sConntrackMonitor(config *K8sConntrackMonitorConfig) (*K8sConntrackMonitor, error) {
@@ -50,13 +50,12 @@ func NewK8sConntrackMonitor(config *K8sConntrackMonitorConfig) (*K8sConntrackMon
 		config:             config,
 		k8sConntrackClient: NewK8sConntrackClient(k8sConntrackClientConfig),
 		metricSink:         metrics.NewEntityMetricSink(),
-		stopCh:             make(chan struct{}, 1),
 	}, nil
 }
 
 func (m *K8sConntrackMonitor) reset() {
 	m.metricSink = metrics.NewEntityMetricSink()
-	m.stopCh = make(chan struct{}, 1)
+	m.timedOutNodes = nil
 }
 
 // Implement MonitoringWorker interface.
@@ -72,14 +71,10 @@ func (m *K8sConntrackMonitor) ReceiveTask(task *task.Task) {
 	m.nodePodMap = util.GroupPodsByNode(task.PodList())
 }
 
-func (m *K8sConntrackMonitor) Stop() {
-	m.stopCh <- struct{}{}
-}
-
 // Implement MonitoringWorker interface.
-func (m *K8sConntrackMonitor) Do() *metrics.EntityMetricSink {
+func (m *K8sConntrackMonitor) Do(ctx context.Context) *metrics.EntityMetricSink {
 	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
-	err := m.RetrieveResourceStat()
+	err := m.RetrieveResourceStat(ctx)
 	if err != nil {
 		glog.Errorf("Failed to execute task: %s", err)
 	}
@@ -87,55 +82,43 @@ func (m *K8sConntrackMonitor) Do() *metrics.EntityMetricSink {
 	return m.metricSink
 }
 
-// Start to retrieve resource stats for the received list of nodes.
-func (m *K8sConntrackMonitor) RetrieveResourceStat() error {
-	defer func() {
-		close(m.stopCh)
-	}()
-
+// Start to retrieve resource stats for the received list of nodes, until the context is cancelled.
+func (m *K8sConntrackMonitor) RetrieveResourceStat(ctx context.Context) error {
 	if m.nodeList == nil || len(m.nodeList) == 0 {
 		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
 	}
-	m.wg.Add(len(m.nodeList))
-
-	for _, node := range m.nodeList {
-		go func(n *api.Node) {
-			defer m.wg.Done()
-
-			select {
-			case <-m.stopCh:
-				return
-			default:
-				m.scrapeK8sConntrack(node)
-			}
-		}(node)
-	}
-
-	m.wg.Wait()
+	m.metricSink, m.timedOutNodes = metrics.ScrapeNodes(ctx, m.nodeList, m.config.nodeScrapeConcurrency,
+		m.config.nodeScrapeTimeout, m.scrapeK8sConntrack)
 
 	return nil
 }
 
+// The nodes which didn't respond in time; their transactions are missing.
+func (m *K8sConntrackMonitor) TimedOutNodes() []*api.Node {
+	return m.timedOutNodes
+}
+
 // Get transaction value from a single host.
-func (m *K8sConntrackMonitor) getTransactionFromNode(ip string) ([]Transaction, error) {
+func (m *K8sConntrackMonitor) getTransactionFromNode(ctx context.Context, ip string) ([]Transaction, error) {
 	host := Host{
 		IP:   ip,
 		Port: m.k8sConntrackClient.GetPort(),
 	}
-	transactions, err := m.k8sConntrackClient.GetTransactionData(host)
+	transactions, err := m.k8sConntrackClient.GetTransactionData(ctx, host)
 	if err != nil {
 		return transactions, err
 	}
 	return transactions, nil
 }
 
-// Retrieve resource metrics for the given node.
-func (m *K8sConntrackMonitor) scrapeK8sConntrack(node *api.Node) {
+// Retrieve resource metrics for the given node, into a sink of its own so that they are dropped if it times out.
+func (m *K8sConntrackMonitor) scrapeK8sConntrack(ctx context.Context, node *api.Node) *metrics.EntityMetricSink {
+	sink := metrics.NewEntityMetricSink()
 	// build pod IP map.
 	runningPods, exist := m.nodePodMap[node.Name]
 	if !exist {
 		glog.Errorf("Failed to get all running pods on node %s.", node.Name)
-		return
+		return sink
 	}
 	podIPMap := m.findPodsIPMapOnNode(runningPods)
 
@@ -143,22 +126,24 @@ func (m *K8sConntrackMonitor) scrapeK8sConntrack(node *api.Node) {
 	ip, err := util.GetNodeIPForMonitor(node, types.K8sConntrackSource)
 	if err != nil {
 		glog.Errorf("Failed to get IP address for getting information from K8sConntrack running in node %s", node.Name)
-		return
+		return sink
 	}
 
 	// get transaction data from node
-	transactionData, err := m.getTransactionFromNode(ip)
+	transactionData, err := m.getTransactionFromNode(ctx, ip)
 	if err != nil {
 		glog.Errorf("Failed to get transaction data from %s: %s", node.Name, err)
 	}
 
-	m.parseTransactionData(runningPods, podIPMap, transactionData)
+	m.parseTransactionData(sink, runningPods, podIPMap, transactionData)
 
 	glog.V(3).Infof("Finished scrape node %s.", node.Name)
+	return sink
 }
 
 // Parse transaction data and create metrics.
-func (m *K8sConntrackMonitor) parseTransactionData(pods []*api.Pod, podIPMap map[string]*api.Pod, transactionData []Transaction) {
+func (m *K8sConntrackMonitor) parseTransactionData(sink *metrics.EntityMetricSink, pods []*api.Pod,
+	podIPMap map[string]*api.Pod, transactionData []Transaction) {
 	runningPodSet := make(map[*api.Pod]struct{})
 	for _, p := range pods {
 		pod := p
@@ -178,13 +163,13 @@ func (m *K8sConntrackMonitor) parseTransactionData(pods []*api.Pod, podIPMap map
 				// application transaction capacity
 				appTransactionCapacityCountMetrics := metrics.NewEntityResourceMetric(task.ApplicationType,
 					util.PodKeyFunc(pod), metrics.Transaction, metrics.Capacity,
-					defaultTransactionCapacity)
+					DefaultTransactionCapacity)
 
 				serviceTransactionUsedCountMetrics := metrics.NewEntityResourceMetric(task.ServiceType,
 					util.PodKeyFunc(pod), metrics.Transaction, metrics.Used, transactionUsedCount)
 
 				// service transaction used
-				m.metricSink.AddNewMetricEntries(appTransactionUsedCountMetrics,
+				sink.AddNewMetricEntries(appTransactionUsedCountMetrics,
 					appTransactionCapacityCountMetrics,
 					serviceTransactionUsedCountMetrics)
 
@@ -203,12 +188,12 @@ func (m *K8sConntrackMonitor) parseTransactionData(pods []*api.Pod, podIPMap map
 
 		podTransactionCapacityCountMetrics := metrics.NewEntityResourceMetric(task.ApplicationType,
 			util.PodKeyFunc(pod), metrics.Transaction, metrics.Capacity,
-			defaultTransactionCapacity)
+			DefaultTransactionCapacity)
 
 		serviceTransactionUsedCountMetrics := metrics.NewEntityResourceMetric(task.ServiceType,
 			util.PodKeyFunc(pod), metrics.Transaction, metrics.Used, zeroTransactionUsed)
 
-		m.metricSink.AddNewMetricEntries(podTransactionUsedCountMetrics,
+		sink.AddNewMetricEntries(podTransactionUsedCountMetrics,
 			podTransactionCapacityCountMetrics,
 			serviceTransactionUsedCountMetrics)
 
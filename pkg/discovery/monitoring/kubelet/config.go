@@ -1,12 +1,25 @@
 package kubelet
 
 import (
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 )
 
 type KubeletMonitorConfig struct {
 	//a kubeletClient or a kubeletConfig?
 	kubeletClient *KubeletClient
+
+	// Shared by all the kubelet monitors built from this config, to compute the rates of cumulative stats.
+	rateCalculator *rateCalculator
+
+	// The number of kubelets scraped at the same time, and the time given to each of them.
+	nodeScrapeConcurrency int
+	nodeScrapeTimeout     time.Duration
+
+	// The network throughput capacity of the nodes, in Mbit/s.
+	networkThroughputCapacity float64
 }
 
 // Implement MonitoringWorkerConfig interface.
@@ -19,6 +32,23 @@ func (c KubeletMonitorConfig) GetMonitoringSource() types.MonitoringSource {
 
 func NewKubeletMonitorConfig(kclient *KubeletClient) *KubeletMonitorConfig {
 	return &KubeletMonitorConfig{
-		kubeletClient: kclient,
+		kubeletClient:         kclient,
+		rateCalculator:        newRateCalculator(),
+		nodeScrapeConcurrency: metrics.DefaultNodeScrapeConcurrency,
+		nodeScrapeTimeout:     metrics.DefaultNodeScrapeTimeout,
+
+		networkThroughputCapacity: DefaultNetworkThroughputCapacity,
 	}
 }
+
+func (c *KubeletMonitorConfig) WithNodeScrapeLimits(concurrency int, timeout time.Duration) *KubeletMonitorConfig {
+	c.nodeScrapeConcurrency = concurrency
+	c.nodeScrapeTimeout = timeout
+	return c
+}
+
+// Set the network throughput capacity (Mbit/s) of the nodes, unless it is set by the annotation of a node.
+func (c *KubeletMonitorConfig) WithNetworkThroughputCapacity(capacity float64) *KubeletMonitorConfig {
+	c.networkThroughputCapacity = capacity
+	return c
+}
@@ -1,6 +1,8 @@
 package kubelet
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/golang/glog"
 	"net/http"
@@ -8,8 +10,12 @@ import (
 	"time"
 
 	cadvisorapi "github.com/google/cadvisor/info/v1"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/util/httputil"
 	netutil "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/kubernetes"
+	api "k8s.io/client-go/pkg/api/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/transport"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
@@ -21,6 +27,7 @@ const (
 
 	DefaultKubeletPort  = 10255
 	DefaultKubeletHttps = false
+	DefaultKubeletProxy = false
 
 	defaultConnTimeOut         = 20 * time.Second
 	defaultTLSHandShakeTimeout = 10 * time.Second
@@ -28,13 +35,39 @@ const (
 
 // Since http.Client is thread safe (https://golang.org/src/net/http/client.go)
 // KubeletClient is also thread-safe if concurrent goroutines won't change the fields.
+// In proxy mode, the requests go through the nodes/proxy endpoint of the API server, and the host is the node name.
 type KubeletClient struct {
 	client *http.Client
 	scheme string
 	port   int
+
+	// Used in proxy mode only.
+	restClient rest.Interface
+	timeout    time.Duration
+	useProxy   bool
+}
+
+// Get the host to reach the kubelet of the given node: the node name in proxy mode, otherwise the node IP.
+func (kc *KubeletClient) GetNodeHost(node *api.Node) (string, error) {
+	if kc.useProxy {
+		return node.Name, nil
+	}
+	return util.GetNodeIPForMonitor(node, types.KubeletSource)
+}
+
+// Whether the kubelets are reached through the nodes/proxy endpoint of the API server.
+func (kc *KubeletClient) UsesProxy() bool {
+	return kc.useProxy
 }
 
-func (kc *KubeletClient) GetSummary(host string) (*stats.Summary, error) {
+// The request is aborted when the context is cancelled.
+func (kc *KubeletClient) GetSummary(ctx context.Context, host string) (*stats.Summary, error) {
+	summary := &stats.Summary{}
+	if kc.useProxy {
+		err := kc.getByProxy(ctx, host, summaryPath, summary)
+		return summary, err
+	}
+
 	requestURL := url.URL{
 		Scheme: kc.scheme,
 		Host:   fmt.Sprintf("%s:%d", host, kc.port),
@@ -45,13 +78,20 @@ func (kc *KubeletClient) GetSummary(host string) (*stats.Summary, error) {
 	if err != nil {
 		return nil, err
 	}
-	summary := &stats.Summary{}
+	req = req.WithContext(ctx)
 	client := kc.client
 	err = httputil.PostRequestAndGetValue(client, req, summary)
 	return summary, err
 }
 
-func (kc *KubeletClient) GetMachineInfo(host string) (*cadvisorapi.MachineInfo, error) {
+// The request is aborted when the context is cancelled.
+func (kc *KubeletClient) GetMachineInfo(ctx context.Context, host string) (*cadvisorapi.MachineInfo, error) {
+	if kc.useProxy {
+		var minfo cadvisorapi.MachineInfo
+		err := kc.getByProxy(ctx, host, specPath, &minfo)
+		return &minfo, err
+	}
+
 	requestURL := url.URL{
 		Scheme: kc.scheme,
 		Host:   fmt.Sprintf("%s:%d", host, kc.port),
@@ -61,6 +101,7 @@ func (kc *KubeletClient) GetMachineInfo(host string) (*cadvisorapi.MachineInfo,
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	var minfo cadvisorapi.MachineInfo
 	err = httputil.PostRequestAndGetValue(kc.client, req, &minfo)
 	return &minfo, err
@@ -68,7 +109,13 @@ func (kc *KubeletClient) GetMachineInfo(host string) (*cadvisorapi.MachineInfo,
 
 // get machine single-core Frequency, in Khz
 func (kc *KubeletClient) GetMachineCpuFrequency(host string) (uint64, error) {
-	minfo, err := kc.GetMachineInfo(host)
+	timeout := kc.timeout
+	if timeout <= 0 {
+		timeout = defaultConnTimeOut
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	minfo, err := kc.GetMachineInfo(ctx, host)
 	if err != nil {
 		glog.Errorf("failed to get machine[%s] cpu.frequency: %v", host, err)
 		return 0, err
@@ -77,10 +124,30 @@ func (kc *KubeletClient) GetMachineCpuFrequency(host string) (uint64, error) {
 	return minfo.CpuFrequency, nil
 }
 
+// Get the given kubelet path of the node through the API server, and parse the response into value.
+func (kc *KubeletClient) getByProxy(ctx context.Context, nodeName, path string, value interface{}) error {
+	body, err := kc.restClient.Get().
+		Context(ctx).
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix(path).
+		Timeout(kc.timeout).
+		DoRaw()
+	if err != nil {
+		return fmt.Errorf("failed to get %s of node %s through proxy: %v", path, nodeName, err)
+	}
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("failed to parse output. Response: %q. Error: %v", string(body), err)
+	}
+	return nil
+}
+
 //----------------- kubeletConfig -----------------------------------
 type KubeletConfig struct {
 	kubeConfig  *rest.Config
 	enableHttps bool
+	useProxy    bool
 	port        int
 	timeout     time.Duration // timeout when fetching information from kubelet;
 	tlsTimeOut  time.Duration
@@ -92,6 +159,7 @@ func NewKubeletConfig(kubeConfig *rest.Config) *KubeletConfig {
 		kubeConfig:  kubeConfig,
 		port:        DefaultKubeletPort,
 		enableHttps: DefaultKubeletHttps,
+		useProxy:    DefaultKubeletProxy,
 		timeout:     defaultConnTimeOut,
 		tlsTimeOut:  defaultTLSHandShakeTimeout,
 	}
@@ -107,12 +175,31 @@ func (kc *KubeletConfig) EnableHttps(enable bool) *KubeletConfig {
 	return kc
 }
 
+// Access kubelet through the nodes/proxy endpoint of the API server, instead of connecting to the nodes directly.
+// The port and https settings are ignored in this mode, as the API server decides how to reach kubelet.
+func (kc *KubeletConfig) EnableProxy(enable bool) *KubeletConfig {
+	kc.useProxy = enable
+	return kc
+}
+
 func (kc *KubeletConfig) Timeout(timeout int) *KubeletConfig {
 	kc.timeout = time.Duration(timeout) * time.Second
 	return kc
 }
 
 func (kc *KubeletConfig) Create() (*KubeletClient, error) {
+	if kc.useProxy {
+		kubeClient, err := kubernetes.NewForConfig(kc.kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &KubeletClient{
+			restClient: kubeClient.CoreV1().RESTClient(),
+			timeout:    kc.timeout,
+			useProxy:   true,
+		}, nil
+	}
+
 	//1. http transport
 	transport, err := makeTransport(kc.kubeConfig, kc.enableHttps, kc.tlsTimeOut)
 	if err != nil {
@@ -131,9 +218,10 @@ func (kc *KubeletConfig) Create() (*KubeletClient, error) {
 
 	//3. create a KubeletClient
 	return &KubeletClient{
-		client: c,
-		scheme: scheme,
-		port:   kc.port,
+		client:  c,
+		scheme:  scheme,
+		port:    kc.port,
+		timeout: kc.timeout,
 	}, nil
 }
 
@@ -0,0 +1,58 @@
+package kubelet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestKubeletClientProxy(t *testing.T) {
+	responses := map[string]string{
+		"/api/v1/nodes/node-1/proxy/spec":          `{"num_cores":2,"cpu_frequency_khz":2400000}`,
+		"/api/v1/nodes/node-1/proxy/stats/summary": `{"node":{"nodeName":"node-1"},"pods":[]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, exist := responses[r.URL.Path]
+		if !exist {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	kc, err := NewKubeletConfig(&rest.Config{Host: server.URL}).EnableProxy(true).Create()
+	if err != nil {
+		t.Fatalf("Failed to create kubelet client: %v", err)
+	}
+
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	host, err := kc.GetNodeHost(node)
+	if err != nil || host != node.Name {
+		t.Fatalf("Expected host %s in proxy mode, got %s: %v", node.Name, host, err)
+	}
+
+	frequency, err := kc.GetMachineCpuFrequency(host)
+	if err != nil {
+		t.Errorf("Failed to get cpu frequency: %v", err)
+	} else if frequency != 2400000 {
+		t.Errorf("Expected cpu frequency 2400000, got %d", frequency)
+	}
+
+	summary, err := kc.GetSummary(context.Background(), host)
+	if err != nil {
+		t.Errorf("Failed to get summary: %v", err)
+	} else if summary.Node.NodeName != node.Name {
+		t.Errorf("Expected summary of node %s, got %s", node.Name, summary.Node.NodeName)
+	}
+
+	if _, err := kc.GetSummary(context.Background(), "node-2"); err == nil {
+		t.Errorf("Expected error for unknown node")
+	}
+}
@@ -1,8 +1,10 @@
 package kubelet
 
 import (
+	"context"
 	"errors"
-	"sync"
+	"strconv"
+	"time"
 
 	api "k8s.io/client-go/pkg/api/v1"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
@@ -17,30 +19,52 @@ import (
 	"github.com/golang/glog"
 )
 
+const (
+	// Kubelet does not report the bandwidth of the nodes. Assume 1 Gbit/s unless it is set by flag or by the
+	// annotation of the node.
+	DefaultNetworkThroughputCapacity float64 = 1000
+
+	// The annotation of a node overriding its network throughput capacity, in Mbit/s.
+	NetworkThroughputCapacityAnnotation = "kubeturbo.io/network-throughput-capacity-mbps"
+
+	// The network throughput is in KB/s.
+	megabitsToKilobytes float64 = 1000 * 1000 / 8 / util.KilobytesToBytes
+)
+
 // KubeletMonitor is a resource monitoring worker.
 type KubeletMonitor struct {
 	nodeList []*api.Node
 
 	kubeletClient *KubeletClient
 
-	metricSink *metrics.EntityMetricSink
+	rateCalculator *rateCalculator
 
-	stopCh chan struct{}
+	nodeScrapeConcurrency int
+	nodeScrapeTimeout     time.Duration
 
-	wg sync.WaitGroup
+	// The network throughput capacity of the nodes without the capacity annotation, in Mbit/s.
+	networkThroughputCapacity float64
+
+	metricSink *metrics.EntityMetricSink
+
+	// The nodes whose kubelet didn't respond in time during the last task.
+	timedOutNodes []*api.Node
 }
 
 func NewKubeletMonitor(config *KubeletMonitorConfig) (*KubeletMonitor, error) {
 	return &KubeletMonitor{
-		kubeletClient: config.kubeletClient,
-		metricSink:    metrics.NewEntityMetricSink(),
-		stopCh:        make(chan struct{}, 1),
+		kubeletClient:             config.kubeletClient,
+		rateCalculator:            config.rateCalculator,
+		nodeScrapeConcurrency:     config.nodeScrapeConcurrency,
+		nodeScrapeTimeout:         config.nodeScrapeTimeout,
+		networkThroughputCapacity: config.networkThroughputCapacity,
+		metricSink:                metrics.NewEntityMetricSink(),
 	}, nil
 }
 
 func (m *KubeletMonitor) reset() {
 	m.metricSink = metrics.NewEntityMetricSink()
-	m.stopCh = make(chan struct{}, 1)
+	m.timedOutNodes = nil
 }
 
 func (m *KubeletMonitor) GetMonitoringSource() types.MonitoringSource {
@@ -49,17 +73,14 @@ func (m *KubeletMonitor) GetMonitoringSource() types.MonitoringSource {
 
 func (m *KubeletMonitor) ReceiveTask(task *task.Task) {
 	m.reset()
+	m.rateCalculator.prune(time.Now().Add(-staleCounterAge))
 
 	m.nodeList = task.NodeList()
 }
 
-func (m *KubeletMonitor) Stop() {
-	m.stopCh <- struct{}{}
-}
-
-func (m *KubeletMonitor) Do() *metrics.EntityMetricSink {
+func (m *KubeletMonitor) Do(ctx context.Context) *metrics.EntityMetricSink {
 	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
-	err := m.RetrieveResourceStat()
+	err := m.RetrieveResourceStat(ctx)
 	if err != nil {
 		glog.Errorf("Failed to execute task: %s", err)
 	}
@@ -67,43 +88,43 @@ func (m *KubeletMonitor) Do() *metrics.EntityMetricSink {
 	return m.metricSink
 }
 
-// Start to retrieve resource stats for the received list of nodes.
-func (m *KubeletMonitor) RetrieveResourceStat() error {
-	defer func() {
-		close(m.stopCh)
-	}()
+// Start to retrieve resource stats for the received list of nodes, until the context is cancelled.
+func (m *KubeletMonitor) RetrieveResourceStat(ctx context.Context) error {
 	if m.nodeList == nil || len(m.nodeList) == 0 {
 		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
 	}
-	m.wg.Add(len(m.nodeList))
+	m.metricSink, m.timedOutNodes = metrics.ScrapeNodes(ctx, m.nodeList, m.nodeScrapeConcurrency,
+		m.nodeScrapeTimeout, m.scrapeKubelet)
 
-	for _, node := range m.nodeList {
-		go func(n *api.Node) {
-			defer m.wg.Done()
-			select {
-			case <-m.stopCh:
-				return
-			default:
-				m.scrapeKubelet(n)
-			}
-		}(node)
-	}
+	return nil
+}
 
-	m.wg.Wait()
+// The nodes whose kubelet didn't respond in time; their metrics are missing.
+func (m *KubeletMonitor) TimedOutNodes() []*api.Node {
+	return m.timedOutNodes
+}
 
-	return nil
+// Retrieve resource metrics for the given node, into a sink of its own so that they are dropped if it times out.
+func (m *KubeletMonitor) scrapeKubelet(ctx context.Context, node *api.Node) *metrics.EntityMetricSink {
+	nodeMonitor := &KubeletMonitor{
+		kubeletClient:             m.kubeletClient,
+		rateCalculator:            m.rateCalculator,
+		networkThroughputCapacity: m.networkThroughputCapacity,
+		metricSink:                metrics.NewEntityMetricSink(),
+	}
+	nodeMonitor.scrapeNode(ctx, node)
+	return nodeMonitor.metricSink
 }
 
-// Retrieve resource metrics for the given node.
-func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
-	ip, err := util.GetNodeIPForMonitor(node, types.KubeletSource)
+func (m *KubeletMonitor) scrapeNode(ctx context.Context, node *api.Node) {
+	ip, err := m.kubeletClient.GetNodeHost(node)
 	if err != nil {
 		glog.Errorf("Failed to get resource metrics from %s: %s", node.Name, err)
 		return
 	}
 
 	// get machine information
-	machineInfo, err := m.kubeletClient.GetMachineInfo(ip)
+	machineInfo, err := m.kubeletClient.GetMachineInfo(ctx, ip)
 	if err != nil {
 		glog.Errorf("Failed to get machine information from %s: %s", node.Name, err)
 		return
@@ -112,13 +133,14 @@ func (m *KubeletMonitor) scrapeKubelet(node *api.Node) {
 	m.parseNodeInfo(node, machineInfo)
 
 	// get summary information about the given node and the pods running on it.
-	summary, err := m.kubeletClient.GetSummary(ip)
+	summary, err := m.kubeletClient.GetSummary(ctx, ip)
 	if err != nil {
 		glog.Errorf("Failed to get resource metrics summary from %s: %s", node.Name, err)
 		return
 	}
-	m.parseNodeStats(summary.Node)
+	m.parseNodeStats(summary.Node, m.getNetworkThroughputCapacity(node))
 	m.parsePodStats(summary.Pods)
+	m.parseStorageStats(summary)
 
 	glog.V(4).Infof("Finished scrape node %s.", node.Name)
 
@@ -131,35 +153,72 @@ func (m *KubeletMonitor) parseNodeInfo(node *api.Node, machineInfo *cadvisorapi.
 	m.metricSink.AddNewMetricEntries(cpuFrequencyMetric)
 }
 
-// Parse node stats and put it into sink.
-func (m *KubeletMonitor) parseNodeStats(nodeStats stats.NodeStats) {
-	// cpu
-	cpuUsageCore := float64(*nodeStats.CPU.UsageNanoCores) / util.NanoToUnit
-	memoryUsageKiloBytes := float64(*nodeStats.Memory.UsageBytes) / util.KilobytesToBytes
+// Get the network throughput capacity of the node in KB/s: the one set by the annotation of the node if it is valid,
+// otherwise the default one.
+func (m *KubeletMonitor) getNetworkThroughputCapacity(node *api.Node) float64 {
+	capacity := m.networkThroughputCapacity
+	if value, exist := node.Annotations[NetworkThroughputCapacityAnnotation]; exist {
+		if c, err := strconv.ParseFloat(value, 64); err != nil || c <= 0 {
+			glog.Warningf("Invalid annotation %s=%s of node %s: the network throughput capacity must be a positive "+
+				"number of Mbit/s", NetworkThroughputCapacityAnnotation, value, node.Name)
+		} else {
+			capacity = c
+		}
+	}
+	return capacity * megabitsToKilobytes
+}
 
+// Parse node stats and put it into sink. The network throughput capacity is in KB/s.
+func (m *KubeletMonitor) parseNodeStats(nodeStats stats.NodeStats, networkCapacity float64) {
 	key := util.NodeStatsKeyFunc(nodeStats)
-	glog.V(3).Infof("CPU usage of node %s is %.3f core", nodeStats.NodeName, cpuUsageCore)
+
+	// cpu, which is skipped rather than reported as 0 if it is not available
+	if cpuUsageCore, ok := m.getCPUUsageCore(task.NodeType, key, nodeStats.CPU); ok {
+		glog.V(3).Infof("CPU usage of node %s is %.3f core", nodeStats.NodeName, cpuUsageCore)
+		m.genCPUUsedMetric(task.NodeType, key, cpuUsageCore)
+	} else {
+		glog.Warningf("CPU usage of node %s is not available", nodeStats.NodeName)
+	}
+
+	// memory
+	memoryUsageKiloBytes := float64(*nodeStats.Memory.UsageBytes) / util.KilobytesToBytes
 	glog.V(3).Infof("Memory usage of node %s is %.3f KB", nodeStats.NodeName, memoryUsageKiloBytes)
-	m.genUsedMetrics(task.NodeType, key, cpuUsageCore, memoryUsageKiloBytes)
+	m.genMemoryUsedMetric(task.NodeType, key, memoryUsageKiloBytes)
+
+	// network
+	if nodeStats.Network != nil {
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.NodeType, key,
+			metrics.NetworkThroughput, metrics.Capacity, networkCapacity))
+	}
+	m.genNetworkMetrics(task.NodeType, key, nodeStats.Network)
 }
 
 // Parse pod stats for every pod and put them into sink.
 func (m *KubeletMonitor) parsePodStats(podStats []stats.PodStats) {
 	for i := range podStats {
 		pod := &(podStats[i])
-		cpuUsed, memUsed := m.parseContainerStats(pod)
+		cpuUsed, cpuOk, memUsed := m.parseContainerStats(pod)
 
 		key := util.PodStatsKeyFunc(pod)
-		glog.V(4).Infof("Cpu usage of pod %s is %.3f core", key, cpuUsed)
+		// The CPU usage of the pod is only known if it is known for all of its containers.
+		if cpuOk {
+			glog.V(4).Infof("Cpu usage of pod %s is %.3f core", key, cpuUsed)
+			m.genCPUUsedMetric(task.PodType, key, cpuUsed)
+		} else {
+			glog.V(3).Infof("CPU usage of pod %s is not available", key)
+		}
 		glog.V(4).Infof("Memory usage of pod %s is %.3f Kb", key, memUsed)
-
-		m.genUsedMetrics(task.PodType, key, cpuUsed, memUsed)
+		m.genMemoryUsedMetric(task.PodType, key, memUsed)
+		m.genNetworkMetrics(task.PodType, key, pod.Network)
 	}
 }
 
-func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats) (float64, float64) {
+// Parse the stats of the containers of the pod, and return the total CPU used, whether it is known for all the
+// containers, and the total memory used.
+func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats) (float64, bool, float64) {
 
 	totalUsedCPU := float64(0.0)
+	totalCPUOk := true
 	totalUsedMem := float64(0.0)
 
 	podId := pod.PodRef.UID
@@ -167,35 +226,146 @@ func (m *KubeletMonitor) parseContainerStats(pod *stats.PodStats) (float64, floa
 
 	for i := range containers {
 		container := &containers[i]
-		if container.CPU == nil || container.CPU.UsageNanoCores == nil {
-			continue
-		}
 		if container.Memory == nil || container.Memory.UsageBytes == nil {
 			continue
 		}
-
-		cpuUsed := float64(*(container.CPU.UsageNanoCores)) / util.NanoToUnit
+		containerId := util.ContainerIdFunc(podId, i)
+		appId := util.ApplicationIdFunc(containerId)
 		memUsed := float64(*(container.Memory.UsageBytes)) / util.KilobytesToBytes
-
-		totalUsedCPU += cpuUsed
 		totalUsedMem += memUsed
 
-		//1. container Used
-		containerId := util.ContainerIdFunc(podId, i)
-		m.genUsedMetrics(task.ContainerType, containerId, cpuUsed, memUsed)
+		//1. container and app CPU Used, skipped if not available
+		cpuUsed, ok := m.getCPUUsageCore(task.ContainerType, containerId, container.CPU)
+		if ok {
+			totalUsedCPU += cpuUsed
+			m.genCPUUsedMetric(task.ContainerType, containerId, cpuUsed)
+			m.genCPUUsedMetric(task.ApplicationType, appId, cpuUsed)
+			glog.V(4).Infof("container[%s-%s] cpu usage:%.3f", pod.PodRef.Name, container.Name, cpuUsed)
+		} else {
+			totalCPUOk = false
+			glog.V(3).Infof("CPU usage of container[%s-%s] is not available", pod.PodRef.Name, container.Name)
+		}
 
-		glog.V(4).Infof("container[%s-%s] cpu/memory usage:%.3f, %.3f", pod.PodRef.Name, container.Name, cpuUsed, memUsed)
+		//2. container and app Memory Used
+		m.genMemoryUsedMetric(task.ContainerType, containerId, memUsed)
+		m.genMemoryUsedMetric(task.ApplicationType, appId, memUsed)
 
-		//2. app Used
-		appId := util.ApplicationIdFunc(containerId)
-		m.genUsedMetrics(task.ApplicationType, appId, cpuUsed, memUsed)
+		glog.V(4).Infof("container[%s-%s] memory usage:%.3f", pod.PodRef.Name, container.Name, memUsed)
+	}
+
+	return totalUsedCPU, totalCPUOk, totalUsedMem
+}
+
+// Get the CPU usage in cores. It is averaged over the time since last discovery, based on the cumulative
+// UsageCoreNanoSeconds; the instantaneous UsageNanoCores is used for the first discovery or after the counter is reset.
+func (m *KubeletMonitor) getCPUUsageCore(etype task.DiscoveredEntityType, key string, cpuStats *stats.CPUStats) (float64, bool) {
+	if cpuStats == nil {
+		return 0, false
 	}
+	if cpuStats.UsageCoreNanoSeconds != nil {
+		rate, ok := m.rateCalculator.rate(string(etype)+"-"+key+"-cpu", float64(*cpuStats.UsageCoreNanoSeconds),
+			cpuStats.Time.Time)
+		if ok {
+			return rate / util.NanoToUnit, true
+		}
+	}
+	if cpuStats.UsageNanoCores != nil {
+		return float64(*cpuStats.UsageNanoCores) / util.NanoToUnit, true
+	}
+	return 0, false
+}
 
-	return totalUsedCPU, totalUsedMem
+func (m *KubeletMonitor) genCPUUsedMetric(etype task.DiscoveredEntityType, key string, cpu float64) {
+	m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu))
 }
 
-func (m *KubeletMonitor) genUsedMetrics(etype task.DiscoveredEntityType, key string, cpu, memory float64) {
-	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu)
-	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory)
-	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+func (m *KubeletMonitor) genMemoryUsedMetric(etype task.DiscoveredEntityType, key string, memory float64) {
+	m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory))
+}
+
+// Generate the network throughput used, in KB/s, from the received and transmitted bytes since last discovery.
+func (m *KubeletMonitor) genNetworkMetrics(etype task.DiscoveredEntityType, key string, networkStats *stats.NetworkStats) {
+	if networkStats == nil || networkStats.RxBytes == nil || networkStats.TxBytes == nil {
+		return
+	}
+	timestamp := networkStats.Time.Time
+	rxRate, rxOk := m.rateCalculator.rate(string(etype)+"-"+key+"-rx", float64(*networkStats.RxBytes), timestamp)
+	txRate, txOk := m.rateCalculator.rate(string(etype)+"-"+key+"-tx", float64(*networkStats.TxBytes), timestamp)
+	if !rxOk || !txOk {
+		glog.V(4).Infof("Network throughput of %s %s is not available yet", etype, key)
+		return
+	}
+	throughput := (rxRate + txRate) / util.KilobytesToBytes
+	glog.V(4).Infof("Network throughput of %s %s is %.3f KB/s", etype, key, throughput)
+	m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(etype, key, metrics.NetworkThroughput,
+		metrics.Used, throughput))
+}
+
+// Parse the ephemeral storage used by the node, pods and containers, in MB.
+// The capacity is not reported here: it comes from the node allocatable ephemeral storage, discovered by the
+// cluster monitor.
+func (m *KubeletMonitor) parseStorageStats(summary *stats.Summary) {
+	nodeKey := util.NodeStatsKeyFunc(summary.Node)
+	nodeUsed, _, exist := fsStatsValues(summary.Node.Fs)
+	if !exist {
+		glog.V(3).Infof("Filesystem stats of node %s are not available", summary.Node.NodeName)
+		return
+	}
+	glog.V(4).Infof("Ephemeral storage used of node %s is %.3f MB", summary.Node.NodeName, nodeUsed)
+	m.genStorageUsedMetrics(task.NodeType, nodeKey, nodeUsed)
+
+	for i := range summary.Pods {
+		pod := &summary.Pods[i]
+		podUsed := 0.0
+		for j := range pod.Containers {
+			container := &pod.Containers[j]
+			rootfsUsed, _, _ := fsStatsValues(container.Rootfs)
+			logsUsed, _, _ := fsStatsValues(container.Logs)
+			containerUsed := rootfsUsed + logsUsed
+			podUsed += containerUsed
+
+			containerId := util.ContainerIdFunc(pod.PodRef.UID, j)
+			m.genStorageUsedMetrics(task.ContainerType, containerId, containerUsed)
+		}
+
+		key := util.PodStatsKeyFunc(pod)
+		glog.V(4).Infof("Ephemeral storage used of pod %s is %.3f MB", key, podUsed)
+		m.genStorageUsedMetrics(task.PodType, key, podUsed)
+
+		m.parseVolumeStats(pod)
+	}
+}
+
+// Parse the storage used by the volumes of the pod, in MB. The volumes not backed by persistent volumes
+// are also reported by kubelet; their metrics are just not used.
+func (m *KubeletMonitor) parseVolumeStats(pod *stats.PodStats) {
+	for i := range pod.VolumeStats {
+		volume := &pod.VolumeStats[i]
+		used, capacity, exist := fsStatsValues(&volume.FsStats)
+		if !exist {
+			continue
+		}
+		key := util.PodVolumeStatsKeyFunc(pod, volume)
+		glog.V(4).Infof("Storage used of volume %s is %.3f MB", key, used)
+		usedMetric := metrics.NewEntityResourceMetric(task.VolumeType, key, metrics.VolumeStorage, metrics.Used, used)
+		capacityMetric := metrics.NewEntityResourceMetric(task.VolumeType, key, metrics.VolumeStorage, metrics.Capacity, capacity)
+		m.metricSink.AddNewMetricEntries(usedMetric, capacityMetric)
+	}
+}
+
+func (m *KubeletMonitor) genStorageUsedMetrics(etype task.DiscoveredEntityType, key string, used float64) {
+	usedMetric := metrics.NewEntityResourceMetric(etype, key, metrics.EphemeralStorage, metrics.Used, used)
+	m.metricSink.AddNewMetricEntries(usedMetric)
+}
+
+// Get the used and capacity of the filesystem in MB.
+func fsStatsValues(fsStats *stats.FsStats) (used, capacity float64, exist bool) {
+	if fsStats == nil || fsStats.UsedBytes == nil {
+		return 0, 0, false
+	}
+	used = float64(*fsStats.UsedBytes) / util.MegabytesToBytes
+	if fsStats.CapacityBytes != nil {
+		capacity = float64(*fsStats.CapacityBytes) / util.MegabytesToBytes
+	}
+	return used, capacity, true
 }
@@ -0,0 +1,165 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+const mb uint64 = 1024 * 1024
+
+func newFsStats(used, capacity uint64) *stats.FsStats {
+	return &stats.FsStats{UsedBytes: &used, CapacityBytes: &capacity}
+}
+
+func checkMetric(t *testing.T, sink *metrics.EntityMetricSink, eType task.DiscoveredEntityType, id string,
+	rType metrics.ResourceType, mProp metrics.MetricProp, expected float64) {
+	uid := metrics.GenerateEntityResourceMetricUID(eType, id, rType, mProp)
+	m, err := sink.GetMetric(uid)
+	if err != nil {
+		t.Errorf("Metric %s not found: %v", uid, err)
+		return
+	}
+	if v := m.GetValue().(float64); v != expected {
+		t.Errorf("Metric %s: expected %f, got %f", uid, expected, v)
+	}
+}
+
+func TestParseStorageStats(t *testing.T) {
+	monitor, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil))
+	summary := &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: "node-1",
+			Fs:       newFsStats(10*mb, 100*mb),
+		},
+		Pods: []stats.PodStats{
+			{
+				PodRef: stats.PodReference{Namespace: "default", Name: "pod-1", UID: "pod-1-uid"},
+				Containers: []stats.ContainerStats{
+					{Name: "app", Rootfs: newFsStats(2*mb, 100*mb), Logs: newFsStats(1*mb, 100*mb)},
+					{Name: "sidecar", Rootfs: newFsStats(1*mb, 100*mb)},
+				},
+			},
+		},
+	}
+	monitor.parseStorageStats(summary)
+	sink := monitor.metricSink
+
+	checkMetric(t, sink, task.NodeType, "node-1", metrics.EphemeralStorage, metrics.Used, 10)
+	checkMetric(t, sink, task.PodType, "default/pod-1", metrics.EphemeralStorage, metrics.Used, 4)
+	checkMetric(t, sink, task.ContainerType, util.ContainerIdFunc("pod-1-uid", 0), metrics.EphemeralStorage,
+		metrics.Used, 3)
+	checkMetric(t, sink, task.ContainerType, util.ContainerIdFunc("pod-1-uid", 1), metrics.EphemeralStorage,
+		metrics.Used, 1)
+
+	// The capacity comes from the node allocatable, not from the filesystem.
+	for _, uid := range []string{
+		metrics.GenerateEntityResourceMetricUID(task.NodeType, "node-1", metrics.EphemeralStorage, metrics.Capacity),
+		metrics.GenerateEntityResourceMetricUID(task.PodType, "default/pod-1", metrics.EphemeralStorage, metrics.Capacity),
+	} {
+		if _, err := sink.GetMetric(uid); err == nil {
+			t.Errorf("Expected no metric %s from the kubelet", uid)
+		}
+	}
+}
+
+func TestGetCPUUsageCore(t *testing.T) {
+	monitor, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil))
+	t0 := time.Now()
+	newCPUStats := func(usageNanoCores, usageCoreNanoSeconds uint64, timestamp time.Time) *stats.CPUStats {
+		return &stats.CPUStats{
+			Time:                 metav1.NewTime(timestamp),
+			UsageNanoCores:       &usageNanoCores,
+			UsageCoreNanoSeconds: &usageCoreNanoSeconds,
+		}
+	}
+
+	// first discovery: instantaneous value
+	if v, ok := monitor.getCPUUsageCore(task.NodeType, "node-1", newCPUStats(5e8, 1e12, t0)); !ok || v != 0.5 {
+		t.Errorf("Expected 0.5 core for the first discovery, got %f, %v", v, ok)
+	}
+	// averaged over 10 seconds
+	if v, ok := monitor.getCPUUsageCore(task.NodeType, "node-1", newCPUStats(5e8, 1e12+2e10, t0.Add(10*time.Second))); !ok || v != 2 {
+		t.Errorf("Expected 2 cores averaged over the interval, got %f, %v", v, ok)
+	}
+	// counter reset: instantaneous value
+	if v, ok := monitor.getCPUUsageCore(task.NodeType, "node-1", newCPUStats(25e7, 1e9, t0.Add(20*time.Second))); !ok || v != 0.25 {
+		t.Errorf("Expected 0.25 core after the counter is reset, got %f, %v", v, ok)
+	}
+	if _, ok := monitor.getCPUUsageCore(task.NodeType, "node-1", nil); ok {
+		t.Errorf("Expected no CPU usage without stats")
+	}
+}
+
+func TestParseStatsWithoutCPU(t *testing.T) {
+	monitor, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil))
+	memoryBytes := 2 * mb
+	usageNanoCores := uint64(5e8)
+	summary := &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: "node-1",
+			Memory:   &stats.MemoryStats{UsageBytes: &memoryBytes},
+		},
+		Pods: []stats.PodStats{
+			{
+				PodRef: stats.PodReference{Namespace: "default", Name: "pod-1", UID: "pod-1-uid"},
+				Containers: []stats.ContainerStats{
+					{Name: "app", CPU: &stats.CPUStats{UsageNanoCores: &usageNanoCores},
+						Memory: &stats.MemoryStats{UsageBytes: &memoryBytes}},
+					{Name: "sidecar", Memory: &stats.MemoryStats{UsageBytes: &memoryBytes}},
+				},
+			},
+		},
+	}
+	monitor.parseNodeStats(summary.Node, DefaultNetworkThroughputCapacity*megabitsToKilobytes)
+	monitor.parsePodStats(summary.Pods)
+	sink := monitor.metricSink
+
+	app := util.ContainerIdFunc("pod-1-uid", 0)
+	sidecar := util.ContainerIdFunc("pod-1-uid", 1)
+	checkMetric(t, sink, task.NodeType, "node-1", metrics.Memory, metrics.Used, 2048)
+	checkMetric(t, sink, task.PodType, "default/pod-1", metrics.Memory, metrics.Used, 4096)
+	checkMetric(t, sink, task.ContainerType, app, metrics.CPU, metrics.Used, 0.5)
+	checkMetric(t, sink, task.ContainerType, sidecar, metrics.Memory, metrics.Used, 2048)
+
+	// The CPU used is skipped rather than reported as 0 where it is not available.
+	for _, uid := range []string{
+		metrics.GenerateEntityResourceMetricUID(task.NodeType, "node-1", metrics.CPU, metrics.Used),
+		metrics.GenerateEntityResourceMetricUID(task.PodType, "default/pod-1", metrics.CPU, metrics.Used),
+		metrics.GenerateEntityResourceMetricUID(task.ContainerType, sidecar, metrics.CPU, metrics.Used),
+	} {
+		if _, err := sink.GetMetric(uid); err == nil {
+			t.Errorf("Expected no metric %s without CPU stats", uid)
+		}
+	}
+}
+
+func TestGetNetworkThroughputCapacity(t *testing.T) {
+	monitor, _ := NewKubeletMonitor(NewKubeletMonitorConfig(nil).WithNetworkThroughputCapacity(10000))
+	// 1 Gbit/s in KB/s.
+	gbps := 1000 * 1000 * 1000 / 8 / 1024.0
+	tests := []struct {
+		annotations map[string]string
+		expected    float64
+	}{
+		{nil, 10 * gbps},
+		{map[string]string{NetworkThroughputCapacityAnnotation: "1000"}, gbps},
+		// invalid annotations are ignored.
+		{map[string]string{NetworkThroughputCapacityAnnotation: "fast"}, 10 * gbps},
+		{map[string]string{NetworkThroughputCapacityAnnotation: "-1"}, 10 * gbps},
+	}
+	for _, test := range tests {
+		node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Annotations: test.annotations}}
+		if capacity := monitor.getNetworkThroughputCapacity(node); capacity != test.expected {
+			t.Errorf("Node with annotations %v: expected capacity %f, got %f", test.annotations, test.expected,
+				capacity)
+		}
+	}
+}
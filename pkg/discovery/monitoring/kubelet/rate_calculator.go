@@ -0,0 +1,60 @@
+package kubelet
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// Samples of counters which are not updated for this long are dropped, e.g. the counters of deleted pods.
+	staleCounterAge = 30 * time.Minute
+)
+
+type counterSample struct {
+	value     float64
+	timestamp time.Time
+}
+
+// rateCalculator keeps the previous sample of cumulative counters, such as the bytes received by a node,
+// and turns the new samples into rates per second.
+// It is shared by all the kubelet monitors, as the nodes are not always assigned to the same worker.
+type rateCalculator struct {
+	samples map[string]counterSample
+	lock    sync.Mutex
+}
+
+func newRateCalculator() *rateCalculator {
+	return &rateCalculator{
+		samples: make(map[string]counterSample),
+	}
+}
+
+// Record the sample of the counter with the given key, and return the rate since the previous sample.
+// No rate is returned for the first sample, or when the counter has been reset.
+func (c *rateCalculator) rate(key string, value float64, timestamp time.Time) (float64, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	prev, exist := c.samples[key]
+	if exist && !timestamp.After(prev.timestamp) {
+		// The same sample is read again, keep the previous one.
+		return 0, false
+	}
+	c.samples[key] = counterSample{value: value, timestamp: timestamp}
+	if !exist || value < prev.value {
+		return 0, false
+	}
+	return (value - prev.value) / timestamp.Sub(prev.timestamp).Seconds(), true
+}
+
+// Drop the samples recorded before the given time.
+func (c *rateCalculator) prune(before time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key, sample := range c.samples {
+		if sample.timestamp.Before(before) {
+			delete(c.samples, key)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package kubelet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateCalculator(t *testing.T) {
+	c := newRateCalculator()
+	t0 := time.Now()
+
+	if _, ok := c.rate("key", 100, t0); ok {
+		t.Errorf("Expected no rate for the first sample")
+	}
+	if r, ok := c.rate("key", 400, t0.Add(10*time.Second)); !ok || r != 30 {
+		t.Errorf("Expected rate 30, got %f, %v", r, ok)
+	}
+	if _, ok := c.rate("key", 500, t0.Add(10*time.Second)); ok {
+		t.Errorf("Expected no rate for a sample with the same timestamp")
+	}
+	if _, ok := c.rate("key", 50, t0.Add(20*time.Second)); ok {
+		t.Errorf("Expected no rate after the counter is reset")
+	}
+	if r, ok := c.rate("key", 250, t0.Add(30*time.Second)); !ok || r != 20 {
+		t.Errorf("Expected rate 20 after the counter is reset, got %f, %v", r, ok)
+	}
+
+	c.prune(t0.Add(time.Minute))
+	if _, ok := c.rate("key", 300, t0.Add(2*time.Minute)); ok {
+		t.Errorf("Expected no rate after the sample is pruned")
+	}
+}
@@ -1,6 +1,7 @@
 package master
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -26,15 +27,12 @@ type ClusterMonitor struct {
 	nodeList []*api.Node
 
 	nodePodMap map[string][]*api.Pod
-
-	stopCh chan struct{}
 }
 
 func NewClusterMonitor(config *ClusterMonitorConfig) (*ClusterMonitor, error) {
 
 	return &ClusterMonitor{
 		config: config,
-		stopCh: make(chan struct{}, 1),
 	}, nil
 }
 
@@ -49,13 +47,9 @@ func (m *ClusterMonitor) ReceiveTask(task *task.Task) {
 	m.nodePodMap = util.GroupPodsByNode(task.PodList())
 }
 
-func (m *ClusterMonitor) Stop() {
-	m.stopCh <- struct{}{}
-}
-
-func (m *ClusterMonitor) Do() *metrics.EntityMetricSink {
+func (m *ClusterMonitor) Do(ctx context.Context) *metrics.EntityMetricSink {
 	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
-	err := m.RetrieveClusterStat()
+	err := m.RetrieveClusterStat(ctx)
 	if err != nil {
 		glog.Errorf("Failed to execute task: %s", err)
 	}
@@ -63,42 +57,35 @@ func (m *ClusterMonitor) Do() *metrics.EntityMetricSink {
 	return m.sink
 }
 
-// Start to retrieve resource stats for the received list of nodes.
-func (m *ClusterMonitor) RetrieveClusterStat() error {
-	defer close(m.stopCh)
-
+// Start to retrieve resource stats for the received list of nodes, until the context is cancelled.
+func (m *ClusterMonitor) RetrieveClusterStat(ctx context.Context) error {
 	if m.nodeList == nil {
 		return errors.New("Invalid nodeList or empty nodeList. Nothing to monitor.")
 	}
-	select {
-	case <-m.stopCh:
+	if ctx.Err() != nil {
 		return nil
-	default:
-		err := m.findClusterID()
-		if err != nil {
-			return fmt.Errorf("Failed to find cluster ID based on Kubernetes service: %v", err)
-		}
-		select {
-		case <-m.stopCh:
-			return nil
-		default:
-			m.findNodeStates()
-		}
-
+	}
+	err := m.findClusterID(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to find cluster ID based on Kubernetes service: %v", err)
+	}
+	if ctx.Err() != nil {
 		return nil
 	}
+	m.findNodeStates()
+
+	return nil
 }
 
 func (m *ClusterMonitor) reset() {
 	m.sink = metrics.NewEntityMetricSink()
-	m.stopCh = make(chan struct{}, 1)
 }
 
 // ----------------------------------------------- Cluster State -------------------------------------------------
 // Get the cluster ID of the Kubernetes cluster.
 // Use Kubernetes service UID as the key for cluster commodity
-func (m *ClusterMonitor) findClusterID() error {
-	kubernetesSvcID, err := m.config.clusterInfoScraper.GetKubernetesServiceID()
+func (m *ClusterMonitor) findClusterID(ctx context.Context) error {
+	kubernetesSvcID, err := m.config.clusterInfoScraper.GetKubernetesServiceIDWithContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -129,16 +116,36 @@ func (m *ClusterMonitor) findNodeStates() {
 // 	memory 			capacity
 //	CPUProvisioned		capacity, used
 //	memoryProvisioned	capacity, used
+//	CPU, memory		reservation, if the raw capacity is used
 func (m *ClusterMonitor) genNodeResourceMetrics(node *api.Node) error {
 	key := util.NodeKeyFunc(node)
 	glog.V(3).Infof("Now get resouce metrics for node %s", key)
 
 	//1. Capacity of cpu and memory
-	cpuCapacityCore, memoryCapacityKiloBytes := util.GetCpuAndMemoryValues(node.Status.Allocatable)
+	nodeCapacity := util.GetNodeCapacity(node, m.config.useNodeCapacity)
+	cpuCapacityCore, memoryCapacityKiloBytes := util.GetCpuAndMemoryValues(nodeCapacity)
 	glog.V(4).Infof("Cpu capacity of node %s is %f core", node.Name, cpuCapacityCore)
 	glog.V(4).Infof("Memory capacity of node %s is %f Kb", node.Name, memoryCapacityKiloBytes)
 	m.genCapacityMetrics(task.NodeType, key, cpuCapacityCore, memoryCapacityKiloBytes)
 
+	// The resources reserved for the kubelet and the system daemons are not available to the pods. They are
+	// excluded from the allocatable capacity; with the raw capacity, they are reserved as overhead instead.
+	if m.config.useNodeCapacity {
+		cpuReservedCore, memoryReservedKiloBytes := util.GetNodeReservedResources(node)
+		glog.V(4).Infof("Reserved cpu and memory of node %s are %f core and %f Kb", node.Name,
+			cpuReservedCore, memoryReservedKiloBytes)
+		m.genReserveMetrics(task.NodeType, key, cpuReservedCore, memoryReservedKiloBytes)
+	}
+
+	// ephemeral storage capacity of the node and its pods, if the node reports it.
+	if storageCapacity, exist := util.GetEphemeralStorageValue(nodeCapacity); exist {
+		glog.V(4).Infof("Ephemeral storage capacity of node %s is %f Mb", node.Name, storageCapacity)
+		m.genStorageCapacityMetrics(task.NodeType, key, storageCapacity)
+		for _, pod := range m.nodePodMap[node.Name] {
+			m.genStorageCapacityMetrics(task.PodType, util.PodKeyFunc(pod), storageCapacity)
+		}
+	}
+
 	//2. Provision Capacity of cpu and memory
 	// The provisioned resources have the same capacities.
 	cpuProvisionedCapacity := cpuCapacityCore
@@ -199,6 +206,7 @@ func (m *ClusterMonitor) genNodePodsMetrics(node *api.Node, cpuCapacity, memCapa
 // (2) generate Container.Capacity and Container.Reservation
 //
 // Note: Pod.Capacity = node.Capacity; Pod.Reservation = sum.container.reservation
+// The provisioned resources of the pod have the same capacity, and the reservation as used value.
 func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacity, nodeMemCapacity float64) {
 	key := util.PodKeyFunc(pod)
 	glog.V(3).Infof("begin to generate pod[%s]'s CPU/Mem Capacity.", key)
@@ -212,6 +220,10 @@ func (m *ClusterMonitor) genPodMetrics(pod *api.Pod, nodeCPUCapacity, nodeMemCap
 	//2.1 Container Capacity and Reservation
 	cpuRequest, memRequest := m.genContainerMetrics(pod, cpuCapacity, memCapacity)
 	m.genReserveMetrics(task.PodType, key, cpuRequest, memRequest)
+
+	//3. Provisioned capacity and used
+	m.genProvisionCapacityMetrics(task.PodType, key, cpuCapacity, memCapacity)
+	m.genProvisionUsedMetrics(task.PodType, key, cpuRequest, memRequest)
 	return
 }
 
@@ -274,6 +286,11 @@ func (m *ClusterMonitor) genCapacityMetrics(etype task.DiscoveredEntityType, key
 	m.sink.AddNewMetricEntries(cpuMetric, memMetric)
 }
 
+func (m *ClusterMonitor) genStorageCapacityMetrics(etype task.DiscoveredEntityType, key string, storage float64) {
+	storageMetric := metrics.NewEntityResourceMetric(etype, key, metrics.EphemeralStorage, metrics.Capacity, storage)
+	m.sink.AddNewMetricEntries(storageMetric)
+}
+
 func (m *ClusterMonitor) genReserveMetrics(etype task.DiscoveredEntityType, key string, cpu, memory float64) {
 	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Reservation, cpu)
 	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Reservation, memory)
@@ -11,6 +11,9 @@ import (
 
 type ClusterMonitorConfig struct {
 	clusterInfoScraper *cluster.ClusterScraper
+
+	// Use the raw capacity of the nodes instead of their allocatable resources.
+	useNodeCapacity bool
 }
 
 func NewClusterMonitorConfig(kubeConfig *restclient.Config) (*ClusterMonitorConfig, error) {
@@ -24,6 +27,13 @@ func NewClusterMonitorConfig(kubeConfig *restclient.Config) (*ClusterMonitorConf
 	}, nil
 }
 
+// With useNodeCapacity, the capacity of a node is its raw capacity, and the resources reserved for the kubelet
+// and the system daemons are reported as its used overhead.
+func (c *ClusterMonitorConfig) WithNodeCapacity(useNodeCapacity bool) *ClusterMonitorConfig {
+	c.useNodeCapacity = useNodeCapacity
+	return c
+}
+
 // Implement MonitoringWorkerConfig interface.
 func (c ClusterMonitorConfig) GetMonitorType() types.MonitorType {
 	return types.StateMonitor
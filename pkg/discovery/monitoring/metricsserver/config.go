@@ -0,0 +1,46 @@
+package metricsserver
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+// Config for building a metrics-server monitor worker.
+type MetricsServerMonitorConfig struct {
+	metricsClient *MetricsClient
+
+	// The CPU frequency (in MHz) assigned to every node.
+	// It is only used when kubelet, which provides the real frequency, is not a monitoring source.
+	nodeCPUFrequencyMHz float64
+}
+
+func NewMetricsServerMonitorConfig(kubeConfig *restclient.Config) (*MetricsServerMonitorConfig, error) {
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid API configuration: %v", err)
+	}
+
+	return &MetricsServerMonitorConfig{
+		metricsClient: NewMetricsClient(kubeClient.CoreV1().RESTClient()),
+	}, nil
+}
+
+// Assign a CPU frequency to all the nodes, as metrics.k8s.io does not provide it.
+func (c *MetricsServerMonitorConfig) WithNodeCPUFrequency(frequencyMHz float64) *MetricsServerMonitorConfig {
+	c.nodeCPUFrequencyMHz = frequencyMHz
+	return c
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c *MetricsServerMonitorConfig) GetMonitorType() types.MonitorType {
+	return types.ResourceMonitor
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c *MetricsServerMonitorConfig) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
@@ -0,0 +1,77 @@
+package metricsserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	metricsAPIPath string = "/apis/metrics.k8s.io/v1beta1"
+)
+
+// The following types mirror the resource metrics API (metrics.k8s.io/v1beta1), which is not vendored.
+// Only the fields used by kubeturbo are kept.
+type NodeMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Usage api.ResourceList `json:"usage"`
+}
+
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Containers []ContainerMetrics `json:"containers"`
+}
+
+type ContainerMetrics struct {
+	Name string `json:"name"`
+
+	Usage api.ResourceList `json:"usage"`
+}
+
+type podMetricsList struct {
+	Items []PodMetrics `json:"items"`
+}
+
+// MetricsClient reads node and pod metrics of metrics-server through the API server.
+// It is thread-safe as the underlying rest client is thread-safe.
+type MetricsClient struct {
+	restClient rest.Interface
+}
+
+func NewMetricsClient(restClient rest.Interface) *MetricsClient {
+	return &MetricsClient{
+		restClient: restClient,
+	}
+}
+
+// Get the metrics of the node with the given name. The request is aborted when the context is cancelled.
+func (c *MetricsClient) GetNodeMetrics(ctx context.Context, nodeName string) (*NodeMetrics, error) {
+	body, err := c.restClient.Get().Context(ctx).AbsPath(metricsAPIPath, "nodes", nodeName).DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics of node %s: %v", nodeName, err)
+	}
+	nodeMetrics := &NodeMetrics{}
+	if err := json.Unmarshal(body, nodeMetrics); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics of node %s: %v", nodeName, err)
+	}
+	return nodeMetrics, nil
+}
+
+// List the metrics of all the pods in the given namespace. The request is aborted when the context is cancelled.
+func (c *MetricsClient) ListPodMetrics(ctx context.Context, namespace string) ([]PodMetrics, error) {
+	body, err := c.restClient.Get().Context(ctx).AbsPath(metricsAPIPath, "namespaces", namespace, "pods").DoRaw()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics in namespace %s: %v", namespace, err)
+	}
+	list := &podMetricsList{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return nil, fmt.Errorf("failed to parse pod metrics in namespace %s: %v", namespace, err)
+	}
+	return list.Items, nil
+}
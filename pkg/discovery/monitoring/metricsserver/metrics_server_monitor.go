@@ -0,0 +1,195 @@
+package metricsserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/golang/glog"
+)
+
+// MetricsServerMonitor is a resource monitoring worker, which reads the metrics.k8s.io API served by metrics-server
+// through the API server, so it does not need to access kubelet of every node directly.
+type MetricsServerMonitor struct {
+	config *MetricsServerMonitorConfig
+
+	nodeList []*api.Node
+
+	// key: namespace; value: pods of the namespace on the nodes of the task, including the pods out of scope.
+	namespacePodMap map[string][]*api.Pod
+
+	metricSink *metrics.EntityMetricSink
+
+	wg sync.WaitGroup
+}
+
+func NewMetricsServerMonitor(config *MetricsServerMonitorConfig) (*MetricsServerMonitor, error) {
+	if config.metricsClient == nil {
+		return nil, errors.New("metrics client is not provided")
+	}
+	return &MetricsServerMonitor{
+		config:     config,
+		metricSink: metrics.NewEntityMetricSink(),
+	}, nil
+}
+
+func (m *MetricsServerMonitor) reset() {
+	m.metricSink = metrics.NewEntityMetricSink()
+}
+
+// Implement MonitoringWorker interface.
+func (m *MetricsServerMonitor) GetMonitoringSource() types.MonitoringSource {
+	return types.MetricsServerSource
+}
+
+// Implement MonitoringWorker interface.
+func (m *MetricsServerMonitor) ReceiveTask(task *task.Task) {
+	m.reset()
+
+	m.nodeList = task.NodeList()
+	m.namespacePodMap = make(map[string][]*api.Pod)
+	// The usage of the pods out of scope is part of the overhead of their node.
+	for _, pod := range task.NodePodList() {
+		m.namespacePodMap[pod.Namespace] = append(m.namespacePodMap[pod.Namespace], pod)
+	}
+}
+
+// Implement MonitoringWorker interface.
+func (m *MetricsServerMonitor) Do(ctx context.Context) *metrics.EntityMetricSink {
+	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
+	err := m.RetrieveResourceStat(ctx)
+	if err != nil {
+		glog.Errorf("Failed to execute task: %s", err)
+	}
+	glog.V(4).Infof("%s monitor has finished task.", m.GetMonitoringSource())
+	return m.metricSink
+}
+
+// Start to retrieve resource stats for the received list of nodes and pods, until the context is cancelled.
+func (m *MetricsServerMonitor) RetrieveResourceStat(ctx context.Context) error {
+	if m.nodeList == nil || len(m.nodeList) == 0 {
+		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
+	}
+	m.wg.Add(len(m.nodeList) + len(m.namespacePodMap))
+
+	for _, node := range m.nodeList {
+		go func(n *api.Node) {
+			defer m.wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			m.scrapeNode(ctx, n)
+		}(node)
+	}
+
+	for namespace, pods := range m.namespacePodMap {
+		go func(ns string, podList []*api.Pod) {
+			defer m.wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			m.scrapePods(ctx, ns, podList)
+		}(namespace, pods)
+	}
+
+	m.wg.Wait()
+
+	return nil
+}
+
+// Retrieve resource metrics for the given node.
+func (m *MetricsServerMonitor) scrapeNode(ctx context.Context, node *api.Node) {
+	key := util.NodeKeyFunc(node)
+	if m.config.nodeCPUFrequencyMHz > 0 {
+		cpuFrequencyMetric := metrics.NewEntityStateMetric(task.NodeType, key, metrics.CpuFrequency,
+			m.config.nodeCPUFrequencyMHz)
+		m.metricSink.AddNewMetricEntries(cpuFrequencyMetric)
+	}
+
+	nodeMetrics, err := m.config.metricsClient.GetNodeMetrics(ctx, node.Name)
+	if err != nil {
+		glog.Errorf("Failed to get resource metrics of node %s: %v", node.Name, err)
+		return
+	}
+	cpuUsageCore, memoryUsageKiloBytes := util.GetCpuAndMemoryValues(nodeMetrics.Usage)
+	glog.V(3).Infof("CPU usage of node %s is %.3f core", node.Name, cpuUsageCore)
+	glog.V(3).Infof("Memory usage of node %s is %.3f KB", node.Name, memoryUsageKiloBytes)
+	m.genUsedMetrics(task.NodeType, key, cpuUsageCore, memoryUsageKiloBytes)
+
+	glog.V(4).Infof("Finished scrape node %s.", node.Name)
+}
+
+// Retrieve resource metrics for the given pods of a namespace.
+func (m *MetricsServerMonitor) scrapePods(ctx context.Context, namespace string, pods []*api.Pod) {
+	podMetricsList, err := m.config.metricsClient.ListPodMetrics(ctx, namespace)
+	if err != nil {
+		glog.Errorf("Failed to get resource metrics of pods: %v", err)
+		return
+	}
+	podMetricsMap := make(map[string]*PodMetrics)
+	for i := range podMetricsList {
+		podMetricsMap[podMetricsList[i].Name] = &podMetricsList[i]
+	}
+
+	for _, pod := range pods {
+		podMetrics, exist := podMetricsMap[pod.Name]
+		if !exist {
+			glog.V(3).Infof("Cannot find resource metrics of pod %s", util.PodKeyFunc(pod))
+			continue
+		}
+		cpuUsed, memUsed := m.parseContainerMetrics(pod, podMetrics)
+
+		key := util.PodKeyFunc(pod)
+		glog.V(4).Infof("Cpu usage of pod %s is %.3f core", key, cpuUsed)
+		glog.V(4).Infof("Memory usage of pod %s is %.3f Kb", key, memUsed)
+		m.genUsedMetrics(task.PodType, key, cpuUsed, memUsed)
+	}
+}
+
+func (m *MetricsServerMonitor) parseContainerMetrics(pod *api.Pod, podMetrics *PodMetrics) (float64, float64) {
+	totalUsedCPU := float64(0.0)
+	totalUsedMem := float64(0.0)
+
+	containerUsage := make(map[string]api.ResourceList)
+	for _, c := range podMetrics.Containers {
+		containerUsage[c.Name] = c.Usage
+	}
+
+	podId := string(pod.UID)
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		usage, exist := containerUsage[container.Name]
+		if !exist {
+			continue
+		}
+		cpuUsed, memUsed := util.GetCpuAndMemoryValues(usage)
+
+		totalUsedCPU += cpuUsed
+		totalUsedMem += memUsed
+
+		//1. container Used
+		containerId := util.ContainerIdFunc(podId, i)
+		m.genUsedMetrics(task.ContainerType, containerId, cpuUsed, memUsed)
+
+		glog.V(4).Infof("container[%s-%s] cpu/memory usage:%.3f, %.3f", pod.Name, container.Name, cpuUsed, memUsed)
+
+		//2. app Used
+		appId := util.ApplicationIdFunc(containerId)
+		m.genUsedMetrics(task.ApplicationType, appId, cpuUsed, memUsed)
+	}
+
+	return totalUsedCPU, totalUsedMem
+}
+
+func (m *MetricsServerMonitor) genUsedMetrics(etype task.DiscoveredEntityType, key string, cpu, memory float64) {
+	cpuMetric := metrics.NewEntityResourceMetric(etype, key, metrics.CPU, metrics.Used, cpu)
+	memMetric := metrics.NewEntityResourceMetric(etype, key, metrics.Memory, metrics.Used, memory)
+	m.metricSink.AddNewMetricEntries(cpuMetric, memMetric)
+}
@@ -0,0 +1,114 @@
+package metricsserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+// A metrics.k8s.io stand-in which answers with canned objects keyed by the request path.
+func newFakeMetricsServer(responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, exist := responses[r.URL.Path]
+		if !exist {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+}
+
+func newTestPod(namespace, name string, containers ...string) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(name + "-uid"),
+		},
+	}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, api.Container{Name: c})
+	}
+	return pod
+}
+
+func checkMetric(t *testing.T, sink *metrics.EntityMetricSink, eType task.DiscoveredEntityType, id string,
+	rType metrics.ResourceType, expected float64) {
+	uid := metrics.GenerateEntityResourceMetricUID(eType, id, rType, metrics.Used)
+	m, err := sink.GetMetric(uid)
+	if err != nil {
+		t.Errorf("Metric %s not found: %v", uid, err)
+		return
+	}
+	if v := m.GetValue().(float64); v != expected {
+		t.Errorf("Metric %s: expected %f, got %f", uid, expected, v)
+	}
+}
+
+func TestMetricsServerMonitor(t *testing.T) {
+	node := &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	pod1 := newTestPod("default", "pod-1", "app", "sidecar")
+	pod2 := newTestPod("default", "pod-2", "app")
+	// out of scope, but part of the overhead of its node.
+	pod3 := newTestPod("default", "other", "app")
+
+	responses := map[string]string{
+		metricsAPIPath + "/nodes/node-1": `{"metadata":{"name":"node-1"},"usage":{"cpu":"1500m","memory":"2Mi"}}`,
+		metricsAPIPath + "/namespaces/default/pods": `{"items":[
+			{"metadata":{"name":"pod-1","namespace":"default"},"containers":[
+				{"name":"app","usage":{"cpu":"500m","memory":"1Mi"}},
+				{"name":"sidecar","usage":{"cpu":"250m","memory":"1Ki"}}]},
+			{"metadata":{"name":"other","namespace":"default"},"containers":[
+				{"name":"app","usage":{"cpu":"8","memory":"8Mi"}}]}]}`,
+	}
+	server := newFakeMetricsServer(responses)
+	defer server.Close()
+
+	config, err := NewMetricsServerMonitorConfig(&restclient.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create metrics-server monitor config: %v", err)
+	}
+	config.WithNodeCPUFrequency(2000)
+	monitor, err := NewMetricsServerMonitor(config)
+	if err != nil {
+		t.Fatalf("Failed to create metrics-server monitor: %v", err)
+	}
+
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}).WithPods([]*api.Pod{pod1, pod2}).
+		WithNodePods([]*api.Pod{pod1, pod2, pod3}))
+	sink := monitor.Do(context.Background())
+
+	// node
+	checkMetric(t, sink, task.NodeType, node.Name, metrics.CPU, 1.5)
+	checkMetric(t, sink, task.NodeType, node.Name, metrics.Memory, 2048)
+	frequencyUID := metrics.GenerateEntityStateMetricUID(task.NodeType, node.Name, metrics.CpuFrequency)
+	if m, err := sink.GetMetric(frequencyUID); err != nil || m.GetValue().(float64) != 2000 {
+		t.Errorf("Unexpected CPU frequency metric of node %s: %v, %v", node.Name, m, err)
+	}
+
+	// containers, applications and pods
+	container0 := util.ContainerIdFunc(string(pod1.UID), 0)
+	container1 := util.ContainerIdFunc(string(pod1.UID), 1)
+	checkMetric(t, sink, task.ContainerType, container0, metrics.CPU, 0.5)
+	checkMetric(t, sink, task.ContainerType, container1, metrics.Memory, 1)
+	checkMetric(t, sink, task.ApplicationType, util.ApplicationIdFunc(container0), metrics.Memory, 1024)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod1), metrics.CPU, 0.75)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod1), metrics.Memory, 1025)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod3), metrics.CPU, 8)
+	if _, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(task.PodType, util.PodKeyFunc(pod2),
+		metrics.CPU, metrics.Used)); err == nil {
+		t.Errorf("Pod without metrics should be ignored")
+	}
+}
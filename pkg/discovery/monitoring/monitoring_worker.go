@@ -1,13 +1,18 @@
 package monitoring
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
+	api "k8s.io/client-go/pkg/api/v1"
+
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/k8sconntrack"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/master"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/metricsserver"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/prometheus"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
 )
@@ -18,20 +23,27 @@ type MonitorWorkerConfig interface {
 }
 
 type MonitoringWorker interface {
-	Do() *metrics.EntityMetricSink
-	Stop()
+	// Do the task received and return the metrics. The in-flight requests are aborted and Do returns promptly once the
+	// context is cancelled, e.g. when the task times out; the metrics returned are then incomplete.
+	Do(ctx context.Context) *metrics.EntityMetricSink
 	ReceiveTask(task *task.Task)
 	GetMonitoringSource() types.MonitoringSource
 }
 
 type ResourceMonitoringWorker interface {
 	MonitoringWorker
-	RetrieveResourceStat() error
+	RetrieveResourceStat(ctx context.Context) error
 }
 
 type StateMonitoringWorker interface {
 	MonitoringWorker
-	RetrieveClusterStat() error
+	RetrieveClusterStat(ctx context.Context) error
+}
+
+// Implemented by the monitoring workers which scrape the nodes one by one, to report the nodes which didn't respond in
+// time during the last task: their metrics from this source are missing.
+type NodeScrapingWorker interface {
+	TimedOutNodes() []*api.Node
 }
 
 func BuildMonitorWorker(source types.MonitoringSource, config MonitorWorkerConfig) (MonitoringWorker, error) {
@@ -55,8 +67,18 @@ func BuildMonitorWorker(source types.MonitoringSource, config MonitorWorkerConfi
 			return nil, errors.New("Failed to build a k8sconntrack monitoring client as the provided config was not a K8sConntrackConfig")
 		}
 		return k8sconntrack.NewK8sConntrackMonitor(k8sconntrackMonitoring)
-
-	//case "Prometheus":
+	case types.PrometheusSource:
+		prometheusMonitorConfig, ok := config.(*prometheus.PrometheusMonitorConfig)
+		if !ok {
+			return nil, errors.New("Failed to build a Prometheus monitoring client as the provided config was not a PrometheusMonitorConfig")
+		}
+		return prometheus.NewPrometheusMonitor(prometheusMonitorConfig)
+	case types.MetricsServerSource:
+		metricsServerMonitorConfig, ok := config.(*metricsserver.MetricsServerMonitorConfig)
+		if !ok {
+			return nil, errors.New("Failed to build a metrics-server monitoring client as the provided config was not a MetricsServerMonitorConfig")
+		}
+		return metricsserver.NewMetricsServerMonitor(metricsServerMonitorConfig)
 	default:
 		return nil, fmt.Errorf("Unsupported monitoring source %s", source)
 	}
@@ -0,0 +1,125 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+)
+
+type PrometheusQueryType string
+
+const (
+	NodeCPUQuery         PrometheusQueryType = "nodeCPU"
+	NodeMemoryQuery      PrometheusQueryType = "nodeMemory"
+	ContainerCPUQuery    PrometheusQueryType = "containerCPU"
+	ContainerMemoryQuery PrometheusQueryType = "containerMemory"
+	PodTransactionQuery  PrometheusQueryType = "podTransaction"
+	PodResponseTimeQuery PrometheusQueryType = "podResponseTime"
+)
+
+const (
+	defaultPrometheusQueryTimeout = 20 * time.Second
+
+	// Placeholder in a query which is replaced by a regex matching the names of the nodes in the current task.
+	NodeNamesPlaceholder string = "$NODES"
+)
+
+var (
+	// The default queries are based on the cAdvisor metrics exposed by kubelet and scraped by Prometheus.
+	// The result of node queries must carry a "node" label; the result of container queries must carry
+	// "namespace", "pod" and "container" labels; the result of pod queries must carry "namespace" and "pod" labels.
+	defaultQueries = map[PrometheusQueryType]string{
+		NodeCPUQuery:    `sum by (node) (rate(container_cpu_usage_seconds_total{id="/",node=~"$NODES"}[1m]))`,
+		NodeMemoryQuery: `sum by (node) (container_memory_usage_bytes{id="/",node=~"$NODES"})`,
+		ContainerCPUQuery: `sum by (namespace, pod, container) ` +
+			`(rate(container_cpu_usage_seconds_total{container!="",container!="POD",node=~"$NODES"}[1m]))`,
+		ContainerMemoryQuery: `sum by (namespace, pod, container) ` +
+			`(container_memory_usage_bytes{container!="",container!="POD",node=~"$NODES"})`,
+	}
+)
+
+// Config for building a Prometheus monitor worker.
+type PrometheusMonitorConfig struct {
+	// the address of the Prometheus server, e.g. http://prometheus.monitoring:9090
+	serverAddress string
+
+	// timeout when querying Prometheus server.
+	timeout time.Duration
+
+	// PromQL queries used to get the metrics. key: query type; value: query.
+	queries map[PrometheusQueryType]string
+
+	// The CPU frequency (in MHz) assigned to every node.
+	// It is only used when kubelet, which provides the real frequency, is not a monitoring source.
+	nodeCPUFrequencyMHz float64
+}
+
+func NewPrometheusMonitorConfig(serverAddress string) *PrometheusMonitorConfig {
+	queries := make(map[PrometheusQueryType]string)
+	for qType, query := range defaultQueries {
+		queries[qType] = query
+	}
+	return &PrometheusMonitorConfig{
+		serverAddress: serverAddress,
+		timeout:       defaultPrometheusQueryTimeout,
+		queries:       queries,
+	}
+}
+
+func (c *PrometheusMonitorConfig) WithTimeout(timeout time.Duration) *PrometheusMonitorConfig {
+	c.timeout = timeout
+	return c
+}
+
+// Assign a CPU frequency to all the nodes, as the cAdvisor metrics do not provide it.
+func (c *PrometheusMonitorConfig) WithNodeCPUFrequency(frequencyMHz float64) *PrometheusMonitorConfig {
+	c.nodeCPUFrequencyMHz = frequencyMHz
+	return c
+}
+
+// Override the default query of the given type. An empty query disables that type of metric.
+func (c *PrometheusMonitorConfig) WithQuery(qType PrometheusQueryType, query string) *PrometheusMonitorConfig {
+	if query == "" {
+		delete(c.queries, qType)
+		return c
+	}
+	c.queries[qType] = query
+	return c
+}
+
+// Override the default queries with the ones defined in a json file, e.g. {"podTransaction": "..."}.
+func (c *PrometheusMonitorConfig) WithQueryFile(path string) (*PrometheusMonitorConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Prometheus query file %s: %v", path, err)
+	}
+	queries := make(map[PrometheusQueryType]string)
+	if err := json.Unmarshal(content, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus query file %s: %v", path, err)
+	}
+	for qType, query := range queries {
+		c.WithQuery(qType, query)
+	}
+	return c, nil
+}
+
+// Whether a query of the pod transactions or response time is configured. There is no default one, as these metrics
+// depend on the applications.
+func (c *PrometheusMonitorConfig) HasTransactionQueries() bool {
+	_, hasTransaction := c.queries[PodTransactionQuery]
+	_, hasResponseTime := c.queries[PodResponseTimeQuery]
+	return hasTransaction || hasResponseTime
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c *PrometheusMonitorConfig) GetMonitorType() types.MonitorType {
+	return types.ResourceMonitor
+}
+
+// Implement MonitoringWorkerConfig interface.
+func (c *PrometheusMonitorConfig) GetMonitoringSource() types.MonitoringSource {
+	return types.PrometheusSource
+}
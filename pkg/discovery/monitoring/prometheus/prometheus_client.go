@@ -0,0 +1,110 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util/httputil"
+)
+
+const (
+	queryPath string = "/api/v1/query"
+
+	statusSuccess    string = "success"
+	resultTypeVector string = "vector"
+)
+
+// The response of Prometheus instant query API.
+// https://prometheus.io/docs/prometheus/latest/querying/api/#instant-queries
+type queryResponse struct {
+	Status    string    `json:"status"`
+	Data      queryData `json:"data"`
+	ErrorType string    `json:"errorType,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+type queryData struct {
+	ResultType string         `json:"resultType"`
+	Result     []sampleResult `json:"result"`
+}
+
+type sampleResult struct {
+	Metric map[string]string `json:"metric"`
+	// A pair of [unix_time, "value"].
+	Value []interface{} `json:"value"`
+}
+
+// A single sample of an instant vector.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// PrometheusClient is used to send PromQL queries to a Prometheus server and parse the response.
+type PrometheusClient struct {
+	address string
+	client  *http.Client
+}
+
+func NewPrometheusClient(address string, timeout time.Duration) *PrometheusClient {
+	return &PrometheusClient{
+		address: address,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Run an instant query and return the samples of the resulting vector.
+// The query is aborted when the context is cancelled.
+func (c *PrometheusClient) Query(ctx context.Context, query string) ([]Sample, error) {
+	requestURL, err := url.Parse(c.address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Prometheus server address %s: %v", c.address, err)
+	}
+	requestURL.Path = queryPath
+	requestURL.RawQuery = url.Values{"query": []string{query}}.Encode()
+
+	req, err := http.NewRequest("GET", requestURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	var resp queryResponse
+	if err := httputil.PostRequestAndGetValue(c.client, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != statusSuccess {
+		return nil, fmt.Errorf("query %s failed: %s %s", query, resp.ErrorType, resp.Error)
+	}
+	if resp.Data.ResultType != resultTypeVector {
+		return nil, fmt.Errorf("query %s returned %s instead of %s", query, resp.Data.ResultType, resultTypeVector)
+	}
+
+	samples := make([]Sample, 0, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		value, err := parseSampleValue(r.Value)
+		if err != nil {
+			return nil, fmt.Errorf("query %s returned invalid sample %v: %v", query, r.Value, err)
+		}
+		samples = append(samples, Sample{
+			Labels: r.Metric,
+			Value:  value,
+		})
+	}
+	return samples, nil
+}
+
+// Prometheus encodes a sample value as [timestamp, "value"].
+func parseSampleValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("expect 2 elements, got %d", len(value))
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("value is not a string")
+	}
+	return strconv.ParseFloat(s, 64)
+}
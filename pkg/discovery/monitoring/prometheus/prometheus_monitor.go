@@ -0,0 +1,264 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/k8sconntrack"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/golang/glog"
+)
+
+const (
+	nodeLabel      string = "node"
+	namespaceLabel string = "namespace"
+	podLabel       string = "pod"
+	containerLabel string = "container"
+)
+
+// PrometheusMonitor is a resource monitoring worker, which gets the metrics of the nodes and pods in the task
+// from a Prometheus server.
+type PrometheusMonitor struct {
+	config *PrometheusMonitorConfig
+
+	promClient *PrometheusClient
+
+	nodeList []*api.Node
+
+	// key: namespace/name of the pod; value: pod on the nodes of the task, including the pods out of scope.
+	podMap map[string]*api.Pod
+
+	metricSink *metrics.EntityMetricSink
+}
+
+func NewPrometheusMonitor(config *PrometheusMonitorConfig) (*PrometheusMonitor, error) {
+	if config.serverAddress == "" {
+		return nil, errors.New("Prometheus server address is not provided")
+	}
+	return &PrometheusMonitor{
+		config:     config,
+		promClient: NewPrometheusClient(config.serverAddress, config.timeout),
+		metricSink: metrics.NewEntityMetricSink(),
+	}, nil
+}
+
+func (m *PrometheusMonitor) reset() {
+	m.metricSink = metrics.NewEntityMetricSink()
+}
+
+// Implement MonitoringWorker interface.
+func (m *PrometheusMonitor) GetMonitoringSource() types.MonitoringSource {
+	return types.PrometheusSource
+}
+
+// Implement MonitoringWorker interface.
+func (m *PrometheusMonitor) ReceiveTask(task *task.Task) {
+	m.reset()
+
+	m.nodeList = task.NodeList()
+	m.podMap = make(map[string]*api.Pod)
+	// The usage of the pods out of scope is part of the overhead of their node.
+	for _, pod := range task.NodePodList() {
+		m.podMap[util.PodKeyFunc(pod)] = pod
+	}
+}
+
+// Implement MonitoringWorker interface.
+func (m *PrometheusMonitor) Do(ctx context.Context) *metrics.EntityMetricSink {
+	glog.V(4).Infof("%s has started task.", m.GetMonitoringSource())
+	err := m.RetrieveResourceStat(ctx)
+	if err != nil {
+		glog.Errorf("Failed to execute task: %s", err)
+	}
+	glog.V(4).Infof("%s monitor has finished task.", m.GetMonitoringSource())
+	return m.metricSink
+}
+
+// Start to retrieve resource stats for the received list of nodes and pods, until the context is cancelled.
+func (m *PrometheusMonitor) RetrieveResourceStat(ctx context.Context) error {
+	if m.nodeList == nil || len(m.nodeList) == 0 {
+		return errors.New("Invalid nodeList or empty nodeList. Finish Immediately...")
+	}
+	steps := []func(context.Context){
+		m.scrapeNodes,
+		m.scrapeContainers,
+		m.scrapeTransactions,
+		m.scrapeResponseTime,
+	}
+	for _, step := range steps {
+		if ctx.Err() != nil {
+			return nil
+		}
+		step(ctx)
+	}
+
+	return nil
+}
+
+// Get CPU and memory used of the nodes, and assign them the configured CPU frequency if any.
+func (m *PrometheusMonitor) scrapeNodes(ctx context.Context) {
+	nodes := make(map[string]struct{})
+	for _, node := range m.nodeList {
+		key := util.NodeKeyFunc(node)
+		nodes[key] = struct{}{}
+		if m.config.nodeCPUFrequencyMHz > 0 {
+			m.metricSink.AddNewMetricEntries(metrics.NewEntityStateMetric(task.NodeType, key, metrics.CpuFrequency,
+				m.config.nodeCPUFrequencyMHz))
+		}
+	}
+
+	cpuSamples := m.query(ctx, NodeCPUQuery)
+	for _, sample := range cpuSamples {
+		name := sample.Labels[nodeLabel]
+		if _, exist := nodes[name]; !exist {
+			continue
+		}
+		glog.V(3).Infof("CPU usage of node %s is %.3f core", name, sample.Value)
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.NodeType, name, metrics.CPU,
+			metrics.Used, sample.Value))
+	}
+
+	memSamples := m.query(ctx, NodeMemoryQuery)
+	for _, sample := range memSamples {
+		name := sample.Labels[nodeLabel]
+		if _, exist := nodes[name]; !exist {
+			continue
+		}
+		memUsed := sample.Value / util.KilobytesToBytes
+		glog.V(3).Infof("Memory usage of node %s is %.3f KB", name, memUsed)
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.NodeType, name, metrics.Memory,
+			metrics.Used, memUsed))
+	}
+}
+
+// Get CPU and memory used of the containers, and aggregate them to applications and pods.
+func (m *PrometheusMonitor) scrapeContainers(ctx context.Context) {
+	m.genContainerMetrics(metrics.CPU, m.query(ctx, ContainerCPUQuery), 1.0)
+	m.genContainerMetrics(metrics.Memory, m.query(ctx, ContainerMemoryQuery), util.KilobytesToBytes)
+}
+
+func (m *PrometheusMonitor) genContainerMetrics(rType metrics.ResourceType, samples []Sample, divisor float64) {
+	podUsed := make(map[*api.Pod]float64)
+	for _, sample := range samples {
+		pod, exist := m.podMap[podKeyFromLabels(sample.Labels)]
+		if !exist {
+			continue
+		}
+		index := containerIndex(pod, sample.Labels[containerLabel])
+		if index < 0 {
+			glog.V(4).Infof("Cannot find container %s in pod %s", sample.Labels[containerLabel], util.PodKeyFunc(pod))
+			continue
+		}
+		used := sample.Value / divisor
+
+		//1. container Used
+		containerId := util.ContainerIdFunc(string(pod.UID), index)
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.ContainerType, containerId, rType,
+			metrics.Used, used))
+
+		//2. app Used
+		appId := util.ApplicationIdFunc(containerId)
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.ApplicationType, appId, rType,
+			metrics.Used, used))
+
+		podUsed[pod] += used
+	}
+
+	//3. pod Used
+	for pod, used := range podUsed {
+		key := util.PodKeyFunc(pod)
+		glog.V(4).Infof("%s usage of pod %s is %.3f", rType, key, used)
+		m.metricSink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.PodType, key, rType,
+			metrics.Used, used))
+	}
+}
+
+// Get transaction per second of the pods.
+func (m *PrometheusMonitor) scrapeTransactions(ctx context.Context) {
+	samples := m.query(ctx, PodTransactionQuery)
+	for _, sample := range samples {
+		pod, exist := m.podMap[podKeyFromLabels(sample.Labels)]
+		if !exist {
+			continue
+		}
+		key := util.PodKeyFunc(pod)
+		glog.V(4).Infof("Transaction count usage of pod %s is %f", key, sample.Value)
+		m.metricSink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(task.PodType, key, metrics.Transaction, metrics.Used, sample.Value),
+			metrics.NewEntityResourceMetric(task.ApplicationType, key, metrics.Transaction, metrics.Used, sample.Value),
+			metrics.NewEntityResourceMetric(task.ApplicationType, key, metrics.Transaction, metrics.Capacity,
+				k8sconntrack.DefaultTransactionCapacity),
+			metrics.NewEntityResourceMetric(task.ServiceType, key, metrics.Transaction, metrics.Used, sample.Value))
+	}
+}
+
+// Get response time of the pods, in milliseconds.
+func (m *PrometheusMonitor) scrapeResponseTime(ctx context.Context) {
+	samples := m.query(ctx, PodResponseTimeQuery)
+	for _, sample := range samples {
+		pod, exist := m.podMap[podKeyFromLabels(sample.Labels)]
+		if !exist {
+			continue
+		}
+		key := util.PodKeyFunc(pod)
+		glog.V(4).Infof("Response time of pod %s is %f ms", key, sample.Value)
+		m.metricSink.AddNewMetricEntries(
+			metrics.NewEntityResourceMetric(task.PodType, key, metrics.ResponseTime, metrics.Used, sample.Value))
+	}
+}
+
+// Run the query of the given type against the nodes of current task. Samples with invalid values are dropped.
+func (m *PrometheusMonitor) query(ctx context.Context, qType PrometheusQueryType) []Sample {
+	query, exist := m.config.queries[qType]
+	if !exist {
+		return nil
+	}
+	query = strings.Replace(query, NodeNamesPlaceholder, m.nodeNamesRegex(), -1)
+	samples, err := m.promClient.Query(ctx, query)
+	if err != nil {
+		glog.Errorf("Failed to get %s metrics from Prometheus: %v", qType, err)
+		return nil
+	}
+
+	result := make([]Sample, 0, len(samples))
+	for _, sample := range samples {
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			continue
+		}
+		result = append(result, sample)
+	}
+	return result
+}
+
+// Build a regex which only matches the names of the nodes in current task.
+// The backslashes are escaped again as the regex is embedded in a PromQL string literal.
+func (m *PrometheusMonitor) nodeNamesRegex() string {
+	names := make([]string, 0, len(m.nodeList))
+	for _, node := range m.nodeList {
+		names = append(names, strings.Replace(regexp.QuoteMeta(node.Name), `\`, `\\`, -1))
+	}
+	return strings.Join(names, "|")
+}
+
+func podKeyFromLabels(labels map[string]string) string {
+	return labels[namespaceLabel] + "/" + labels[podLabel]
+}
+
+// Find the index of the container with the given name in the pod spec; return -1 if it is not found.
+func containerIndex(pod *api.Pod, containerName string) int {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			return i
+		}
+	}
+	return -1
+}
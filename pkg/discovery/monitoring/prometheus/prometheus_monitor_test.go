@@ -0,0 +1,231 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+)
+
+const (
+	testNodeCPUQuery         = `node_cpu{node=~"$NODES"}`
+	testNodeMemoryQuery      = `node_memory{node=~"$NODES"}`
+	testContainerCPUQuery    = `container_cpu`
+	testContainerMemoryQuery = `container_memory`
+	testTransactionQuery     = `pod_transaction`
+	testResponseTimeQuery    = `pod_response_time`
+)
+
+// A Prometheus stand-in which answers the instant queries with canned vectors.
+func newFakePrometheusServer(responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != queryPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query().Get("query")
+		result, exist := responses[query]
+		if !exist {
+			fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"unknown query"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, result)
+	}))
+}
+
+func newTestNode(name string) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			UID:  types.UID(name + "-uid"),
+		},
+	}
+}
+
+func newTestPod(namespace, name, nodeName string, containers ...string) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			UID:       types.UID(name + "-uid"),
+		},
+		Spec: api.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, api.Container{Name: c})
+	}
+	return pod
+}
+
+func checkMetric(t *testing.T, sink *metrics.EntityMetricSink, eType task.DiscoveredEntityType, id string,
+	rType metrics.ResourceType, expected float64) {
+	uid := metrics.GenerateEntityResourceMetricUID(eType, id, rType, metrics.Used)
+	m, err := sink.GetMetric(uid)
+	if err != nil {
+		t.Errorf("Metric %s not found: %v", uid, err)
+		return
+	}
+	if v := m.GetValue().(float64); v != expected {
+		t.Errorf("Metric %s: expected %f, got %f", uid, expected, v)
+	}
+}
+
+func TestPrometheusMonitor(t *testing.T) {
+	node1 := newTestNode("node-1.example.com")
+	node2 := newTestNode("node-2")
+	pod1 := newTestPod("default", "pod-1", node1.Name, "app", "sidecar")
+	pod2 := newTestPod("kube-system", "pod-2", node2.Name, "app")
+	// out of scope, but part of the overhead of its node.
+	pod3 := newTestPod("monitoring", "pod-3", node1.Name, "app")
+
+	responses := map[string]string{
+		`node_cpu{node=~"node-1\\.example\\.com|node-2"}`: `
+			{"metric":{"node":"node-1.example.com"},"value":[1500000000,"1.5"]},
+			{"metric":{"node":"node-2"},"value":[1500000000,"NaN"]},
+			{"metric":{"node":"node-3"},"value":[1500000000,"3"]}`,
+		`node_memory{node=~"node-1\\.example\\.com|node-2"}`: `
+			{"metric":{"node":"node-1.example.com"},"value":[1500000000,"2048"]},
+			{"metric":{"node":"node-2"},"value":[1500000000,"4096"]}`,
+		testContainerCPUQuery: `
+			{"metric":{"namespace":"default","pod":"pod-1","container":"app"},"value":[1500000000,"0.5"]},
+			{"metric":{"namespace":"default","pod":"pod-1","container":"sidecar"},"value":[1500000000,"0.25"]},
+			{"metric":{"namespace":"default","pod":"pod-1","container":"unknown"},"value":[1500000000,"8"]},
+			{"metric":{"namespace":"default","pod":"other","container":"app"},"value":[1500000000,"8"]},
+			{"metric":{"namespace":"monitoring","pod":"pod-3","container":"app"},"value":[1500000000,"0.1"]}`,
+		testContainerMemoryQuery: `
+			{"metric":{"namespace":"kube-system","pod":"pod-2","container":"app"},"value":[1500000000,"1024"]}`,
+		testTransactionQuery: `
+			{"metric":{"namespace":"default","pod":"pod-1"},"value":[1500000000,"12"]}`,
+		testResponseTimeQuery: `
+			{"metric":{"namespace":"default","pod":"pod-1"},"value":[1500000000,"35.5"]}`,
+	}
+	server := newFakePrometheusServer(responses)
+	defer server.Close()
+
+	config := NewPrometheusMonitorConfig(server.URL).
+		WithQuery(NodeCPUQuery, testNodeCPUQuery).
+		WithQuery(NodeMemoryQuery, testNodeMemoryQuery).
+		WithQuery(ContainerCPUQuery, testContainerCPUQuery).
+		WithQuery(ContainerMemoryQuery, testContainerMemoryQuery).
+		WithQuery(PodTransactionQuery, testTransactionQuery).
+		WithQuery(PodResponseTimeQuery, testResponseTimeQuery)
+	monitor, err := NewPrometheusMonitor(config)
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus monitor: %v", err)
+	}
+
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node1, node2}).WithPods([]*api.Pod{pod1, pod2}).
+		WithNodePods([]*api.Pod{pod1, pod2, pod3}))
+	sink := monitor.Do(context.Background())
+
+	// nodes
+	checkMetric(t, sink, task.NodeType, node1.Name, metrics.CPU, 1.5)
+	checkMetric(t, sink, task.NodeType, node1.Name, metrics.Memory, 2)
+	checkMetric(t, sink, task.NodeType, node2.Name, metrics.Memory, 4)
+	if _, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(task.NodeType, node2.Name, metrics.CPU,
+		metrics.Used)); err == nil {
+		t.Errorf("NaN sample should be dropped")
+	}
+	if _, err := sink.GetMetric(metrics.GenerateEntityResourceMetricUID(task.NodeType, "node-3", metrics.CPU,
+		metrics.Used)); err == nil {
+		t.Errorf("Node which is not in the task should be ignored")
+	}
+
+	// containers, applications and pods
+	container0 := util.ContainerIdFunc(string(pod1.UID), 0)
+	container1 := util.ContainerIdFunc(string(pod1.UID), 1)
+	checkMetric(t, sink, task.ContainerType, container0, metrics.CPU, 0.5)
+	checkMetric(t, sink, task.ContainerType, container1, metrics.CPU, 0.25)
+	checkMetric(t, sink, task.ApplicationType, util.ApplicationIdFunc(container0), metrics.CPU, 0.5)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod1), metrics.CPU, 0.75)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod2), metrics.Memory, 1)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod3), metrics.CPU, 0.1)
+
+	// transaction and response time
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod1), metrics.Transaction, 12)
+	checkMetric(t, sink, task.ServiceType, util.PodKeyFunc(pod1), metrics.Transaction, 12)
+	checkMetric(t, sink, task.PodType, util.PodKeyFunc(pod1), metrics.ResponseTime, 35.5)
+}
+
+func TestPrometheusMonitorDisabledQuery(t *testing.T) {
+	node := newTestNode("node-1")
+	responses := map[string]string{
+		`node_cpu{node=~"node-1"}`: `{"metric":{"node":"node-1"},"value":[1500000000,"1"]}`,
+	}
+	server := newFakePrometheusServer(responses)
+	defer server.Close()
+
+	config := NewPrometheusMonitorConfig(server.URL).WithQuery(NodeCPUQuery, testNodeCPUQuery)
+	for _, qType := range []PrometheusQueryType{NodeMemoryQuery, ContainerCPUQuery, ContainerMemoryQuery} {
+		config.WithQuery(qType, "")
+	}
+	monitor, err := NewPrometheusMonitor(config)
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus monitor: %v", err)
+	}
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}).WithPods([]*api.Pod{}))
+	sink := monitor.Do(context.Background())
+
+	checkMetric(t, sink, task.NodeType, node.Name, metrics.CPU, 1)
+	if _, err := sink.GetMetric(metrics.GenerateEntityStateMetricUID(task.NodeType, node.Name,
+		metrics.CpuFrequency)); err == nil {
+		t.Errorf("CPU frequency should not be assigned unless configured")
+	}
+}
+
+func TestPrometheusMonitorNodeCPUFrequency(t *testing.T) {
+	node := newTestNode("node-1")
+	server := newFakePrometheusServer(map[string]string{})
+	defer server.Close()
+
+	config := NewPrometheusMonitorConfig(server.URL).WithNodeCPUFrequency(2600)
+	monitor, err := NewPrometheusMonitor(config)
+	if err != nil {
+		t.Fatalf("Failed to create Prometheus monitor: %v", err)
+	}
+	monitor.ReceiveTask(task.NewTask().WithNodes([]*api.Node{node}).WithPods([]*api.Pod{}))
+	sink := monitor.Do(context.Background())
+
+	uid := metrics.GenerateEntityStateMetricUID(task.NodeType, node.Name, metrics.CpuFrequency)
+	m, err := sink.GetMetric(uid)
+	if err != nil {
+		t.Fatalf("Metric %s not found: %v", uid, err)
+	}
+	if v := m.GetValue().(float64); v != 2600 {
+		t.Errorf("Metric %s: expected 2600, got %f", uid, v)
+	}
+}
+
+func TestPrometheusClientQueryError(t *testing.T) {
+	server := newFakePrometheusServer(map[string]string{})
+	defer server.Close()
+
+	client := NewPrometheusClient(server.URL, defaultPrometheusQueryTimeout)
+	_, err := client.Query(context.Background(), "up")
+	if err == nil || !strings.Contains(err.Error(), "unknown query") {
+		t.Errorf("Expected query error, got %v", err)
+	}
+}
+
+func TestPrometheusMonitorConfigHasTransactionQueries(t *testing.T) {
+	config := NewPrometheusMonitorConfig("http://prometheus:9090")
+	if config.HasTransactionQueries() {
+		t.Errorf("The default queries should not include transaction queries")
+	}
+	config.WithQuery(PodResponseTimeQuery, `sum by (namespace, pod) (http_request_duration_ms)`)
+	if !config.HasTransactionQueries() {
+		t.Errorf("A response time query should be a transaction query")
+	}
+}
@@ -15,6 +15,7 @@ const (
 	ContainerType   DiscoveredEntityType = "Container"
 	ApplicationType DiscoveredEntityType = "Application"
 	ServiceType     DiscoveredEntityType = "Service"
+	VolumeType      DiscoveredEntityType = "Volume"
 
 	TaskSucceeded TaskResultState = "Succeeded"
 	TaskFailed    TaskResultState = "Failed"
@@ -27,6 +28,12 @@ type Task struct {
 
 	nodeList []*api.Node
 	podList  []*api.Pod
+
+	// All the pods running on the nodes, including the pods out of scope.
+	nodePodList []*api.Pod
+
+	// The persistent volumes bound to the claims, indexed by "namespace/claim name".
+	persistentVolumes map[string]*api.PersistentVolume
 }
 
 // Worker task is consisted of a list of nodes the worker must discover.
@@ -48,6 +55,18 @@ func (t *Task) WithPods(podList []*api.Pod) *Task {
 	return t
 }
 
+// Assign all the pods running on the nodes, including the pods out of scope, to the task.
+func (t *Task) WithNodePods(nodePodList []*api.Pod) *Task {
+	t.nodePodList = nodePodList
+	return t
+}
+
+// Assign the persistent volumes, which may be mounted by the pods, to the task.
+func (t *Task) WithPersistentVolumes(volumesByClaim map[string]*api.PersistentVolume) *Task {
+	t.persistentVolumes = volumesByClaim
+	return t
+}
+
 // Get node list from the task.
 func (t *Task) NodeList() []*api.Node {
 	return t.nodeList
@@ -58,6 +77,20 @@ func (t *Task) PodList() []*api.Pod {
 	return t.podList
 }
 
+// Get all the pods running on the nodes, including the pods out of scope, from the task.
+// They are the pods in scope if the task was not given the pods of the nodes.
+func (t *Task) NodePodList() []*api.Pod {
+	if t.nodePodList == nil {
+		return t.podList
+	}
+	return t.nodePodList
+}
+
+// Get the persistent volumes, indexed by "namespace/claim name", from the task.
+func (t *Task) PersistentVolumes() map[string]*api.PersistentVolume {
+	return t.persistentVolumes
+}
+
 type TaskResultState string
 
 // A TaskResult contains a state, indicate whether the task is finished successfully; a err if there is any; a list of
@@ -68,6 +101,13 @@ type TaskResult struct {
 	err      error
 
 	content []*proto.EntityDTO
+
+	// The errors which didn't stop the task but left some entities out, e.g. a monitoring source timing out.
+	errorDTOs []*proto.ErrorDTO
+
+	// The CPU frequency (MHz) of the nodes of the task, used to convert CPU in cores to MHz after the discovery.
+	// key: node name; value: CPU frequency.
+	nodeCPUFrequencies map[string]float64
 }
 
 func NewTaskResult(workerID string, state TaskResultState) *TaskResult {
@@ -98,3 +138,43 @@ func (r *TaskResult) WithContent(entityDTOs []*proto.EntityDTO) *TaskResult {
 	r.content = entityDTOs
 	return r
 }
+
+func (r *TaskResult) WorkerID() string {
+	return r.workerID
+}
+
+func (r *TaskResult) ErrorDTOs() []*proto.ErrorDTO {
+	return r.errorDTOs
+}
+
+func (r *TaskResult) WithErrorDTOs(errorDTOs ...*proto.ErrorDTO) *TaskResult {
+	r.errorDTOs = append(r.errorDTOs, errorDTOs...)
+	return r
+}
+
+func (r *TaskResult) NodeCPUFrequencies() map[string]float64 {
+	return r.nodeCPUFrequencies
+}
+
+func (r *TaskResult) WithNodeCPUFrequencies(frequencies map[string]float64) *TaskResult {
+	r.nodeCPUFrequencies = frequencies
+	return r
+}
+
+// Build an ErrorDTO reported to the server along with the discovery result.
+func NewErrorDTO(severity proto.ErrorDTO_ErrorSeverity, description string) *proto.ErrorDTO {
+	return &proto.ErrorDTO{
+		Severity:    &severity,
+		Description: &description,
+	}
+}
+
+// Build an ErrorDTO about the given entity, e.g. a node which is not discovered.
+func NewEntityErrorDTO(severity proto.ErrorDTO_ErrorSeverity, description string, entityType proto.EntityDTO_EntityType,
+	entityID string) *proto.ErrorDTO {
+	errorDTO := NewErrorDTO(severity, description)
+	entityTypeName := entityType.String()
+	errorDTO.EntityType = &entityTypeName
+	errorDTO.EntityUuid = &entityID
+	return errorDTO
+}
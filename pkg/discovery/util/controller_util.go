@@ -0,0 +1,129 @@
+package util
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	apps "k8s.io/client-go/pkg/apis/apps/v1beta1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+// WorkloadController is the common view of the controllers which create and manage pods:
+// Deployments, ReplicaSets, ReplicationControllers, StatefulSets and DaemonSets.
+type WorkloadController struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       string
+
+	// The desired and the ready number of pods. For a DaemonSet, the desired number is the number of nodes
+	// which should run the daemon pod.
+	Replicas      int32
+	ReadyReplicas int32
+
+	// The reference to the object which controls this controller, e.g. the Deployment of a ReplicaSet.
+	// Nil if the controller is not controlled by any other object.
+	Owner *metav1.OwnerReference
+
+	Template *api.PodTemplateSpec
+}
+
+func newWorkloadController(kind string, meta *metav1.ObjectMeta, replicas *int32, readyReplicas int32,
+	template *api.PodTemplateSpec) *WorkloadController {
+	controller := &WorkloadController{
+		Kind:          kind,
+		Namespace:     meta.Namespace,
+		Name:          meta.Name,
+		UID:           string(meta.UID),
+		ReadyReplicas: readyReplicas,
+		Owner:         GetControllerOwnerReference(meta.OwnerReferences),
+		Template:      template,
+	}
+	// Kubernetes defaults the number of replicas to 1 when it is not set.
+	controller.Replicas = 1
+	if replicas != nil {
+		controller.Replicas = *replicas
+	}
+	return controller
+}
+
+func NewDeploymentController(deploy *extensions.Deployment) *WorkloadController {
+	return newWorkloadController(Kind_Deployment, &deploy.ObjectMeta, deploy.Spec.Replicas,
+		deploy.Status.ReadyReplicas, &deploy.Spec.Template)
+}
+
+func NewReplicaSetController(rs *extensions.ReplicaSet) *WorkloadController {
+	return newWorkloadController(Kind_ReplicaSet, &rs.ObjectMeta, rs.Spec.Replicas,
+		rs.Status.ReadyReplicas, &rs.Spec.Template)
+}
+
+func NewReplicationControllerController(rc *api.ReplicationController) *WorkloadController {
+	return newWorkloadController(Kind_ReplicationController, &rc.ObjectMeta, rc.Spec.Replicas,
+		rc.Status.ReadyReplicas, rc.Spec.Template)
+}
+
+func NewStatefulSetController(ss *apps.StatefulSet) *WorkloadController {
+	return newWorkloadController(Kind_StatefulSet, &ss.ObjectMeta, ss.Spec.Replicas,
+		ss.Status.ReadyReplicas, &ss.Spec.Template)
+}
+
+func NewDaemonSetController(ds *extensions.DaemonSet) *WorkloadController {
+	desired := ds.Status.DesiredNumberScheduled
+	return newWorkloadController(Kind_DaemonSet, &ds.ObjectMeta, &desired,
+		ds.Status.NumberReady, &ds.Spec.Template)
+}
+
+// The key of a controller is unique in the cluster, and can be built from an owner reference of the same namespace.
+func (c *WorkloadController) Key() string {
+	return WorkloadControllerKey(c.Kind, c.Namespace, c.Name)
+}
+
+func (c *WorkloadController) String() string {
+	return fmt.Sprintf("%s %s/%s", c.Kind, c.Namespace, c.Name)
+}
+
+func WorkloadControllerKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// Get the owner reference which points to the managing controller; nil if there is none.
+func GetControllerOwnerReference(owners []metav1.OwnerReference) *metav1.OwnerReference {
+	for i := range owners {
+		owner := &owners[i]
+		if owner.Controller != nil && *owner.Controller && owner.Kind != "" && owner.Name != "" {
+			return owner
+		}
+	}
+	return nil
+}
+
+// Get the kind and name of the controller of the pod, from its owner references, or from the created-by annotation
+// set by older versions of Kubernetes. Empty if the pod has no controller.
+func GetPodControllerKindAndName(pod *api.Pod) (string, string, error) {
+	if owner := GetControllerOwnerReference(pod.OwnerReferences); owner != nil {
+		return owner.Kind, owner.Name, nil
+	}
+	ref, err := FindParentReferenceObject(pod)
+	if err != nil || ref == nil {
+		return "", "", err
+	}
+	return ref.Kind, ref.Name, nil
+}
+
+// Get the total CPU and memory requests and limits of the containers of the pod template.
+// CPU is in millicores and memory is in Kb.
+func GetTemplateResources(template *api.PodTemplateSpec) (cpuRequest, cpuLimit, memRequest, memLimit float64) {
+	if template == nil {
+		return
+	}
+	for _, container := range template.Spec.Containers {
+		cpu, mem := GetCpuAndMemoryValues(container.Resources.Requests)
+		cpuRequest += cpu * MilliToUnit
+		memRequest += mem
+		cpu, mem = GetCpuAndMemoryValues(container.Resources.Limits)
+		cpuLimit += cpu * MilliToUnit
+		memLimit += mem
+	}
+	return
+}
@@ -15,6 +15,8 @@ const (
 	Kind_ReplicationController string = "ReplicationController"
 	Kind_ReplicaSet            string = "ReplicaSet"
 	Kind_Job                   string = "Job"
+	Kind_Deployment            string = "Deployment"
+	Kind_StatefulSet           string = "StatefulSet"
 )
 
 // Returns a bool indicates whether the given pod should be monitored.
@@ -40,9 +42,9 @@ func isMirrorPod(pod *api.Pod) bool {
 	return false
 }
 
-// Check is a pod is created by the given type of entity.
+// Check is a pod is created by the given type of entity, by its owner references or created-by annotation.
 func isPodCreatedBy(pod *api.Pod, kind string) bool {
-	parentKind, err := findParentObjectKind(pod)
+	parentKind, _, err := GetPodControllerKindAndName(pod)
 	if err != nil {
 		glog.Errorf("%++v", err)
 	}
@@ -65,20 +67,6 @@ func FindParentReferenceObject(pod *api.Pod) (*api.ObjectReference, error) {
 	return GetCreatedByRef(createdByRef)
 }
 
-// Find the kind of the parent object that creates the pod, from the annotations of pod.
-func findParentObjectKind(pod *api.Pod) (string, error) {
-	parentObject, err := FindParentReferenceObject(pod)
-	if err != nil {
-		return "", err
-	}
-	if parentObject == nil {
-		return "", nil
-	}
-	kind := parentObject.Kind
-	glog.V(4).Infof("The kind of parent object of Pod %s/%s is %s", pod.Namespace, pod.Name, kind)
-	return kind, nil
-}
-
 func GetCreatedByRef(refData string) (*api.ObjectReference, error) {
 	var ref api.SerializedReference
 	if err := DecodeJSON(&ref, refData); err != nil {
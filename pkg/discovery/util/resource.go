@@ -3,10 +3,16 @@ package util
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	api "k8s.io/client-go/pkg/api/v1"
 )
 
+const (
+	// The resource name of local ephemeral storage, which is not defined by the vendored API yet.
+	ResourceEphemeralStorage api.ResourceName = "ephemeral-storage"
+)
+
 func GetNodeResourceRequestConsumption(pods []*api.Pod) (nodeCpuProvisionedUsedCore, nodeMemoryProvisionedUsedKiloBytes float64, err error) {
 	if pods == nil {
 		err = errors.New("pod list passed in is nil")
@@ -56,6 +62,28 @@ func GetPodResourceRequest(pod *api.Pod) (cpuRequest float64, memRequest float64
 	return
 }
 
+// Get the resources of the node available to the pods. They are the allocatable resources, which exclude the
+// resources reserved for the kubelet, the system daemons and the eviction threshold, unless useNodeCapacity is set,
+// or the node does not report them.
+func GetNodeCapacity(node *api.Node, useNodeCapacity bool) api.ResourceList {
+	if useNodeCapacity || len(node.Status.Allocatable) == 0 {
+		return node.Status.Capacity
+	}
+	return node.Status.Allocatable
+}
+
+// Get the CPU, in cores, and memory, in KB, of the node which are not allocatable to the pods.
+func GetNodeReservedResources(node *api.Node) (cpuReservedCore, memoryReservedKiloBytes float64) {
+	if len(node.Status.Allocatable) == 0 {
+		return
+	}
+	cpuCapacity, memoryCapacity := GetCpuAndMemoryValues(node.Status.Capacity)
+	cpuAllocatable, memoryAllocatable := GetCpuAndMemoryValues(node.Status.Allocatable)
+	cpuReservedCore = math.Max(cpuCapacity-cpuAllocatable, 0)
+	memoryReservedKiloBytes = math.Max(memoryCapacity-memoryAllocatable, 0)
+	return
+}
+
 // CPU returned is in core; Memory is in Kb
 func GetCpuAndMemoryValues(resource api.ResourceList) (cpuCapacityCore, memoryCapacityKiloBytes float64) {
 	ctnMemoryCapacityBytes := resource.Memory().Value()
@@ -65,3 +93,15 @@ func GetCpuAndMemoryValues(resource api.ResourceList) (cpuCapacityCore, memoryCa
 
 	return
 }
+
+// Ephemeral storage returned is in Mb; the alpha scratch storage resource is used if ephemeral-storage is not set.
+func GetEphemeralStorageValue(resource api.ResourceList) (storageMegaBytes float64, exist bool) {
+	quantity, exist := resource[ResourceEphemeralStorage]
+	if !exist {
+		quantity, exist = resource[api.ResourceStorageScratch]
+	}
+	if !exist {
+		return 0, false
+	}
+	return float64(quantity.Value()) / MegabytesToBytes, true
+}
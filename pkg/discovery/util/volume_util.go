@@ -0,0 +1,162 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	api "k8s.io/client-go/pkg/api/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+)
+
+const (
+	// The annotation of the storage class of a persistent volume, used before StorageClassName was added to the spec.
+	betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+
+	// The zone label of a volume available in several zones joins the zones with this separator.
+	multiZoneSeparator = "__"
+)
+
+// PodVolume is a volume of a pod, which mounts a persistent volume through a claim.
+type PodVolume struct {
+	// The name of the volume in the pod spec.
+	VolumeName string
+	// The name of the claim, in the namespace of the pod.
+	ClaimName string
+	// The persistent volume bound to the claim.
+	PersistentVolume *api.PersistentVolume
+}
+
+// PodVolumeKeyFunc and PodVolumeStatsKeyFunc should return the same value.
+func PodVolumeKeyFunc(pod *api.Pod, volumeName string) string {
+	return PodKeyFunc(pod) + "/" + volumeName
+}
+
+func PodVolumeStatsKeyFunc(podStat *stats.PodStats, volumeStat *stats.VolumeStats) string {
+	return PodStatsKeyFunc(podStat) + "/" + volumeStat.Name
+}
+
+// Index the persistent volumes bound to the given claims by "namespace/claim name".
+func GetPersistentVolumesByClaim(pvcs []*api.PersistentVolumeClaim,
+	pvs []*api.PersistentVolume) map[string]*api.PersistentVolume {
+	pvsByName := make(map[string]*api.PersistentVolume)
+	for _, pv := range pvs {
+		pvsByName[pv.Name] = pv
+	}
+	volumesByClaim := make(map[string]*api.PersistentVolume)
+	for _, pvc := range pvcs {
+		if pv, exist := pvsByName[pvc.Spec.VolumeName]; exist {
+			volumesByClaim[pvc.Namespace+"/"+pvc.Name] = pv
+		}
+	}
+	return volumesByClaim
+}
+
+// Get the volumes of the pod, which mount the bound persistent volumes.
+// The given persistent volumes are indexed by "namespace/claim name".
+func GetPodPersistentVolumes(pod *api.Pod, volumesByClaim map[string]*api.PersistentVolume) []*PodVolume {
+	var result []*PodVolume
+	claims := make(map[string]struct{})
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		claimName := volume.PersistentVolumeClaim.ClaimName
+		pv, exist := volumesByClaim[pod.Namespace+"/"+claimName]
+		if !exist {
+			continue
+		}
+		// The same claim may be mounted by several volumes of the pod.
+		if _, found := claims[claimName]; found {
+			continue
+		}
+		claims[claimName] = struct{}{}
+		result = append(result, &PodVolume{
+			VolumeName:       volume.Name,
+			ClaimName:        claimName,
+			PersistentVolume: pv,
+		})
+	}
+	return result
+}
+
+// Get the storage class of the persistent volume, or empty if it has none.
+func GetPersistentVolumeClass(pv *api.PersistentVolume) string {
+	if class, exist := pv.Annotations[betaStorageClassAnnotation]; exist {
+		return class
+	}
+	return pv.Spec.StorageClassName
+}
+
+// Get the capacity of the persistent volume in MB.
+func GetPersistentVolumeCapacity(pv *api.PersistentVolume) float64 {
+	capacity, exist := pv.Spec.Capacity[api.ResourceStorage]
+	if !exist {
+		return 0
+	}
+	return float64(capacity.Value()) / MegabytesToBytes
+}
+
+// Get the zone and region of the persistent volume by label key, i.e. the topology domains that the pods mounting
+// the volume must stay in. The value of a volume in several zones is the set of its zones, sorted and joined with
+// the multi-zone separator; use GetTopologyDomains to split it.
+func GetPersistentVolumeTopology(pv *api.PersistentVolume) map[string]string {
+	domains := make(map[string]string)
+	for _, key := range []string{kubeletapis.LabelZoneFailureDomain, kubeletapis.LabelZoneRegion} {
+		value, exist := pv.Labels[key]
+		if !exist || value == "" {
+			continue
+		}
+		domains[key] = JoinTopologyDomains(GetTopologyDomains(value))
+	}
+	return domains
+}
+
+// Split the topology label value of a volume into the domains it is available in, e.g. the zones of a volume
+// in several zones.
+func GetTopologyDomains(value string) []string {
+	var domains []string
+	for _, domain := range strings.Split(value, multiZoneSeparator) {
+		if domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// Join the topology domains into the label value of a volume available in all of them. The domains are sorted and
+// deduplicated, so that the same set of domains always gives the same value.
+func JoinTopologyDomains(domains []string) string {
+	set := make(map[string]struct{})
+	var result []string
+	for _, domain := range domains {
+		if _, found := set[domain]; found {
+			continue
+		}
+		set[domain] = struct{}{}
+		result = append(result, domain)
+	}
+	sort.Strings(result)
+	return strings.Join(result, multiZoneSeparator)
+}
+
+// Check whether the persistent volume can be mounted on the node, i.e. the node is in one of the zones and regions
+// of the volume. The volumes without these labels can be mounted anywhere.
+func CheckPersistentVolumeTopology(pv *api.PersistentVolume, node *api.Node) error {
+	for key, value := range GetPersistentVolumeTopology(pv) {
+		nodeValue := node.Labels[key]
+		matched := false
+		for _, domain := range GetTopologyDomains(value) {
+			if domain == nodeValue {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("persistent volume %s is in %s=%s, but node %s is in %q", pv.Name, key, value,
+				node.Name, nodeValue)
+		}
+	}
+	return nil
+}
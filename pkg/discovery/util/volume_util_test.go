@@ -0,0 +1,79 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/client-go/pkg/api/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+)
+
+func TestGetPodPersistentVolumes(t *testing.T) {
+	pv := &api.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv-1"}}
+	claimVolume := func(name, claimName string) api.Volume {
+		return api.Volume{
+			Name: name,
+			VolumeSource: api.VolumeSource{
+				PersistentVolumeClaim: &api.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			},
+		}
+	}
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db-0"},
+		Spec: api.PodSpec{
+			Volumes: []api.Volume{
+				claimVolume("data", "data-db-0"),
+				claimVolume("data-again", "data-db-0"),
+				claimVolume("unbound", "pending"),
+				{Name: "tmp", VolumeSource: api.VolumeSource{EmptyDir: &api.EmptyDirVolumeSource{}}},
+			},
+		},
+	}
+
+	podVolumes := GetPodPersistentVolumes(pod, map[string]*api.PersistentVolume{"default/data-db-0": pv})
+	if len(podVolumes) != 1 {
+		t.Fatalf("Expected 1 persistent volume, got %d", len(podVolumes))
+	}
+	if podVolumes[0].VolumeName != "data" || podVolumes[0].ClaimName != "data-db-0" || podVolumes[0].PersistentVolume != pv {
+		t.Errorf("Unexpected pod volume %+v", podVolumes[0])
+	}
+}
+
+func TestGetPersistentVolumeTopology(t *testing.T) {
+	tests := []struct {
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{nil, map[string]string{}},
+		{
+			map[string]string{kubeletapis.LabelZoneFailureDomain: "us-east-1a", kubeletapis.LabelZoneRegion: "us-east-1"},
+			map[string]string{kubeletapis.LabelZoneFailureDomain: "us-east-1a", kubeletapis.LabelZoneRegion: "us-east-1"},
+		},
+		{
+			map[string]string{kubeletapis.LabelZoneFailureDomain: "us-east-1b__us-east-1a", kubeletapis.LabelZoneRegion: "us-east-1"},
+			map[string]string{kubeletapis.LabelZoneFailureDomain: "us-east-1a__us-east-1b", kubeletapis.LabelZoneRegion: "us-east-1"},
+		},
+	}
+	for _, test := range tests {
+		pv := &api.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv", Labels: test.labels}}
+		domains := GetPersistentVolumeTopology(pv)
+		if !reflect.DeepEqual(domains, test.expected) {
+			t.Errorf("Volume with labels %v: expected %v, got %v", test.labels, test.expected, domains)
+		}
+	}
+}
+
+func TestCheckPersistentVolumeTopology(t *testing.T) {
+	pv := &api.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv",
+		Labels: map[string]string{kubeletapis.LabelZoneFailureDomain: "us-east-1a__us-east-1b"}}}
+	newNode := func(zone string) *api.Node {
+		return &api.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-" + zone,
+			Labels: map[string]string{kubeletapis.LabelZoneFailureDomain: zone}}}
+	}
+	for zone, allowed := range map[string]bool{"us-east-1a": true, "us-east-1b": true, "us-east-1c": false} {
+		if err := CheckPersistentVolumeTopology(pv, newNode(zone)); (err == nil) != allowed {
+			t.Errorf("Node in zone %s: expected allowed %t, got error %v", zone, allowed, err)
+		}
+	}
+}
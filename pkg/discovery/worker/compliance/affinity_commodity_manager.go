@@ -56,6 +56,18 @@ func (acm *AffinityCommodityManager) GetAccessCommoditiesForPodAffinityAntiAffin
 	return accessCommsSold, accessCommsBought, nil
 }
 
+// Get the access commodities of a node taint. They are sold by the nodes without the taint, and bought by the pods
+// which don't tolerate it.
+func (acm *AffinityCommodityManager) GetAccessCommoditiesForTaint(taint api.Taint) (*proto.CommodityDTO, *proto.CommodityDTO, error) {
+	return acm.getCommoditySoldAndBought(taint.ToString())
+}
+
+// Get the access commodities of a topology domain, e.g. a zone, identified by the node label and its value.
+// They are sold by the nodes in the domain, and bought by the pods which must stay in it.
+func (acm *AffinityCommodityManager) GetAccessCommoditiesForTopology(labelKey, labelValue string) (*proto.CommodityDTO, *proto.CommodityDTO, error) {
+	return acm.getCommoditySoldAndBought(labelKey + "=" + labelValue)
+}
+
 func (acm *AffinityCommodityManager) getCommoditySoldAndBought(termString string) (*proto.CommodityDTO, *proto.CommodityDTO, error) {
 	key, err := generateKey(termString)
 	if err != nil {
@@ -6,6 +6,10 @@ import (
 	"errors"
 	"fmt"
 
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+
 	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
@@ -137,6 +141,15 @@ func (cp *ComplianceProcessor) AddCommoditiesBought(entityDTO *proto.EntityDTO,
 }
 
 // check if a commodity has already been sold by the entity.
+// Get the node provider of a pod. A pending pod has no node: it buys the commodities of the node it is to be placed on
+// as an unplaced entity.
+func getPodNodeProvider(node *api.Node) *sdkbuilder.ProviderDTO {
+	if node == nil {
+		return sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, dtofactory.UnplacedProviderID)
+	}
+	return sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID))
+}
+
 func hasCommoditySold(entityDTO *proto.EntityDTO, commDTO *proto.CommodityDTO) bool {
 	commoditiesSold := entityDTO.GetCommoditiesSold()
 	return hasCommodity(commoditiesSold, commDTO)
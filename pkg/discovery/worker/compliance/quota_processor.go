@@ -0,0 +1,97 @@
+package compliance
+
+import (
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+// quotaProcessorConfig defines necessary configuration for build a quota processor.
+type quotaProcessorConfig struct {
+	// define how quotaProcessor accesses Kubernetes cluster.
+	k8sClusterScraper *cluster.ClusterScraper
+}
+
+func NewQuotaProcessorConfig(k8sClusterScraper *cluster.ClusterScraper) *quotaProcessorConfig {
+	return &quotaProcessorConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+// Quota processor creates an entityDTO for each namespace with ResourceQuotas, and makes the pods in the namespace
+// buy the quota commodities from it.
+type QuotaProcessor struct {
+	*ComplianceProcessor
+
+	quotaDTOBuilder *dtofactory.QuotaEntityDTOBuilder
+
+	quotas []*api.ResourceQuota
+	pods   []*api.Pod
+}
+
+func NewQuotaProcessor(config *quotaProcessorConfig) (*QuotaProcessor, error) {
+	clusterID, err := config.k8sClusterScraper.GetKubernetesServiceID()
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := config.k8sClusterScraper.GetAllResourceQuotas()
+	if err != nil {
+		return nil, err
+	}
+	allPods, err := config.k8sClusterScraper.GetAllPods()
+	if err != nil {
+		return nil, err
+	}
+	return &QuotaProcessor{
+		ComplianceProcessor: NewComplianceProcessor(),
+		quotaDTOBuilder:     dtofactory.NewQuotaEntityDTOBuilder(clusterID),
+
+		quotas: quotas,
+		pods:   allPods,
+	}, nil
+}
+
+// Add the quota entityDTOs, and the quota commodities bought by the pods, to the given entityDTOs.
+func (qp *QuotaProcessor) ProcessQuotas(entityDTOs []*proto.EntityDTO) []*proto.EntityDTO {
+	quotaDTOs := qp.quotaDTOBuilder.BuildEntityDTOs(qp.quotas)
+	if len(quotaDTOs) == 0 {
+		return entityDTOs
+	}
+
+	qp.GroupEntityDTOs(entityDTOs)
+	for _, pod := range qp.pods {
+		quotaDTO, exist := quotaDTOs[pod.Namespace]
+		if !exist {
+			continue
+		}
+		podEntityDTO, err := qp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, string(pod.UID))
+		if err != nil {
+			// pods which are neither running nor pending are not discovered.
+			glog.V(4).Infof("Cannot find the entityDTO: %s", err)
+			continue
+		}
+		commoditiesBought, err := qp.quotaDTOBuilder.GetPodCommoditiesBought(pod, quotaDTO)
+		if err != nil {
+			glog.Errorf("Failed to build quota commodities bought by %s: %s", util.GetPodClusterID(pod), err)
+			continue
+		}
+		provider := sdkbuilder.CreateProvider(proto.EntityDTO_VIRTUAL_DATACENTER, quotaDTO.GetId())
+		if err := qp.AddCommoditiesBought(podEntityDTO, provider, commoditiesBought...); err != nil {
+			glog.Errorf("Failed to add quota commodityDTOs to %s: %s", util.GetPodClusterID(pod), err)
+		}
+	}
+
+	result := qp.GetAllEntityDTOs()
+	for _, quotaDTO := range quotaDTOs {
+		result = append(result, quotaDTO)
+	}
+	glog.V(2).Infof("Built %d quota entityDTOs.", len(quotaDTOs))
+	return result
+}
@@ -0,0 +1,126 @@
+package compliance
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newTestQuota(namespace, name string, hard, used api.ResourceList) *api.ResourceQuota {
+	return &api.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     api.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestProcessQuotas(t *testing.T) {
+	quotas := []*api.ResourceQuota{
+		newTestQuota("team-a", "compute",
+			api.ResourceList{
+				api.ResourceLimitsCPU:    resource.MustParse("4"),
+				api.ResourceRequestsCPU:  resource.MustParse("2"),
+				api.ResourceLimitsMemory: resource.MustParse("8Gi"),
+			},
+			api.ResourceList{
+				api.ResourceLimitsCPU:    resource.MustParse("1"),
+				api.ResourceRequestsCPU:  resource.MustParse("500m"),
+				api.ResourceLimitsMemory: resource.MustParse("1Gi"),
+			}),
+		// less headroom of CPU limits than the quota above.
+		newTestQuota("team-a", "cpu",
+			api.ResourceList{api.ResourceLimitsCPU: resource.MustParse("3")},
+			api.ResourceList{api.ResourceLimitsCPU: resource.MustParse("1")}),
+		// quota of object counts only.
+		newTestQuota("team-b", "objects",
+			api.ResourceList{api.ResourcePods: resource.MustParse("10")}, nil),
+	}
+
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "pod-1", UID: types.UID("pod-1")},
+		Spec: api.PodSpec{
+			NodeName: "node-1",
+			Containers: []api.Container{{
+				Resources: api.ResourceRequirements{
+					Limits: api.ResourceList{
+						api.ResourceCPU:    resource.MustParse("1"),
+						api.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Requests: api.ResourceList{api.ResourceCPU: resource.MustParse("500m")},
+				},
+			}},
+		},
+	}
+	podDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(pod.UID)).Create()
+	if err != nil {
+		t.Fatalf("Failed to build pod entityDTO: %v", err)
+	}
+
+	qp := &QuotaProcessor{
+		ComplianceProcessor: NewComplianceProcessor(),
+		quotaDTOBuilder:     dtofactory.NewQuotaEntityDTOBuilder("cluster"),
+		quotas:              quotas,
+		pods:                []*api.Pod{pod},
+	}
+	entityDTOs := qp.ProcessQuotas([]*proto.EntityDTO{podDTO})
+
+	var quotaDTOs []*proto.EntityDTO
+	for _, e := range entityDTOs {
+		if e.GetEntityType() == proto.EntityDTO_VIRTUAL_DATACENTER {
+			quotaDTOs = append(quotaDTOs, e)
+		}
+	}
+	if len(quotaDTOs) != 1 {
+		t.Fatalf("Expected 1 quota entityDTO, got %d", len(quotaDTOs))
+	}
+	quotaDTO := quotaDTOs[0]
+
+	// CPU is in cores.
+	expectedSold := map[proto.CommodityDTO_CommodityType][2]float64{
+		proto.CommodityDTO_CPU_ALLOCATION:  {3, 1},
+		proto.CommodityDTO_CPU_PROVISIONED: {2, 0.5},
+		proto.CommodityDTO_MEM_ALLOCATION:  {8 * 1024 * 1024, 1024 * 1024},
+	}
+	if len(quotaDTO.GetCommoditiesSold()) != len(expectedSold) {
+		t.Errorf("Expected %d commodities sold by quota, got %d", len(expectedSold), len(quotaDTO.GetCommoditiesSold()))
+	}
+	for _, comm := range quotaDTO.GetCommoditiesSold() {
+		expected := expectedSold[comm.GetCommodityType()]
+		if comm.GetCapacity() != expected[0] || comm.GetUsed() != expected[1] || comm.GetKey() != quotaDTO.GetId() {
+			t.Errorf("Unexpected %s sold by quota: capacity %f, used %f, key %s", comm.GetCommodityType(),
+				comm.GetCapacity(), comm.GetUsed(), comm.GetKey())
+		}
+	}
+
+	expectedBought := map[proto.CommodityDTO_CommodityType]float64{
+		proto.CommodityDTO_CPU_ALLOCATION:  1,
+		proto.CommodityDTO_CPU_PROVISIONED: 0.5,
+		proto.CommodityDTO_MEM_ALLOCATION:  1024 * 1024,
+	}
+	found := false
+	for _, bought := range podDTO.GetCommoditiesBought() {
+		if bought.GetProviderId() != quotaDTO.GetId() {
+			continue
+		}
+		found = true
+		if len(bought.GetBought()) != len(expectedBought) {
+			t.Errorf("Expected %d commodities bought from quota, got %d", len(expectedBought), len(bought.GetBought()))
+		}
+		for _, comm := range bought.GetBought() {
+			if comm.GetUsed() != expectedBought[comm.GetCommodityType()] || comm.GetKey() != quotaDTO.GetId() {
+				t.Errorf("Unexpected %s bought from quota: used %f, key %s", comm.GetCommodityType(),
+					comm.GetUsed(), comm.GetKey())
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Pod doesn't buy from quota %s", quotaDTO.GetId())
+	}
+}
@@ -0,0 +1,158 @@
+package compliance
+
+import (
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+// taintTolerationProcessorConfig defines necessary configuration for build a taint toleration processor.
+type taintTolerationProcessorConfig struct {
+	// define how taintTolerationProcessor accesses Kubernetes cluster.
+	k8sClusterScraper *cluster.ClusterScraper
+}
+
+func NewTaintTolerationProcessorConfig(k8sClusterScraper *cluster.ClusterScraper) *taintTolerationProcessorConfig {
+	return &taintTolerationProcessorConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+// Taint toleration processor creates an access commodity for each NoSchedule or NoExecute taint of the nodes.
+// The commodity is sold by the nodes without the taint, and bought by the pods which don't tolerate it, so that
+// these pods can only be placed on the nodes without the taint.
+type TaintTolerationProcessor struct {
+	*ComplianceProcessor
+
+	commManager *AffinityCommodityManager
+
+	nodes []*api.Node
+	pods  []*api.Pod
+}
+
+func NewTaintTolerationProcessor(config *taintTolerationProcessorConfig) (*TaintTolerationProcessor, error) {
+	allNodes, err := config.k8sClusterScraper.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	allPods, err := config.k8sClusterScraper.GetAllPods()
+	if err != nil {
+		return nil, err
+	}
+	return &TaintTolerationProcessor{
+		ComplianceProcessor: NewComplianceProcessor(),
+		commManager:         NewAffinityCommodityManager(),
+
+		nodes: allNodes,
+		pods:  allPods,
+	}, nil
+}
+
+func (tp *TaintTolerationProcessor) ProcessTaints(entityDTOs []*proto.EntityDTO) []*proto.EntityDTO {
+	taints := getAllTaints(tp.nodes)
+	if len(taints) == 0 {
+		return entityDTOs
+	}
+
+	tp.GroupEntityDTOs(entityDTOs)
+	nodesMap := make(map[string]*api.Node)
+	for _, node := range tp.nodes {
+		nodesMap[node.Name] = node
+	}
+
+	for _, taint := range taints {
+		commSold, commBought, err := tp.commManager.GetAccessCommoditiesForTaint(taint)
+		if err != nil {
+			glog.Errorf("Failed to build commodity for taint %s: %s", taint.ToString(), err)
+			continue
+		}
+		for _, node := range tp.nodes {
+			if !nodeHasTaint(node, &taint) {
+				tp.addCommoditySoldByNode(node, commSold)
+			}
+		}
+		for _, pod := range tp.pods {
+			// A pending pod has no node yet: it buys the commodity as an unplaced entity.
+			node, exist := nodesMap[pod.Spec.NodeName]
+			if !exist && pod.Spec.NodeName != "" {
+				continue
+			}
+			// The pod was admitted to its node before the taint was added, which is allowed by a NoSchedule taint.
+			if node != nil && nodeHasTaint(node, &taint) {
+				continue
+			}
+			if !podToleratesTaint(pod, &taint) {
+				tp.addCommodityBoughtByPod(pod, node, commBought)
+			}
+		}
+	}
+	return tp.GetAllEntityDTOs()
+}
+
+// Get the taints of the nodes which keep pods without toleration away. PreferNoSchedule taints are only a preference
+// of the scheduler, and are ignored.
+func getAllTaints(nodes []*api.Node) []api.Taint {
+	var taints []api.Taint
+	taintSet := make(map[string]struct{})
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect != api.TaintEffectNoSchedule && taint.Effect != api.TaintEffectNoExecute {
+				continue
+			}
+			if _, exist := taintSet[taint.ToString()]; exist {
+				continue
+			}
+			taintSet[taint.ToString()] = struct{}{}
+			taints = append(taints, taint)
+		}
+	}
+	return taints
+}
+
+func nodeHasTaint(node *api.Node, taint *api.Taint) bool {
+	for i := range node.Spec.Taints {
+		nodeTaint := &node.Spec.Taints[i]
+		if nodeTaint.MatchTaint(taint) && nodeTaint.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func podToleratesTaint(pod *api.Pod, taint *api.Taint) bool {
+	for i := range pod.Spec.Tolerations {
+		if pod.Spec.Tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+func (tp *TaintTolerationProcessor) addCommoditySoldByNode(node *api.Node, commodityDTO *proto.CommodityDTO) {
+	nodeEntityDTO, err := tp.GetEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID))
+	if err != nil {
+		glog.Errorf("Cannot find the entityDTO: %s", err)
+		return
+	}
+	if err := tp.AddCommoditiesSold(nodeEntityDTO, commodityDTO); err != nil {
+		glog.Errorf("Failed to add commodityDTO to %s: %s", node.Name, err)
+	}
+}
+
+func (tp *TaintTolerationProcessor) addCommodityBoughtByPod(pod *api.Pod, node *api.Node, commodityDTO *proto.CommodityDTO) {
+	podEntityDTO, err := tp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, string(pod.UID))
+	if err != nil {
+		// pods which are not monitored are not discovered.
+		glog.V(4).Infof("Cannot find the entityDTO: %s", err)
+		return
+	}
+	provider := getPodNodeProvider(node)
+	if err := tp.AddCommoditiesBought(podEntityDTO, provider, commodityDTO); err != nil {
+		glog.Errorf("Failed to add commodityDTO to %s: %s", util.GetPodClusterID(pod), err)
+	}
+}
@@ -0,0 +1,97 @@
+package compliance
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newTaintedNode(name string, taints ...api.Taint) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec:       api.NodeSpec{Taints: taints},
+	}
+}
+
+func newTolerantPod(name, nodeName string, tolerations ...api.Toleration) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)},
+		Spec:       api.PodSpec{NodeName: nodeName, Tolerations: tolerations},
+	}
+}
+
+func TestProcessTaints(t *testing.T) {
+	gpuTaint := api.Taint{Key: "gpu", Value: "true", Effect: api.TaintEffectNoSchedule}
+	nodes := []*api.Node{
+		newTaintedNode("node-1"),
+		newTaintedNode("node-2", gpuTaint),
+		newTaintedNode("node-3", api.Taint{Key: "spot", Effect: api.TaintEffectPreferNoSchedule}),
+	}
+	pods := []*api.Pod{
+		newTolerantPod("pod-1", "node-1"),
+		newTolerantPod("pod-2", "node-1", api.Toleration{Key: "gpu", Operator: api.TolerationOpExists}),
+		// admitted before the node was tainted.
+		newTolerantPod("pod-3", "node-2"),
+		// a pending pod, which is not placed on any node yet.
+		newTolerantPod("pod-4", ""),
+	}
+
+	var entityDTOs []*proto.EntityDTO
+	for _, node := range nodes {
+		e, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID)).Create()
+		entityDTOs = append(entityDTOs, e)
+	}
+	for _, pod := range pods {
+		e, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(pod.UID)).Create()
+		entityDTOs = append(entityDTOs, e)
+	}
+
+	tp := &TaintTolerationProcessor{
+		ComplianceProcessor: NewComplianceProcessor(),
+		commManager:         NewAffinityCommodityManager(),
+		nodes:               nodes,
+		pods:                pods,
+	}
+	tp.ProcessTaints(entityDTOs)
+
+	accessCommodities := func(comms []*proto.CommodityDTO) int {
+		count := 0
+		for _, comm := range comms {
+			if comm.GetCommodityType() == proto.CommodityDTO_VMPM_ACCESS {
+				count++
+			}
+		}
+		return count
+	}
+
+	expectedSold := map[string]int{"node-1": 1, "node-2": 0, "node-3": 1}
+	for id, expected := range expectedSold {
+		e, _ := tp.GetEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, id)
+		if sold := accessCommodities(e.GetCommoditiesSold()); sold != expected {
+			t.Errorf("Expected %d access commodities sold by %s, got %d", expected, id, sold)
+		}
+	}
+
+	expectedBought := map[string]int{"pod-1": 1, "pod-2": 0, "pod-3": 0, "pod-4": 1}
+	for id, expected := range expectedBought {
+		e, _ := tp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, id)
+		bought := 0
+		for _, commBought := range e.GetCommoditiesBought() {
+			bought += accessCommodities(commBought.GetBought())
+		}
+		if bought != expected {
+			t.Errorf("Expected %d access commodities bought by %s, got %d", expected, id, bought)
+		}
+	}
+	// the pending pod buys the commodity as an unplaced entity.
+	e, _ := tp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, "pod-4")
+	if bought := e.GetCommoditiesBought(); len(bought) != 1 || bought[0].GetProviderId() != "" ||
+		bought[0].GetProviderType() != proto.EntityDTO_VIRTUAL_MACHINE {
+		t.Errorf("Expected pod-4 to buy from no node, got %v", bought)
+	}
+}
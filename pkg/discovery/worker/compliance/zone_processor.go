@@ -0,0 +1,205 @@
+package compliance
+
+import (
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+// zoneProcessorConfig defines necessary configuration for build a zone processor.
+type zoneProcessorConfig struct {
+	// define how zoneProcessor accesses Kubernetes cluster.
+	k8sClusterScraper *cluster.ClusterScraper
+}
+
+func NewZoneProcessorConfig(k8sClusterScraper *cluster.ClusterScraper) *zoneProcessorConfig {
+	return &zoneProcessorConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+// Zone processor keeps the pods with zonal persistent volumes in the zone and region of their volumes.
+// Each such zone or region is an access commodity, sold by the nodes with the same zone or region label, and bought
+// by the pods mounting the volumes. A volume in several zones is a commodity for the set of its zones, sold by the
+// nodes in any of them. Zone constraints set in the node selector or node affinity of a pod are
+// handled by the AffinityProcessor.
+type ZoneProcessor struct {
+	*ComplianceProcessor
+
+	commManager *AffinityCommodityManager
+
+	nodes []*api.Node
+	pods  []*api.Pod
+
+	// The bound persistent volumes indexed by "namespace/claim name".
+	volumesByClaim map[string]*api.PersistentVolume
+}
+
+func NewZoneProcessor(config *zoneProcessorConfig) (*ZoneProcessor, error) {
+	allNodes, err := config.k8sClusterScraper.GetAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	allPods, err := config.k8sClusterScraper.GetAllPods()
+	if err != nil {
+		return nil, err
+	}
+	allPVCs, err := config.k8sClusterScraper.GetAllPersistentVolumeClaims()
+	if err != nil {
+		return nil, err
+	}
+	allPVs, err := config.k8sClusterScraper.GetAllPersistentVolumes()
+	if err != nil {
+		return nil, err
+	}
+	return newZoneProcessor(allNodes, allPods, allPVCs, allPVs), nil
+}
+
+func newZoneProcessor(nodes []*api.Node, pods []*api.Pod, pvcs []*api.PersistentVolumeClaim,
+	pvs []*api.PersistentVolume) *ZoneProcessor {
+	return &ZoneProcessor{
+		ComplianceProcessor: NewComplianceProcessor(),
+		commManager:         NewAffinityCommodityManager(),
+
+		nodes:          nodes,
+		pods:           pods,
+		volumesByClaim: util.GetPersistentVolumesByClaim(pvcs, pvs),
+	}
+}
+
+func (zp *ZoneProcessor) ProcessZones(entityDTOs []*proto.EntityDTO) []*proto.EntityDTO {
+	nodesMap := make(map[string]*api.Node)
+	for _, node := range zp.nodes {
+		nodesMap[node.Name] = node
+	}
+
+	// The topology domains, by label key and value, that the pods must stay in.
+	podDomains := make(map[*api.Pod]map[string]string)
+	usedDomains := make(map[string]map[string]struct{})
+	for _, pod := range zp.pods {
+		if _, exist := nodesMap[pod.Spec.NodeName]; !exist && pod.Spec.NodeName != "" {
+			continue
+		}
+		domains := zp.getPodTopologyDomains(pod)
+		if len(domains) == 0 {
+			continue
+		}
+		podDomains[pod] = domains
+		for key, value := range domains {
+			if _, exist := usedDomains[key]; !exist {
+				usedDomains[key] = make(map[string]struct{})
+			}
+			usedDomains[key][value] = struct{}{}
+		}
+	}
+	if len(podDomains) == 0 {
+		return entityDTOs
+	}
+
+	zp.GroupEntityDTOs(entityDTOs)
+	for _, node := range zp.nodes {
+		for key, values := range usedDomains {
+			nodeValue, exist := node.Labels[key]
+			if !exist {
+				continue
+			}
+			for value := range values {
+				if !containsDomain(value, nodeValue) {
+					continue
+				}
+				commSold, _, err := zp.commManager.GetAccessCommoditiesForTopology(key, value)
+				if err != nil {
+					glog.Errorf("Failed to build commodity for %s=%s: %s", key, value, err)
+					continue
+				}
+				zp.addCommoditySoldByNode(node, commSold)
+			}
+		}
+	}
+	for pod, domains := range podDomains {
+		// A pending pod has no node yet: it buys the commodities as an unplaced entity.
+		node := nodesMap[pod.Spec.NodeName]
+		for key, value := range domains {
+			_, commBought, err := zp.commManager.GetAccessCommoditiesForTopology(key, value)
+			if err != nil {
+				glog.Errorf("Failed to build commodity for %s=%s: %s", key, value, err)
+				continue
+			}
+			zp.addCommodityBoughtByPod(pod, node, commBought)
+		}
+	}
+	return zp.GetAllEntityDTOs()
+}
+
+// Get the zone and region of the persistent volumes mounted by the pod. If the pod mounts several volumes, it must
+// stay in the domains shared by all of them.
+func (zp *ZoneProcessor) getPodTopologyDomains(pod *api.Pod) map[string]string {
+	domains := make(map[string]string)
+	for _, podVolume := range util.GetPodPersistentVolumes(pod, zp.volumesByClaim) {
+		for key, value := range util.GetPersistentVolumeTopology(podVolume.PersistentVolume) {
+			current, found := domains[key]
+			if !found {
+				domains[key] = value
+				continue
+			}
+			shared := intersectDomains(current, value)
+			if shared == "" {
+				glog.Warningf("Pod %s mounts volumes in different domains %s=%s and %s=%s.",
+					util.GetPodClusterID(pod), key, current, key, value)
+				continue
+			}
+			domains[key] = shared
+		}
+	}
+	return domains
+}
+
+// Whether the domain is one of the domains listed in the topology label value of a volume.
+func containsDomain(value, domain string) bool {
+	for _, d := range util.GetTopologyDomains(value) {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// Get the domains listed in both topology label values, joined as a label value; empty if there is none.
+func intersectDomains(value1, value2 string) string {
+	var shared []string
+	for _, domain := range util.GetTopologyDomains(value1) {
+		if containsDomain(value2, domain) {
+			shared = append(shared, domain)
+		}
+	}
+	return util.JoinTopologyDomains(shared)
+}
+
+func (zp *ZoneProcessor) addCommoditySoldByNode(node *api.Node, commodityDTO *proto.CommodityDTO) {
+	nodeEntityDTO, err := zp.GetEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID))
+	if err != nil {
+		glog.Errorf("Cannot find the entityDTO: %s", err)
+		return
+	}
+	if err := zp.AddCommoditiesSold(nodeEntityDTO, commodityDTO); err != nil {
+		glog.Errorf("Failed to add commodityDTO to %s: %s", node.Name, err)
+	}
+}
+
+func (zp *ZoneProcessor) addCommodityBoughtByPod(pod *api.Pod, node *api.Node, commodityDTO *proto.CommodityDTO) {
+	podEntityDTO, err := zp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, string(pod.UID))
+	if err != nil {
+		// pods which are not monitored are not discovered.
+		glog.V(4).Infof("Cannot find the entityDTO: %s", err)
+		return
+	}
+	provider := getPodNodeProvider(node)
+	if err := zp.AddCommoditiesBought(podEntityDTO, provider, commodityDTO); err != nil {
+		glog.Errorf("Failed to add commodityDTO to %s: %s", util.GetPodClusterID(pod), err)
+	}
+}
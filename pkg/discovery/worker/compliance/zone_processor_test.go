@@ -0,0 +1,137 @@
+package compliance
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+	kubeletapis "k8s.io/kubernetes/pkg/kubelet/apis"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newZonalNode(name, zone string) *api.Node {
+	return &api.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			UID:    types.UID(name),
+			Labels: map[string]string{kubeletapis.LabelZoneFailureDomain: zone},
+		},
+	}
+}
+
+func newZonalPV(name, zone string) *api.PersistentVolume {
+	return &api.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{kubeletapis.LabelZoneFailureDomain: zone},
+		},
+	}
+}
+
+func newBoundPVC(name, volumeName string) *api.PersistentVolumeClaim {
+	return &api.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       api.PersistentVolumeClaimSpec{VolumeName: volumeName},
+	}
+}
+
+func newPodWithClaim(name, nodeName, claimName string) *api.Pod {
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)},
+		Spec:       api.PodSpec{NodeName: nodeName},
+	}
+	if claimName != "" {
+		pod.Spec.Volumes = []api.Volume{{
+			Name: "data",
+			VolumeSource: api.VolumeSource{
+				PersistentVolumeClaim: &api.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			},
+		}}
+	}
+	return pod
+}
+
+func TestProcessZones(t *testing.T) {
+	nodes := []*api.Node{
+		newZonalNode("node-1", "us-east-1a"),
+		newZonalNode("node-2", "us-east-1a"),
+		newZonalNode("node-3", "us-east-1b"),
+	}
+	pvs := []*api.PersistentVolume{
+		newZonalPV("pv-1", "us-east-1a"),
+		newZonalPV("pv-2", "us-east-1a__us-east-1b"),
+	}
+	pvcs := []*api.PersistentVolumeClaim{
+		newBoundPVC("claim-1", "pv-1"),
+		newBoundPVC("claim-2", "pv-2"),
+	}
+	pods := []*api.Pod{
+		newPodWithClaim("pod-1", "node-1", "claim-1"),
+		// the volume is in multiple zones: the pod can be on the nodes of any of them.
+		newPodWithClaim("pod-2", "node-3", "claim-2"),
+		newPodWithClaim("pod-3", "node-3", ""),
+	}
+
+	var entityDTOs []*proto.EntityDTO
+	for _, node := range nodes {
+		e, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID)).Create()
+		entityDTOs = append(entityDTOs, e)
+	}
+	for _, pod := range pods {
+		e, _ := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(pod.UID)).Create()
+		entityDTOs = append(entityDTOs, e)
+	}
+
+	zp := newZoneProcessor(nodes, pods, pvcs, pvs)
+	zp.ProcessZones(entityDTOs)
+
+	accessCommodities := func(comms []*proto.CommodityDTO) int {
+		count := 0
+		for _, comm := range comms {
+			if comm.GetCommodityType() == proto.CommodityDTO_VMPM_ACCESS {
+				count++
+			}
+		}
+		return count
+	}
+
+	// the nodes in us-east-1a sell the commodities of us-east-1a and of us-east-1a__us-east-1b.
+	expectedSold := map[string]int{"node-1": 2, "node-2": 2, "node-3": 1}
+	for id, expected := range expectedSold {
+		e, _ := zp.GetEntityDTO(proto.EntityDTO_VIRTUAL_MACHINE, id)
+		if sold := accessCommodities(e.GetCommoditiesSold()); sold != expected {
+			t.Errorf("Expected %d access commodities sold by %s, got %d", expected, id, sold)
+		}
+	}
+
+	expectedBought := map[string]int{"pod-1": 1, "pod-2": 1, "pod-3": 0}
+	for id, expected := range expectedBought {
+		e, _ := zp.GetEntityDTO(proto.EntityDTO_CONTAINER_POD, id)
+		bought := 0
+		for _, commBought := range e.GetCommoditiesBought() {
+			bought += accessCommodities(commBought.GetBought())
+		}
+		if bought != expected {
+			t.Errorf("Expected %d access commodities bought by %s, got %d", expected, id, bought)
+		}
+	}
+}
+
+func TestIntersectDomains(t *testing.T) {
+	tests := []struct {
+		value1, value2, expected string
+	}{
+		{"us-east-1a", "us-east-1a", "us-east-1a"},
+		{"us-east-1a__us-east-1b", "us-east-1b__us-east-1c", "us-east-1b"},
+		{"us-east-1b__us-east-1a", "us-east-1a__us-east-1b__us-east-1c", "us-east-1a__us-east-1b"},
+		{"us-east-1a", "us-east-1b", ""},
+	}
+	for _, test := range tests {
+		if shared := intersectDomains(test.value1, test.value2); shared != test.expected {
+			t.Errorf("Domains shared by %s and %s: expected %q, got %q", test.value1, test.value2, test.expected, shared)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package worker
+
+import (
+	"fmt"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	k8sControllerDiscWorkerID string = "ControllerDiscoveryWorker"
+)
+
+type k8sControllerDiscoveryWorkerConfig struct {
+	k8sClusterScraper *cluster.ClusterScraper
+}
+
+func NewK8sControllerDiscoveryWorkerConfig(k8sClusterScraper *cluster.ClusterScraper) *k8sControllerDiscoveryWorkerConfig {
+	return &k8sControllerDiscoveryWorkerConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+// Controller discovery worker builds the entityDTOs of the workload controllers which manage the discovered pods.
+// Only the top controller of a pod is modeled as an entity, e.g. the Deployment instead of its ReplicaSets.
+type k8sControllerDiscoveryWorker struct {
+	id string
+
+	config *k8sControllerDiscoveryWorkerConfig
+
+	controllerDTOBuilder *dtofactory.WorkloadControllerEntityDTOBuilder
+}
+
+func NewK8sControllerDiscoveryWorker(config *k8sControllerDiscoveryWorkerConfig) *k8sControllerDiscoveryWorker {
+	return &k8sControllerDiscoveryWorker{
+		id:                   k8sControllerDiscWorkerID,
+		config:               config,
+		controllerDTOBuilder: dtofactory.NewWorkloadControllerEntityDTOBuilder(),
+	}
+}
+
+// Post-process the entityDTOs and create the workload controller entityDTOs.
+// The properties of the controllers are also added to the pod entityDTOs, so that actions on a pod can find its controller.
+func (w *k8sControllerDiscoveryWorker) Do(entityDTOs []*proto.EntityDTO) *task.TaskResult {
+	controllers, err := w.getAllControllers()
+	if err != nil {
+		return task.NewTaskResult(w.id, task.TaskFailed).WithErr(err)
+	}
+	pods, err := w.config.k8sClusterScraper.GetAllPods()
+	if err != nil {
+		return task.NewTaskResult(w.id, task.TaskFailed).WithErr(fmt.Errorf("failed to get all pods: %s", err))
+	}
+
+	podDTOs := make(map[string]*proto.EntityDTO)
+	for _, e := range entityDTOs {
+		if e.GetEntityType() == proto.EntityDTO_CONTAINER_POD {
+			podDTOs[e.GetId()] = e
+		}
+	}
+
+	controllerDTOs := w.buildControllerEntityDTOs(controllers, pods, podDTOs)
+	glog.V(3).Infof("There are %d workload controller entityDTOs", len(controllerDTOs))
+	return task.NewTaskResult(w.id, task.TaskSucceeded).WithContent(controllerDTOs)
+}
+
+func (w *k8sControllerDiscoveryWorker) getAllControllers() ([]*util.WorkloadController, error) {
+	scraper := w.config.k8sClusterScraper
+	var controllers []*util.WorkloadController
+
+	deployments, err := scraper.GetAllDeployments()
+	if err != nil {
+		return nil, err
+	}
+	for _, deploy := range deployments {
+		controllers = append(controllers, util.NewDeploymentController(deploy))
+	}
+
+	replicaSets, err := scraper.GetAllReplicaSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets {
+		controllers = append(controllers, util.NewReplicaSetController(rs))
+	}
+
+	rcs, err := scraper.GetAllReplicationControllers()
+	if err != nil {
+		return nil, err
+	}
+	for _, rc := range rcs {
+		controllers = append(controllers, util.NewReplicationControllerController(rc))
+	}
+
+	statefulSets, err := scraper.GetAllStatefulSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, ss := range statefulSets {
+		controllers = append(controllers, util.NewStatefulSetController(ss))
+	}
+
+	daemonSets, err := scraper.GetAllDaemonSets()
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets {
+		controllers = append(controllers, util.NewDaemonSetController(ds))
+	}
+	return controllers, nil
+}
+
+// Build an entityDTO for each top controller, i.e. a controller which is not controlled by another known controller.
+// The discovered pods are linked to the top controller up their chain of owners.
+func (w *k8sControllerDiscoveryWorker) buildControllerEntityDTOs(controllers []*util.WorkloadController, pods []*api.Pod,
+	podDTOs map[string]*proto.EntityDTO) []*proto.EntityDTO {
+	controllerMap := make(map[string]*util.WorkloadController)
+	for _, controller := range controllers {
+		controllerMap[controller.Key()] = controller
+	}
+
+	controllerPodDTOs := make(map[string][]*proto.EntityDTO)
+	for _, pod := range pods {
+		podDTO, exist := podDTOs[string(pod.UID)]
+		if !exist {
+			continue
+		}
+		chain := getPodControllerChain(pod, controllerMap)
+		if len(chain) == 0 {
+			continue
+		}
+		top := chain[len(chain)-1]
+		podDTO.EntityProperties = append(podDTO.EntityProperties, property.BuildPodControllerProperties(top, chain)...)
+		controllerPodDTOs[top.Key()] = append(controllerPodDTOs[top.Key()], podDTO)
+	}
+
+	var result []*proto.EntityDTO
+	for _, controller := range controllers {
+		if getOwnerController(controller, controllerMap) != nil {
+			continue
+		}
+		entityDTO, err := w.controllerDTOBuilder.BuildEntityDTO(controller, controllerPodDTOs[controller.Key()])
+		if err != nil {
+			glog.Errorf("Failed to build workload controller entityDTO: %s", err)
+			continue
+		}
+		result = append(result, entityDTO)
+	}
+	return result
+}
+
+// Get the known controllers of the pod, from its owner up to the top controller.
+func getPodControllerChain(pod *api.Pod, controllerMap map[string]*util.WorkloadController) []*util.WorkloadController {
+	kind, name, err := util.GetPodControllerKindAndName(pod)
+	if err != nil {
+		glog.Errorf("Failed to get the controller of pod %s: %s", util.GetPodClusterID(pod), err)
+		return nil
+	}
+	if kind == "" {
+		return nil
+	}
+	controller, exist := controllerMap[util.WorkloadControllerKey(kind, pod.Namespace, name)]
+	if !exist {
+		glog.V(4).Infof("The controller %s %s of pod %s is not discovered.", kind, name, util.GetPodClusterID(pod))
+		return nil
+	}
+
+	chain := []*util.WorkloadController{controller}
+	// Bound the walk by the number of controllers, in case of a loop in the owner references.
+	for len(chain) <= len(controllerMap) {
+		controller = getOwnerController(controller, controllerMap)
+		if controller == nil {
+			break
+		}
+		chain = append(chain, controller)
+	}
+	return chain
+}
+
+func getOwnerController(controller *util.WorkloadController, controllerMap map[string]*util.WorkloadController) *util.WorkloadController {
+	if controller.Owner == nil {
+		return nil
+	}
+	return controllerMap[util.WorkloadControllerKey(controller.Owner.Kind, controller.Namespace, controller.Owner.Name)]
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+	extensions "k8s.io/client-go/pkg/apis/extensions/v1beta1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory/property"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func newControllerOwnerReference(kind, name string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+}
+
+func TestBuildControllerEntityDTOs(t *testing.T) {
+	replicas := int32(2)
+	deploy := &extensions.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", UID: types.UID("deploy-uid")},
+		Spec:       extensions.DeploymentSpec{Replicas: &replicas},
+	}
+	rs := &extensions.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-2401", UID: types.UID("rs-uid"),
+			OwnerReferences: newControllerOwnerReference(util.Kind_Deployment, "web")},
+		Spec: extensions.ReplicaSetSpec{Replicas: &replicas},
+	}
+	controllers := []*util.WorkloadController{
+		util.NewDeploymentController(deploy),
+		util.NewReplicaSetController(rs),
+	}
+
+	pod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web-2401-abcde", UID: types.UID("pod-uid"),
+			OwnerReferences: newControllerOwnerReference(util.Kind_ReplicaSet, "web-2401")},
+	}
+	vcpu, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VCPU).Capacity(2000).Used(500).Create()
+	vmem, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_VMEM).Capacity(4096).Used(1024).Create()
+	podDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(pod.UID)).
+		SellsCommodities([]*proto.CommodityDTO{vcpu, vmem}).
+		Create()
+	if err != nil {
+		t.Fatalf("Failed to build pod entityDTO: %v", err)
+	}
+
+	w := NewK8sControllerDiscoveryWorker(NewK8sControllerDiscoveryWorkerConfig(nil))
+	controllerDTOs := w.buildControllerEntityDTOs(controllers, []*api.Pod{pod},
+		map[string]*proto.EntityDTO{podDTO.GetId(): podDTO})
+
+	// The ReplicaSet is controlled by the Deployment, so only the Deployment is an entity.
+	if len(controllerDTOs) != 1 {
+		t.Fatalf("Expected 1 controller entityDTO, got %d", len(controllerDTOs))
+	}
+	controllerDTO := controllerDTOs[0]
+	if controllerDTO.GetEntityType() != dtofactory.WorkloadControllerEntityType || controllerDTO.GetId() != "deploy-uid" {
+		t.Errorf("Unexpected controller entityDTO %s of type %s", controllerDTO.GetId(), controllerDTO.GetEntityType())
+	}
+	namespace, kind, name, err := property.GetWorkloadControllerInfoFromProperty(controllerDTO.GetEntityProperties())
+	if err != nil || namespace != "default" || kind != util.Kind_Deployment || name != "web" {
+		t.Errorf("Unexpected controller properties: %s, %s, %s, %v", namespace, kind, name, err)
+	}
+
+	bought := controllerDTO.GetCommoditiesBought()
+	if len(bought) != 1 || bought[0].GetProviderId() != podDTO.GetId() || len(bought[0].GetBought()) != 2 {
+		t.Fatalf("Expected the controller to buy VCPU and VMEM from pod %s, got %v", podDTO.GetId(), bought)
+	}
+	for _, comm := range bought[0].GetBought() {
+		if (comm.GetCommodityType() == proto.CommodityDTO_VCPU && comm.GetUsed() != 500) ||
+			(comm.GetCommodityType() == proto.CommodityDTO_VMEM && comm.GetUsed() != 1024) {
+			t.Errorf("Unexpected %s bought from pod: used %f", comm.GetCommodityType(), comm.GetUsed())
+		}
+	}
+
+	// The pod refers to its top controller, and the whole chain of controllers.
+	podProperties := make(map[string]string)
+	for _, p := range podDTO.GetEntityProperties() {
+		podProperties[p.GetName()] = p.GetValue()
+	}
+	if podProperties["KubernetesControllerKind"] != util.Kind_Deployment || podProperties["KubernetesControllerName"] != "web" ||
+		podProperties["KubernetesControllerChain"] != "ReplicaSet/web-2401,Deployment/web" {
+		t.Errorf("Unexpected pod controller properties: %v", podProperties)
+	}
+}
@@ -3,6 +3,8 @@ package worker
 import (
 	"fmt"
 	"math"
+	"sync"
+	"time"
 
 	api "k8s.io/client-go/pkg/api/v1"
 
@@ -13,41 +15,93 @@ import (
 	"github.com/turbonomic/kubeturbo/pkg/cluster"
 )
 
+const (
+	DefaultMinWorkerCount      = 4
+	DefaultMaxWorkerCount      = 16
+	DefaultTargetDiscoveryTime = 5 * time.Minute
+
+	// The nodes are split in several tasks per worker instead of a single one: the workers which finish early pick up
+	// the remaining tasks, so that a slow worker doesn't hold back the nodes which are not discovered yet.
+	tasksPerWorker = 4
+
+	// The time to discover a node assumed until the first tasks finish.
+	defaultNodeDiscoveryTime = time.Second
+)
+
 type DispatcherConfig struct {
 	clusterInfoScraper *cluster.ClusterScraper
 	probeConfig        *configs.ProbeConfig
 
-	workerCount int
+	// The range of the number of workers discovering the nodes at the same time.
+	minWorkerCount int
+	maxWorkerCount int
+
+	// The time the discovery of all the nodes should take, which must be shorter than the discovery interval.
+	targetDiscoveryTime time.Duration
 }
 
-func NewDispatcherConfig(clusterInfoScraper *cluster.ClusterScraper, probeConfig *configs.ProbeConfig, workerCount int) *DispatcherConfig {
+func NewDispatcherConfig(clusterInfoScraper *cluster.ClusterScraper, probeConfig *configs.ProbeConfig) *DispatcherConfig {
 	return &DispatcherConfig{
-		clusterInfoScraper: clusterInfoScraper,
-		probeConfig:        probeConfig,
-		workerCount:        workerCount,
+		clusterInfoScraper:  clusterInfoScraper,
+		probeConfig:         probeConfig,
+		minWorkerCount:      DefaultMinWorkerCount,
+		maxWorkerCount:      DefaultMaxWorkerCount,
+		targetDiscoveryTime: DefaultTargetDiscoveryTime,
+	}
+}
+
+// Set the range of the number of workers. Non-positive values keep the defaults.
+func (c *DispatcherConfig) WithWorkerCount(minWorkerCount, maxWorkerCount int) *DispatcherConfig {
+	if minWorkerCount > 0 {
+		c.minWorkerCount = minWorkerCount
 	}
+	if maxWorkerCount > 0 {
+		c.maxWorkerCount = maxWorkerCount
+	}
+	if c.maxWorkerCount < c.minWorkerCount {
+		c.maxWorkerCount = c.minWorkerCount
+	}
+	return c
+}
+
+func (c *DispatcherConfig) WithTargetDiscoveryTime(targetDiscoveryTime time.Duration) *DispatcherConfig {
+	if targetDiscoveryTime > 0 {
+		c.targetDiscoveryTime = targetDiscoveryTime
+	}
+	return c
+}
+
+// The maximum number of workers, i.e. of discovery tasks executed at the same time.
+func (c *DispatcherConfig) MaxWorkerCount() int {
+	return c.maxWorkerCount
 }
 
 type Dispatcher struct {
 	config     *DispatcherConfig
 	workerPool chan chan *task.Task
+
+	lock sync.Mutex
+	// Limits the number of tasks executed at the same time to the pool size of the current discovery.
+	slots chan struct{}
+	// The moving average of the time taken by a worker to discover a node.
+	nodeDiscoveryTime time.Duration
 }
 
 func NewDispatcher(config *DispatcherConfig) *Dispatcher {
 	return &Dispatcher{
 		config: config,
-		// TODO use maxWorker count for now. Improve in the future once we find a good way to get the number of task.
-		// TODO If we allow worker number burst (# of workers > maxWorker), then the extra worker would block on registering. Or we use a threshold for burst number.
-		workerPool: make(chan chan *task.Task, config.workerCount),
+		// All the workers are created up front; only the pool size of each discovery are given a task at the same time.
+		workerPool: make(chan chan *task.Task, config.maxWorkerCount),
 	}
 }
 
 func (d *Dispatcher) Init(c *ResultCollector) {
-	for i := 0; i < d.config.workerCount; i++ {
+	for i := 0; i < d.config.maxWorkerCount; i++ {
 		workerConfig := NewK8sDiscoveryWorkerConfig(d.config.probeConfig.StitchingPropertyType)
 		for _, mc := range d.config.probeConfig.MonitoringConfigs {
 			workerConfig.WithMonitoringWorkerConfig(mc)
 		}
+		workerConfig.WithMetricHistory(d.config.probeConfig.MetricHistory, d.config.probeConfig.UsagePercentile)
 		// create workers
 		wid := fmt.Sprintf("w%d", i)
 		discoveryWorker, err := NewK8sDiscoveryWorker(workerConfig, wid)
@@ -63,33 +117,89 @@ func (d *Dispatcher) RegisterWorker(worker *k8sDiscoveryWorker) {
 	d.workerPool <- worker.taskChan
 }
 
+// Split the nodes in tasks and hand them to the workers as they become available, without waiting for the tasks to be
+// assigned. Return the number of tasks, i.e. of the results to collect.
 func (d *Dispatcher) Dispatch(nodes []*api.Node) int {
-	// make sure when len(node) < workerCount, worker will receive at most 1 node to discover
-	perTaskNodeLength := int(math.Ceil(float64(len(nodes)) / float64(d.config.workerCount)))
-	glog.V(3).Infof("The number of nodes per task is: %d", perTaskNodeLength)
-	assignedNodesCount := 0
-	assignedWorkerCount := 0
-	for assignedNodesCount+perTaskNodeLength <= len(nodes) {
-		currNodes := nodes[assignedNodesCount : assignedNodesCount+perTaskNodeLength]
-		currPods := d.config.clusterInfoScraper.GetRunningPodsOnNodes(currNodes)
-		currTask := task.NewTask().WithNodes(currNodes).WithPods(currPods)
-		d.assignTask(currTask)
-
-		assignedNodesCount += perTaskNodeLength
-
-		assignedWorkerCount++
+	if len(nodes) == 0 {
+		return 0
+	}
+	poolSize, perTaskNodeLength := d.plan(len(nodes))
+	taskCount := int(math.Ceil(float64(len(nodes)) / float64(perTaskNodeLength)))
+	glog.V(2).Infof("Dispatching %d nodes in %d tasks of %d nodes to %d workers", len(nodes), taskCount,
+		perTaskNodeLength, poolSize)
+
+	volumesByClaim, err := d.config.clusterInfoScraper.GetPersistentVolumesByClaim()
+	if err != nil {
+		glog.Errorf("Failed to get persistent volumes, the volumes of the pods are not discovered: %s", err)
+	}
+
+	slots := make(chan struct{}, poolSize)
+	d.lock.Lock()
+	d.slots = slots
+	d.lock.Unlock()
+	go func() {
+		for assignedNodesCount := 0; assignedNodesCount < len(nodes); assignedNodesCount += perTaskNodeLength {
+			end := assignedNodesCount + perTaskNodeLength
+			if end > len(nodes) {
+				end = len(nodes)
+			}
+			// Released by the worker once it finishes the task.
+			slots <- struct{}{}
+			currNodes := nodes[assignedNodesCount:end]
+			nodePods := d.config.clusterInfoScraper.GetAllRunningPodsOnNodes(currNodes)
+			currPods := d.config.clusterInfoScraper.FilterPods(nodePods)
+			currTask := task.NewTask().WithNodes(currNodes).WithPods(currPods).WithNodePods(nodePods).
+				WithPersistentVolumes(volumesByClaim)
+			d.assignTask(currTask)
+		}
+	}()
+
+	return taskCount
+}
+
+// Size the worker pool so that the nodes are discovered within the target time, based on the time observed to discover
+// a node, and get the number of nodes per task.
+func (d *Dispatcher) plan(nodeCount int) (int, int) {
+	d.lock.Lock()
+	nodeDiscoveryTime := d.nodeDiscoveryTime
+	d.lock.Unlock()
+	if nodeDiscoveryTime <= 0 {
+		nodeDiscoveryTime = defaultNodeDiscoveryTime
 	}
-	if assignedNodesCount < len(nodes) {
-		currNodes := nodes[assignedNodesCount:]
-		currPods := d.config.clusterInfoScraper.GetRunningPodsOnNodes(currNodes)
-		currTask := task.NewTask().WithNodes(currNodes).WithPods(currPods)
-		d.assignTask(currTask)
 
-		assignedWorkerCount++
+	poolSize := int(math.Ceil(float64(nodeCount) * float64(nodeDiscoveryTime) / float64(d.config.targetDiscoveryTime)))
+	if poolSize < d.config.minWorkerCount {
+		poolSize = d.config.minWorkerCount
+	}
+	if poolSize > d.config.maxWorkerCount {
+		glog.Warningf("Discovering %d nodes at %v per node needs more than the max %d workers to finish within %v",
+			nodeCount, nodeDiscoveryTime, d.config.maxWorkerCount, d.config.targetDiscoveryTime)
+		poolSize = d.config.maxWorkerCount
 	}
-	glog.V(2).Infof("Dispatched discovery task to %d workers", assignedWorkerCount)
+	// make sure when nodeCount < poolSize, a worker will receive at most 1 node to discover
+	if poolSize > nodeCount {
+		poolSize = nodeCount
+	}
+	perTaskNodeLength := int(math.Ceil(float64(nodeCount) / float64(poolSize*tasksPerWorker)))
+	return poolSize, perTaskNodeLength
+}
 
-	return assignedWorkerCount
+// Called by a worker when it finishes a task: record the time it took to discover the nodes and free its slot.
+func (d *Dispatcher) FinishTask(nodeCount int, duration time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if nodeCount > 0 {
+		sample := duration / time.Duration(nodeCount)
+		if d.nodeDiscoveryTime == 0 {
+			d.nodeDiscoveryTime = sample
+		} else {
+			// Smooth the samples so that a single slow task doesn't resize the pool.
+			d.nodeDiscoveryTime = (d.nodeDiscoveryTime*7 + sample*3) / 10
+		}
+	}
+	if d.slots != nil {
+		<-d.slots
+	}
 }
 
 func (d *Dispatcher) assignTask(t *task.Task) {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"testing"
+	"time"
 )
 
 func TestDispatcher_DispatchMimic(t *testing.T) {
@@ -55,3 +56,51 @@ func TestDispatcher_DispatchMimic(t *testing.T) {
 		t.Errorf("%d Vs. %d", receiveNum, nodeNum)
 	}
 }
+
+func TestDispatcher_Plan(t *testing.T) {
+	config := NewDispatcherConfig(nil, nil).WithWorkerCount(2, 8).WithTargetDiscoveryTime(time.Minute)
+	d := NewDispatcher(config)
+
+	table := []struct {
+		nodeDiscoveryTime time.Duration
+		nodeCount         int
+		poolSize          int
+		perTaskNodeLength int
+	}{
+		// At most 1 node per worker in a small cluster.
+		{nodeCount: 1, poolSize: 1, perTaskNodeLength: 1},
+		// The min pool size is enough with the default time per node.
+		{nodeCount: 100, poolSize: 2, perTaskNodeLength: 13},
+		{nodeDiscoveryTime: 2 * time.Second, nodeCount: 100, poolSize: 4, perTaskNodeLength: 7},
+		// Capped by the max pool size.
+		{nodeDiscoveryTime: 10 * time.Second, nodeCount: 100, poolSize: 8, perTaskNodeLength: 4},
+	}
+	for _, item := range table {
+		d.nodeDiscoveryTime = item.nodeDiscoveryTime
+		poolSize, perTaskNodeLength := d.plan(item.nodeCount)
+		if poolSize != item.poolSize || perTaskNodeLength != item.perTaskNodeLength {
+			t.Errorf("Expected pool size %d and %d nodes per task for %d nodes at %v per node, got %d and %d",
+				item.poolSize, item.perTaskNodeLength, item.nodeCount, item.nodeDiscoveryTime, poolSize,
+				perTaskNodeLength)
+		}
+	}
+}
+
+func TestDispatcher_FinishTask(t *testing.T) {
+	d := NewDispatcher(NewDispatcherConfig(nil, nil))
+	d.slots = make(chan struct{}, 2)
+	d.slots <- struct{}{}
+	d.slots <- struct{}{}
+
+	d.FinishTask(10, 20*time.Second)
+	if d.nodeDiscoveryTime != 2*time.Second {
+		t.Errorf("Expected 2s per node, got %v", d.nodeDiscoveryTime)
+	}
+	d.FinishTask(10, 120*time.Second)
+	if d.nodeDiscoveryTime != 5*time.Second {
+		t.Errorf("Expected 5s per node, got %v", d.nodeDiscoveryTime)
+	}
+	if len(d.slots) != 0 {
+		t.Errorf("Expected all the slots to be freed, got %d taken", len(d.slots))
+	}
+}
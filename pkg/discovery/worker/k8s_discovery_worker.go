@@ -1,16 +1,20 @@
 package worker
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
+	api "k8s.io/client-go/pkg/api/v1"
+
 	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
-	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 
@@ -20,35 +24,42 @@ import (
 const (
 	defaultMonitoringWorkerTimeout time.Duration = time.Minute * 3
 	defaultMaxTimeout              time.Duration = time.Minute * 20
+
+	// The history of the metrics which are not updated for this long is dropped.
+	staleMetricHistoryAge time.Duration = time.Hour
 )
 
 type k8sDiscoveryWorkerConfig struct {
 	//k8sClusterScraper *cluster.ClusterScraper
 
-	// a collection of all configs for building different monitoring clients.
-	// key: monitor type; value: monitor worker config.
-	monitoringSourceConfigs map[types.MonitorType][]monitoring.MonitorWorkerConfig
+	// all configs for building different monitoring clients, in the order they are added.
+	monitoringSourceConfigs []monitoring.MonitorWorkerConfig
 
 	stitchingPropertyType stitching.StitchingPropertyType
+
+	// Shared by all the discovery workers.
+	metricHistory   *metrics.EntityMetricHistory
+	usagePercentile float64
 }
 
 func NewK8sDiscoveryWorkerConfig(sType stitching.StitchingPropertyType) *k8sDiscoveryWorkerConfig {
 	return &k8sDiscoveryWorkerConfig{
-		stitchingPropertyType:   sType,
-		monitoringSourceConfigs: make(map[types.MonitorType][]monitoring.MonitorWorkerConfig),
+		stitchingPropertyType: sType,
 	}
 }
 
 // Add new monitoring worker config to the discovery worker config.
+// The metrics of a monitoring source override the same metrics of the sources added before it.
 func (c *k8sDiscoveryWorkerConfig) WithMonitoringWorkerConfig(config monitoring.MonitorWorkerConfig) *k8sDiscoveryWorkerConfig {
-	monitorType := config.GetMonitorType()
-	configs, exist := c.monitoringSourceConfigs[monitorType]
-	if !exist {
-		configs = []monitoring.MonitorWorkerConfig{}
-	}
+	c.monitoringSourceConfigs = append(c.monitoringSourceConfigs, config)
 
-	configs = append(configs, config)
-	c.monitoringSourceConfigs[monitorType] = configs
+	return c
+}
+
+// Report the peak and percentile of the usage based on the given metric history.
+func (c *k8sDiscoveryWorkerConfig) WithMetricHistory(history *metrics.EntityMetricHistory, usagePercentile float64) *k8sDiscoveryWorkerConfig {
+	c.metricHistory = history
+	c.usagePercentile = usagePercentile
 
 	return c
 }
@@ -62,9 +73,13 @@ type k8sDiscoveryWorker struct {
 	// config
 	config *k8sDiscoveryWorkerConfig
 
-	// a collection of all monitoring worker of different types.
-	// key: monitoring types; value: monitoring worker instance.
-	monitoringWorker map[types.MonitorType][]monitoring.MonitoringWorker
+	// all the monitoring workers, in the order of their configs.
+	monitoringWorkers []monitoring.MonitoringWorker
+
+	// The monitoring workers left behind by a timed out task and still running it; they are not given a new task
+	// until they return.
+	busyLock     sync.Mutex
+	busyMonitors map[monitoring.MonitoringWorker]bool
 
 	// sink is a central place to store all the monitored data.
 	sink *metrics.EntityMetricSink
@@ -79,29 +94,23 @@ func NewK8sDiscoveryWorker(config *k8sDiscoveryWorkerConfig, wid string) (*k8sDi
 	}
 
 	// Build all the monitoring worker based on configs.
-	monitoringWorkerMap := make(map[types.MonitorType][]monitoring.MonitoringWorker)
-	for monitorType, configList := range config.monitoringSourceConfigs {
-		monitorList, exist := monitoringWorkerMap[monitorType]
-		if !exist {
-			monitorList = []monitoring.MonitoringWorker{}
+	var monitoringWorkers []monitoring.MonitoringWorker
+	for _, config := range config.monitoringSourceConfigs {
+		monitoringWorker, err := monitoring.BuildMonitorWorker(config.GetMonitoringSource(), config)
+		if err != nil {
+			// TODO return?
+			glog.Errorf("Failed to build monitoring worker configuration: %v", err)
+			continue
 		}
-		for _, config := range configList {
-			monitoringWorker, err := monitoring.BuildMonitorWorker(config.GetMonitoringSource(), config)
-			if err != nil {
-				// TODO return?
-				glog.Errorf("Failed to build monitoring worker configuration: %v", err)
-				continue
-			}
-			monitorList = append(monitorList, monitoringWorker)
-		}
-		monitoringWorkerMap[monitorType] = monitorList
+		monitoringWorkers = append(monitoringWorkers, monitoringWorker)
 	}
 
 	return &k8sDiscoveryWorker{
-		id:               wid,
-		config:           config,
-		monitoringWorker: monitoringWorkerMap,
-		sink:             metrics.NewEntityMetricSink(),
+		id:                wid,
+		config:            config,
+		monitoringWorkers: monitoringWorkers,
+		busyMonitors:      make(map[monitoring.MonitoringWorker]bool),
+		sink:              metrics.NewEntityMetricSink(),
 
 		taskChan: make(chan *task.Task),
 	}, nil
@@ -113,7 +122,13 @@ func (worker *k8sDiscoveryWorker) RegisterAndRun(dispatcher *Dispatcher, collect
 		select {
 		case currTask := <-worker.taskChan:
 			glog.V(2).Infof("Worker %s has received a discovery task.", worker.id)
+			start := time.Now()
 			result := worker.executeTask(currTask)
+			nodeCount := 0
+			if currTask != nil {
+				nodeCount = len(currTask.NodeList())
+			}
+			dispatcher.FinishTask(nodeCount, time.Since(start))
 			collector.ResultPool() <- result
 			glog.V(2).Infof("Worker %s has finished the discovery task.", worker.id)
 
@@ -130,71 +145,160 @@ func (worker *k8sDiscoveryWorker) executeTask(currTask *task.Task) *task.TaskRes
 		return task.NewTaskResult(worker.id, task.TaskFailed).WithErr(err)
 	}
 
+	// The worker executes tasks for different nodes, so the metrics of the previous task are dropped.
+	worker.sink = metrics.NewEntityMetricSink()
+	errorDTOs := worker.scrapeMetrics(currTask, calcTimeOut(len(currTask.NodeList())))
+
+	discoveryTime := time.Now()
+	entityDTOs, err := worker.buildDTOs(currTask, discoveryTime)
+	if err != nil {
+		return task.NewTaskResult(worker.id, task.TaskFailed).WithErr(err)
+	}
+	if worker.config.metricHistory != nil {
+		worker.config.metricHistory.Prune(discoveryTime.Add(-staleMetricHistoryAge))
+	}
+	errorDTOs = append(errorDTOs, getSkippedNodeErrors(currTask.NodeList(), entityDTOs)...)
+	result := task.NewTaskResult(worker.id, task.TaskSucceeded).WithContent(entityDTOs).WithErrorDTOs(errorDTOs...).
+		WithNodeCPUFrequencies(worker.getNodeCPUFrequencies(currTask.NodeList()))
+	return result
+}
+
+// Get the CPU frequency of the given nodes from the sink; the nodes whose frequency is unknown are left out.
+func (worker *k8sDiscoveryWorker) getNodeCPUFrequencies(nodes []*api.Node) map[string]float64 {
+	frequencies := make(map[string]float64)
+	for _, node := range nodes {
+		uid := metrics.GenerateEntityStateMetricUID(task.NodeType, util.NodeKeyFunc(node), metrics.CpuFrequency)
+		metric, err := worker.sink.GetMetric(uid)
+		if err != nil {
+			continue
+		}
+		if frequency, ok := metric.GetValue().(float64); ok && frequency > 0 {
+			frequencies[node.Name] = frequency
+		}
+	}
+	return frequencies
+}
+
+// Run the monitoring workers on the task and merge their metrics into the sink of the worker, in the order of the
+// monitoring configs, so that a monitoring source configured later overrides the same metrics provided by an earlier
+// one. Return the errors of the monitoring sources and the nodes which didn't respond within the time limit.
+func (worker *k8sDiscoveryWorker) scrapeMetrics(currTask *task.Task, timeout time.Duration) []*proto.ErrorDTO {
 	// wait group to make sure metrics scraping finishes.
 	var wg sync.WaitGroup
-	timeout := calcTimeOut(len(currTask.NodeList()))
-
-	// Resource monitoring
-	resourceMonitorTask := currTask
-	//if resourceMonitoringWorkers, exist := worker.monitoringWorker[types.ResourceMonitor]; exist {
-	for _, resourceMonitoringWorkers := range worker.monitoringWorker {
-		for _, rmWorker := range resourceMonitoringWorkers {
-			wg.Add(1)
-			go func(w monitoring.MonitoringWorker) {
-				finishCh := make(chan struct{})
-				stopCh := make(chan struct{}, 1)
-				defer close(finishCh)
-				defer close(stopCh)
-				defer wg.Done()
-
-				w.ReceiveTask(resourceMonitorTask)
-				t := time.NewTimer(timeout)
-				go func() {
-					glog.V(2).Infof("A %s monitoring worker is invoked.", w.GetMonitoringSource())
-					// Assign task to monitoring worker.
-					monitoringSink := w.Do()
-					select {
-					case <-stopCh:
-						//glog.Infof("Calling thread: %s monitoring worker timeout!", w.GetMonitoringSource())
-						return
-					default:
-					}
-					//glog.Infof("%s has finished", w.GetMonitoringSource())
-					t.Stop()
-					// Don't do any filtering
-					worker.sink.MergeSink(monitoringSink, nil)
-					//glog.Infof("send to finish channel %p", finishCh)
-					finishCh <- struct{}{}
-				}()
-
-				// either finish as expected or timeout.
-				select {
-				case <-finishCh:
-					//glog.Infof("Worker %s finished as expected.", w.GetMonitoringSource())
-					return
-				case <-t.C:
-					glog.Errorf("%s monitoring worker exceeds the max time limit for "+
-						"completing the task.", w.GetMonitoringSource())
-					stopCh <- struct{}{}
-					//glog.Infof("%s stop", w.GetMonitoringSource())
-					w.Stop()
-					return
-				}
-			}(rmWorker)
+	// Cancelling the context aborts the in-flight requests of the monitoring workers which exceed the time limit.
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The sinks of the monitoring workers are merged once all of them finish.
+	var sinkLock sync.Mutex
+	monitoringSinks := make(map[monitoring.MonitoringWorker]*metrics.EntityMetricSink)
+	// The nodes which didn't respond in time to each monitoring source, guarded by the sink lock.
+	timedOutNodes := make(map[monitoring.MonitoringWorker][]*api.Node)
+
+	// The monitoring workers still running a previous task.
+	busyMonitors := make(map[monitoring.MonitoringWorker]bool)
+	for _, mWorker := range worker.monitoringWorkers {
+		worker.busyLock.Lock()
+		busy := worker.busyMonitors[mWorker]
+		worker.busyMonitors[mWorker] = true
+		worker.busyLock.Unlock()
+		if busy {
+			busyMonitors[mWorker] = true
+			continue
 		}
+		wg.Add(1)
+		go func(w monitoring.MonitoringWorker) {
+			defer wg.Done()
+			defer func() {
+				worker.busyLock.Lock()
+				delete(worker.busyMonitors, w)
+				worker.busyLock.Unlock()
+			}()
+
+			// Assign task to monitoring worker.
+			w.ReceiveTask(currTask)
+			glog.V(2).Infof("A %s monitoring worker is invoked.", w.GetMonitoringSource())
+			monitoringSink := w.Do(ctx)
+
+			sinkLock.Lock()
+			defer sinkLock.Unlock()
+			if ctx.Err() != nil {
+				// The metrics scraped before the cancellation are incomplete, so they are dropped.
+				return
+			}
+			monitoringSinks[w] = monitoringSink
+			if nodeScrapingWorker, ok := w.(monitoring.NodeScrapingWorker); ok {
+				timedOutNodes[w] = nodeScrapingWorker.TimedOutNodes()
+			}
+		}(mWorker)
 	}
 
-	wg.Wait()
+	// Wait for the monitoring workers until the time limit: a worker which doesn't return once its context is
+	// cancelled, e.g. blocked in a call which cannot be aborted, is left behind with its metrics dropped.
+	finishCh := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finishCh)
+	}()
+	select {
+	case <-finishCh:
+	case <-ctx.Done():
+	}
 
-	entityDTOs, err := worker.buildDTOs(currTask)
-	if err != nil {
-		return task.NewTaskResult(worker.id, task.TaskFailed).WithErr(err)
+	sinkLock.Lock()
+	var errorDTOs []*proto.ErrorDTO
+	for _, w := range worker.monitoringWorkers {
+		if monitoringSink, exist := monitoringSinks[w]; exist {
+			// Don't do any filtering
+			worker.sink.MergeSink(monitoringSink, nil)
+			continue
+		}
+		if busyMonitors[w] {
+			glog.Errorf("%s monitoring worker is still running a previous task.", w.GetMonitoringSource())
+			errorDTOs = append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_WARNING,
+				fmt.Sprintf("%s monitoring of %d nodes is skipped as it is still running a previous task; their "+
+					"metrics are missing.", w.GetMonitoringSource(), len(currTask.NodeList()))))
+			continue
+		}
+		glog.Errorf("%s monitoring worker exceeds the max time limit for completing the task.",
+			w.GetMonitoringSource())
+		errorDTOs = append(errorDTOs, task.NewErrorDTO(proto.ErrorDTO_WARNING,
+			fmt.Sprintf("%s monitoring of %d nodes timed out after %v; their metrics are missing.",
+				w.GetMonitoringSource(), len(currTask.NodeList()), timeout)))
 	}
-	result := task.NewTaskResult(worker.id, task.TaskSucceeded).WithContent(entityDTOs)
-	return result
+	for _, w := range worker.monitoringWorkers {
+		for _, node := range timedOutNodes[w] {
+			errorDTOs = append(errorDTOs, task.NewEntityErrorDTO(proto.ErrorDTO_WARNING,
+				fmt.Sprintf("%s monitoring of node %s timed out; its metrics from this source are missing.",
+					w.GetMonitoringSource(), node.Name), proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID)))
+		}
+	}
+	sinkLock.Unlock()
+
+	return errorDTOs
+}
+
+// Report the nodes for which no entityDTO is built, mostly because their kubelets cannot be reached.
+func getSkippedNodeErrors(nodes []*api.Node, entityDTOs []*proto.EntityDTO) []*proto.ErrorDTO {
+	discovered := make(map[string]bool)
+	for _, e := range entityDTOs {
+		if e.GetEntityType() == proto.EntityDTO_VIRTUAL_MACHINE {
+			discovered[e.GetId()] = true
+		}
+	}
+	var errorDTOs []*proto.ErrorDTO
+	for _, node := range nodes {
+		if discovered[string(node.UID)] {
+			continue
+		}
+		errorDTOs = append(errorDTOs, task.NewEntityErrorDTO(proto.ErrorDTO_WARNING,
+			fmt.Sprintf("Node %s is not discovered: its metrics are missing, e.g. its kubelet is unreachable.", node.Name),
+			proto.EntityDTO_VIRTUAL_MACHINE, string(node.UID)))
+	}
+	return errorDTOs
 }
 
-func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.EntityDTO, error) {
+func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task, discoveryTime time.Time) ([]*proto.EntityDTO, error) {
 	var result []*proto.EntityDTO
 
 	//0. setUp nodeName to nodeId mapping
@@ -209,6 +313,8 @@ func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.Entit
 
 	//1. build entityDTOs for nodes
 	nodeEntityDTOBuilder := dtofactory.NewNodeEntityDTOBuilder(worker.sink, stitchingManager)
+	nodeEntityDTOBuilder.SetMetricHistory(worker.config.metricHistory, discoveryTime, worker.config.usagePercentile)
+	nodeEntityDTOBuilder.SetPods(currTask.NodePodList(), currTask.PodList())
 	nodeEntityDTOs, err := nodeEntityDTOBuilder.BuildEntityDTOs(nodes)
 	if err != nil {
 		glog.Errorf("Error while creating node entityDTOs: %v", err)
@@ -220,6 +326,8 @@ func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.Entit
 	//2. build entityDTOs for pods
 	pods := currTask.PodList()
 	podEntityDTOBuilder := dtofactory.NewPodEntityDTOBuilder(worker.sink, stitchingManager, nodeNameUIDMap)
+	podEntityDTOBuilder.SetMetricHistory(worker.config.metricHistory, discoveryTime, worker.config.usagePercentile)
+	podEntityDTOBuilder.SetPersistentVolumes(currTask.PersistentVolumes())
 	podEntityDTOs, err := podEntityDTOBuilder.BuildEntityDTOs(pods)
 	if err != nil {
 		glog.Errorf("Error while creating pod entityDTOs: %v", err)
@@ -229,6 +337,7 @@ func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.Entit
 
 	//3. build entityDTOs for containers
 	containerDTOBuilder := dtofactory.NewContainerDTOBuilder(worker.sink)
+	containerDTOBuilder.SetMetricHistory(worker.config.metricHistory, discoveryTime, worker.config.usagePercentile)
 	containerDTOs, err := containerDTOBuilder.BuildDTOs(pods)
 	if err != nil {
 		glog.Errorf("Error while createing container entityDTOs: %v", err)
@@ -238,6 +347,7 @@ func (worker *k8sDiscoveryWorker) buildDTOs(currTask *task.Task) ([]*proto.Entit
 
 	//4. build entityDTOs for applications
 	applicationEntityDTOBuilder := dtofactory.NewApplicationEntityDTOBuilder(worker.sink)
+	applicationEntityDTOBuilder.SetMetricHistory(worker.config.metricHistory, discoveryTime, worker.config.usagePercentile)
 	appEntityDTOs, err := applicationEntityDTOBuilder.BuildEntityDTOs(pods)
 	if err != nil {
 		glog.Errorf("Error while creating application entityDTOs: %v", err)
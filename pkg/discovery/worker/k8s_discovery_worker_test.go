@@ -1,8 +1,22 @@
 package worker
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/metrics"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring"
+	mtypes "github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/types"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
 )
 
 func TestCalTimeOut(t *testing.T) {
@@ -37,3 +51,139 @@ func TestCalTimeOut(t *testing.T) {
 		}
 	}
 }
+
+func TestGetSkippedNodeErrors(t *testing.T) {
+	nodes := []*api.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: types.UID("uid-1")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2", UID: types.UID("uid-2")}},
+	}
+	nodeDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, "uid-1").Create()
+	if err != nil {
+		t.Fatalf("Failed to build node entityDTO: %v", err)
+	}
+
+	errorDTOs := getSkippedNodeErrors(nodes, []*proto.EntityDTO{nodeDTO})
+	if len(errorDTOs) != 1 {
+		t.Fatalf("Expected 1 skipped node, got %d", len(errorDTOs))
+	}
+	errorDTO := errorDTOs[0]
+	if errorDTO.GetEntityUuid() != "uid-2" || errorDTO.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE.String() ||
+		errorDTO.GetSeverity() != proto.ErrorDTO_WARNING {
+		t.Errorf("Unexpected error %+v", errorDTO)
+	}
+}
+
+// A monitoring worker reporting the same CPU used of a node as the other sources, with a value of its own.
+type fakeMonitoringWorker struct {
+	source  mtypes.MonitoringSource
+	cpuUsed float64
+}
+
+func (m *fakeMonitoringWorker) Do(ctx context.Context) *metrics.EntityMetricSink {
+	sink := metrics.NewEntityMetricSink()
+	sink.AddNewMetricEntries(metrics.NewEntityResourceMetric(task.NodeType, "node-1", metrics.CPU, metrics.Used,
+		m.cpuUsed))
+	return sink
+}
+
+func (m *fakeMonitoringWorker) ReceiveTask(task *task.Task) {}
+
+func (m *fakeMonitoringWorker) GetMonitoringSource() mtypes.MonitoringSource {
+	return m.source
+}
+
+func TestExecuteTask_MonitoringSourcePrecedence(t *testing.T) {
+	worker := &k8sDiscoveryWorker{
+		id:     "w0",
+		config: NewK8sDiscoveryWorkerConfig(stitching.IP),
+		monitoringWorkers: []monitoring.MonitoringWorker{
+			&fakeMonitoringWorker{source: mtypes.KubeletSource, cpuUsed: 1},
+			&fakeMonitoringWorker{source: mtypes.ClusterSource, cpuUsed: 2},
+			&fakeMonitoringWorker{source: mtypes.PrometheusSource, cpuUsed: 3},
+		},
+		busyMonitors: make(map[monitoring.MonitoringWorker]bool),
+	}
+	uid := metrics.GenerateEntityResourceMetricUID(task.NodeType, "node-1", metrics.CPU, metrics.Used)
+	// Repeated, as an order which is not enforced could still be right once by chance.
+	for i := 0; i < 10; i++ {
+		worker.executeTask(task.NewTask())
+		metric, err := worker.sink.GetMetric(uid)
+		if err != nil {
+			t.Fatalf("Metric %s not found: %v", uid, err)
+		}
+		if v := metric.GetValue().(float64); v != 3 {
+			t.Fatalf("Expected the metric of the last monitoring source, got %f", v)
+		}
+	}
+}
+
+// A monitoring worker blocked in a call which ignores the cancellation, until it is released.
+type hungMonitoringWorker struct {
+	fakeMonitoringWorker
+	release chan struct{}
+}
+
+func (m *hungMonitoringWorker) Do(ctx context.Context) *metrics.EntityMetricSink {
+	<-m.release
+	return m.fakeMonitoringWorker.Do(ctx)
+}
+
+func TestScrapeMetrics_HungMonitoringWorker(t *testing.T) {
+	hung := &hungMonitoringWorker{
+		fakeMonitoringWorker: fakeMonitoringWorker{source: mtypes.ClusterSource, cpuUsed: 2},
+		release:              make(chan struct{}),
+	}
+	worker := &k8sDiscoveryWorker{
+		id:     "w0",
+		config: NewK8sDiscoveryWorkerConfig(stitching.IP),
+		monitoringWorkers: []monitoring.MonitoringWorker{
+			&fakeMonitoringWorker{source: mtypes.KubeletSource, cpuUsed: 1},
+			hung,
+		},
+		busyMonitors: make(map[monitoring.MonitoringWorker]bool),
+	}
+	uid := metrics.GenerateEntityResourceMetricUID(task.NodeType, "node-1", metrics.CPU, metrics.Used)
+	checkCPUUsed := func(expected float64) {
+		metric, err := worker.sink.GetMetric(uid)
+		if err != nil {
+			t.Fatalf("Metric %s not found: %v", uid, err)
+		}
+		if v := metric.GetValue().(float64); v != expected {
+			t.Errorf("Expected CPU used %f, got %f", expected, v)
+		}
+	}
+
+	// The hung worker is left behind once the time limit is reached.
+	worker.sink = metrics.NewEntityMetricSink()
+	errorDTOs := worker.scrapeMetrics(task.NewTask(), 50*time.Millisecond)
+	if len(errorDTOs) != 1 {
+		t.Errorf("Expected 1 timed out monitoring source, got %v", errorDTOs)
+	}
+	checkCPUUsed(1)
+
+	// It is not given the next task while it is still running.
+	worker.sink = metrics.NewEntityMetricSink()
+	errorDTOs = worker.scrapeMetrics(task.NewTask(), time.Minute)
+	if len(errorDTOs) != 1 {
+		t.Errorf("Expected 1 skipped monitoring source, got %v", errorDTOs)
+	}
+	checkCPUUsed(1)
+
+	// Once released, it is back in use.
+	close(hung.release)
+	for i := 0; i < 100; i++ {
+		worker.busyLock.Lock()
+		busy := worker.busyMonitors[hung]
+		worker.busyLock.Unlock()
+		if !busy {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	worker.sink = metrics.NewEntityMetricSink()
+	errorDTOs = worker.scrapeMetrics(task.NewTask(), time.Minute)
+	if len(errorDTOs) != 0 {
+		t.Errorf("Expected no error, got %v", errorDTOs)
+	}
+	checkCPUUsed(2)
+}
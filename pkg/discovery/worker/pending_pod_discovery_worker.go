@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"fmt"
+
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	k8sPendingPodDiscWorkerID string = "PendingPodDiscoveryWorker"
+)
+
+type k8sPendingPodDiscoveryWorkerConfig struct {
+	k8sClusterScraper *cluster.ClusterScraper
+
+	// The CPU frequencies of the nodes in MHz, indexed by node name, to convert the CPU requests of the pods.
+	nodeCPUFrequencies map[string]float64
+}
+
+func NewK8sPendingPodDiscoveryWorkerConfig(k8sClusterScraper *cluster.ClusterScraper) *k8sPendingPodDiscoveryWorkerConfig {
+	return &k8sPendingPodDiscoveryWorkerConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+func (c *k8sPendingPodDiscoveryWorkerConfig) WithNodeCPUFrequencies(
+	nodeCPUFrequencies map[string]float64) *k8sPendingPodDiscoveryWorkerConfig {
+	c.nodeCPUFrequencies = nodeCPUFrequencies
+	return c
+}
+
+// Pending pod discovery worker builds the entityDTOs of the pods which cannot be scheduled to any node.
+// These pods are not discovered by the node level workers, as they are not running on any node.
+type k8sPendingPodDiscoveryWorker struct {
+	id string
+
+	config *k8sPendingPodDiscoveryWorkerConfig
+}
+
+func NewK8sPendingPodDiscoveryWorker(config *k8sPendingPodDiscoveryWorkerConfig) *k8sPendingPodDiscoveryWorker {
+	return &k8sPendingPodDiscoveryWorker{
+		id:     k8sPendingPodDiscWorkerID,
+		config: config,
+	}
+}
+
+// Create the entityDTOs of the pending pods, based on the node entityDTOs already discovered. They are built before
+// the compliance processors run, so that the processors also handle the pods which have no node.
+func (w *k8sPendingPodDiscoveryWorker) Do(entityDTOs []*proto.EntityDTO) *task.TaskResult {
+	pods, err := w.config.k8sClusterScraper.GetPendingPods()
+	if err != nil {
+		return task.NewTaskResult(w.id, task.TaskFailed).WithErr(err)
+	}
+	if len(pods) == 0 {
+		return task.NewTaskResult(w.id, task.TaskSucceeded).WithContent([]*proto.EntityDTO{})
+	}
+	// The scheduling failures are only informative; the pods are still discovered without them.
+	failures, err := w.config.k8sClusterScraper.GetPodSchedulingFailures(pods)
+	if err != nil {
+		glog.Errorf("Failed to get pod scheduling failures: %s", err)
+		failures = make(map[string]string)
+	}
+
+	podDTOs, err := w.buildPendingPodEntityDTOs(pods, failures, entityDTOs)
+	if err != nil {
+		return task.NewTaskResult(w.id, task.TaskFailed).WithErr(err)
+	}
+	glog.V(3).Infof("There are %d pending pod entityDTOs", len(podDTOs))
+	return task.NewTaskResult(w.id, task.TaskSucceeded).WithContent(podDTOs)
+}
+
+func (w *k8sPendingPodDiscoveryWorker) buildPendingPodEntityDTOs(pods []*api.Pod, failures map[string]string,
+	entityDTOs []*proto.EntityDTO) ([]*proto.EntityDTO, error) {
+	// The node of a pending pod is not known yet: its CPU request is converted with the average node frequency.
+	cpuFrequency := averageCPUFrequency(w.config.nodeCPUFrequencies)
+	if cpuFrequency <= 0 {
+		return nil, fmt.Errorf("failed to get the CPU frequency of nodes")
+	}
+
+	builder := dtofactory.NewPendingPodEntityDTOBuilder(cpuFrequency, getClusterKey(entityDTOs))
+	var result []*proto.EntityDTO
+	for _, pod := range pods {
+		failure, exist := failures[string(pod.UID)]
+		if !exist {
+			failure = getPodSchedulingConditionMessage(pod)
+		}
+		podDTO, err := builder.BuildEntityDTO(pod, failure)
+		if err != nil {
+			glog.Errorf("Failed to build entityDTO for pending pod %s: %s", util.GetPodClusterID(pod), err)
+			continue
+		}
+		result = append(result, podDTO)
+	}
+	return result, nil
+}
+
+func averageCPUFrequency(nodeCPUFrequencies map[string]float64) float64 {
+	total := 0.0
+	count := 0
+	for _, frequency := range nodeCPUFrequencies {
+		if frequency > 0 {
+			total += frequency
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// Get the key of the cluster commodity sold by the nodes.
+func getClusterKey(entityDTOs []*proto.EntityDTO) string {
+	for _, e := range entityDTOs {
+		if e.GetEntityType() != proto.EntityDTO_VIRTUAL_MACHINE {
+			continue
+		}
+		for _, sold := range e.GetCommoditiesSold() {
+			if sold.GetCommodityType() == proto.CommodityDTO_CLUSTER {
+				return sold.GetKey()
+			}
+		}
+	}
+	return ""
+}
+
+// Get the message of the PodScheduled condition, which is set by the scheduler when the pod cannot be scheduled.
+func getPodSchedulingConditionMessage(pod *api.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == api.PodScheduled {
+			return condition.Message
+		}
+	}
+	return ""
+}
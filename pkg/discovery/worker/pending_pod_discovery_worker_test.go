@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	goproto "github.com/golang/protobuf/proto"
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestBuildPendingPodEntityDTOs(t *testing.T) {
+	cluster, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_CLUSTER).Key("cluster-1").Capacity(1e10).Create()
+	nodeDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_VIRTUAL_MACHINE, "node-uid").
+		SellsCommodities([]*proto.CommodityDTO{cluster}).
+		Create()
+	if err != nil {
+		t.Fatalf("Failed to build node entityDTO: %v", err)
+	}
+
+	newPod := func(name, uid, message string) *api.Pod {
+		return &api.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(uid)},
+			Spec: api.PodSpec{
+				Containers: []api.Container{{
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{
+							api.ResourceCPU:    resource.MustParse("500m"),
+							api.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				}},
+			},
+			Status: api.PodStatus{
+				Phase: api.PodPending,
+				Conditions: []api.PodCondition{{
+					Type:    api.PodScheduled,
+					Status:  api.ConditionFalse,
+					Reason:  api.PodReasonUnschedulable,
+					Message: message,
+				}},
+			},
+		}
+	}
+	pods := []*api.Pod{
+		newPod("web-1", "uid-1", "0/1 nodes are available: 1 Insufficient cpu."),
+		newPod("web-2", "uid-2", "No nodes are available that match all of the predicates."),
+	}
+	failures := map[string]string{"uid-1": "0/3 nodes are available: 3 Insufficient memory."}
+
+	config := NewK8sPendingPodDiscoveryWorkerConfig(nil).
+		WithNodeCPUFrequencies(map[string]float64{"node-1": 1500, "node-2": 2500})
+	w := NewK8sPendingPodDiscoveryWorker(config)
+	podDTOs, err := w.buildPendingPodEntityDTOs(pods, failures, []*proto.EntityDTO{nodeDTO})
+	if err != nil {
+		t.Fatalf("Failed to build pending pod entityDTOs: %v", err)
+	}
+	if len(podDTOs) != 2 {
+		t.Fatalf("Expected 2 pending pod entityDTOs, got %d", len(podDTOs))
+	}
+
+	// The failure from the events is preferred over the message of the pod condition.
+	expectedFailures := map[string]string{
+		"uid-1": "0/3 nodes are available: 3 Insufficient memory.",
+		"uid-2": "No nodes are available that match all of the predicates.",
+	}
+	for _, podDTO := range podDTOs {
+		// The pod is unplaced: it buys its requests from no node.
+		bought := podDTO.GetCommoditiesBought()
+		if len(bought) != 1 || bought[0].GetProviderId() != "" ||
+			bought[0].GetProviderType() != proto.EntityDTO_VIRTUAL_MACHINE {
+			t.Fatalf("Expected pod %s to be unplaced, got %v", podDTO.GetId(), bought)
+		}
+		commodities := make(map[proto.CommodityDTO_CommodityType]*proto.CommodityDTO)
+		for _, comm := range bought[0].GetBought() {
+			commodities[comm.GetCommodityType()] = comm
+		}
+		// 0.5 core on nodes at 2000MHz on average.
+		for _, cType := range []proto.CommodityDTO_CommodityType{proto.CommodityDTO_VCPU, proto.CommodityDTO_CPU_PROVISIONED} {
+			if used := commodities[cType].GetUsed(); used != 1000 {
+				t.Errorf("Expected %s used 1000, got %v", cType, used)
+			}
+		}
+		for _, cType := range []proto.CommodityDTO_CommodityType{proto.CommodityDTO_VMEM, proto.CommodityDTO_MEM_PROVISIONED} {
+			if used := commodities[cType].GetUsed(); used != 1024*1024 {
+				t.Errorf("Expected %s used 1048576, got %v", cType, used)
+			}
+		}
+		if key := commodities[proto.CommodityDTO_CLUSTER].GetKey(); key != "cluster-1" {
+			t.Errorf("Expected cluster key cluster-1, got %q", key)
+		}
+		// The unplaced purchase is still a valid message.
+		if _, err := goproto.Marshal(podDTO); err != nil {
+			t.Errorf("Failed to marshal the entityDTO of pod %s: %v", podDTO.GetId(), err)
+		}
+
+		failure := ""
+		for _, p := range podDTO.GetEntityProperties() {
+			if p.GetName() == "KubernetesSchedulingFailure" {
+				failure = p.GetValue()
+			}
+		}
+		if failure != expectedFailures[podDTO.GetId()] {
+			t.Errorf("Expected scheduling failure %q for pod %s, got %q", expectedFailures[podDTO.GetId()],
+				podDTO.GetId(), failure)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"fmt"
 	"strings"
 	"sync"
 
@@ -26,8 +27,13 @@ func (rc *ResultCollector) ResultPool() chan *task.TaskResult {
 	return rc.resultPool
 }
 
-func (rc *ResultCollector) Collect(count int) []*proto.EntityDTO {
+// Collect the entityDTOs built by the given number of workers, and the errors met by them: a failed worker is a
+// critical error, as the entities of all its nodes are missing. The CPU frequency of the discovered nodes, keyed by
+// node name, is also returned.
+func (rc *ResultCollector) Collect(count int) ([]*proto.EntityDTO, []*proto.ErrorDTO, map[string]float64) {
 	discoveryResult := []*proto.EntityDTO{}
+	discoveryErrors := []*proto.ErrorDTO{}
+	nodeCPUFrequencies := make(map[string]float64)
 	discoveryErrorString := []string{}
 
 	glog.V(2).Infof("Waiting for results from %d workers.", count)
@@ -43,9 +49,15 @@ func (rc *ResultCollector) Collect(count int) []*proto.EntityDTO {
 			case result := <-rc.resultPool:
 				if err := result.Err(); err != nil {
 					discoveryErrorString = append(discoveryErrorString, err.Error())
+					discoveryErrors = append(discoveryErrors, task.NewErrorDTO(proto.ErrorDTO_CRITICAL,
+						fmt.Sprintf("Discovery worker %s failed: %s", result.WorkerID(), err)))
 				} else {
 					discoveryResult = append(discoveryResult, result.Content()...)
+					for nodeName, frequency := range result.NodeCPUFrequencies() {
+						nodeCPUFrequencies[nodeName] = frequency
+					}
 				}
+				discoveryErrors = append(discoveryErrors, result.ErrorDTOs()...)
 				wg.Done()
 			}
 		}
@@ -59,5 +71,5 @@ func (rc *ResultCollector) Collect(count int) []*proto.EntityDTO {
 		glog.Errorf("One or more discovery worker failed: %s", strings.Join(discoveryErrorString, "\t\t"))
 	}
 
-	return discoveryResult
+	return discoveryResult, discoveryErrors, nodeCPUFrequencies
 }
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestResultCollector_Collect(t *testing.T) {
+	collector := NewResultCollector(3)
+	warning := task.NewErrorDTO(proto.ErrorDTO_WARNING, "kubelet monitoring timed out")
+	collector.ResultPool() <- task.NewTaskResult("worker-1", task.TaskSucceeded).
+		WithContent([]*proto.EntityDTO{{}, {}}).
+		WithErrorDTOs(warning).
+		WithNodeCPUFrequencies(map[string]float64{"node-1": 2600})
+	collector.ResultPool() <- task.NewTaskResult("worker-2", task.TaskFailed).WithErr(errors.New("no task"))
+	collector.ResultPool() <- task.NewTaskResult("worker-3", task.TaskSucceeded).WithContent([]*proto.EntityDTO{{}}).
+		WithNodeCPUFrequencies(map[string]float64{"node-2": 2000})
+
+	entityDTOs, errorDTOs, nodeCPUFrequencies := collector.Collect(3)
+	if len(nodeCPUFrequencies) != 2 || nodeCPUFrequencies["node-1"] != 2600 || nodeCPUFrequencies["node-2"] != 2000 {
+		t.Errorf("Expected the CPU frequencies of node-1 and node-2, got %v", nodeCPUFrequencies)
+	}
+	if len(entityDTOs) != 3 {
+		t.Errorf("Expected 3 entityDTOs, got %d", len(entityDTOs))
+	}
+	if len(errorDTOs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d", len(errorDTOs))
+	}
+	severities := map[proto.ErrorDTO_ErrorSeverity]int{}
+	for _, errorDTO := range errorDTOs {
+		severities[errorDTO.GetSeverity()]++
+	}
+	if severities[proto.ErrorDTO_CRITICAL] != 1 || severities[proto.ErrorDTO_WARNING] != 1 {
+		t.Errorf("Expected 1 critical error and 1 warning, got %v", errorDTOs)
+	}
+}
@@ -0,0 +1,98 @@
+package worker
+
+import (
+	api "k8s.io/client-go/pkg/api/v1"
+
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/task"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/util"
+
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+
+	"github.com/golang/glog"
+)
+
+const (
+	k8sStorageDiscWorkerID string = "StorageDiscoveryWorker"
+)
+
+type k8sStorageDiscoveryWorkerConfig struct {
+	k8sClusterScraper *cluster.ClusterScraper
+}
+
+func NewK8sStorageDiscoveryWorkerConfig(k8sClusterScraper *cluster.ClusterScraper) *k8sStorageDiscoveryWorkerConfig {
+	return &k8sStorageDiscoveryWorkerConfig{
+		k8sClusterScraper: k8sClusterScraper,
+	}
+}
+
+// Storage discovery worker builds the entityDTOs of the persistent volumes bound to the claims in scope.
+// The pods mounting a volume already buy its storage; they are found by the provider of their commodities.
+type k8sStorageDiscoveryWorker struct {
+	id string
+
+	config *k8sStorageDiscoveryWorkerConfig
+
+	storageDTOBuilder *dtofactory.StorageEntityDTOBuilder
+}
+
+func NewK8sStorageDiscoveryWorker(config *k8sStorageDiscoveryWorkerConfig) *k8sStorageDiscoveryWorker {
+	return &k8sStorageDiscoveryWorker{
+		id:                k8sStorageDiscWorkerID,
+		config:            config,
+		storageDTOBuilder: dtofactory.NewStorageEntityDTOBuilder(),
+	}
+}
+
+// Post-process the entityDTOs and create the persistent volume entityDTOs.
+func (w *k8sStorageDiscoveryWorker) Do(entityDTOs []*proto.EntityDTO) *task.TaskResult {
+	volumesByClaim, err := w.config.k8sClusterScraper.GetPersistentVolumesByClaim()
+	if err != nil {
+		return task.NewTaskResult(w.id, task.TaskFailed).WithErr(err)
+	}
+	// The provisioner is only informative; the volumes are still discovered without it.
+	provisioners := make(map[string]string)
+	storageClasses, err := w.config.k8sClusterScraper.GetAllStorageClasses()
+	if err != nil {
+		glog.Errorf("Failed to get storage classes: %s", err)
+	}
+	for _, sc := range storageClasses {
+		provisioners[sc.Name] = sc.Provisioner
+	}
+
+	storageDTOs := w.buildStorageEntityDTOs(volumesByClaim, provisioners, entityDTOs)
+	glog.V(3).Infof("There are %d persistent volume entityDTOs", len(storageDTOs))
+	return task.NewTaskResult(w.id, task.TaskSucceeded).WithContent(storageDTOs)
+}
+
+func (w *k8sStorageDiscoveryWorker) buildStorageEntityDTOs(volumesByClaim map[string]*api.PersistentVolume,
+	provisioners map[string]string, entityDTOs []*proto.EntityDTO) []*proto.EntityDTO {
+	// The pods buying from each persistent volume, by volume UID.
+	volumePodDTOs := make(map[string][]*proto.EntityDTO)
+	for _, pv := range volumesByClaim {
+		volumePodDTOs[string(pv.UID)] = nil
+	}
+	for _, e := range entityDTOs {
+		if e.GetEntityType() != proto.EntityDTO_CONTAINER_POD {
+			continue
+		}
+		for _, bought := range e.GetCommoditiesBought() {
+			if podDTOs, exist := volumePodDTOs[bought.GetProviderId()]; exist {
+				volumePodDTOs[bought.GetProviderId()] = append(podDTOs, e)
+			}
+		}
+	}
+
+	var result []*proto.EntityDTO
+	for _, pv := range volumesByClaim {
+		provisioner := provisioners[util.GetPersistentVolumeClass(pv)]
+		entityDTO, err := w.storageDTOBuilder.BuildEntityDTO(pv, provisioner, volumePodDTOs[string(pv.UID)])
+		if err != nil {
+			glog.Errorf("Failed to build persistent volume entityDTO: %s", err)
+			continue
+		}
+		result = append(result, entityDTO)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	api "k8s.io/client-go/pkg/api/v1"
+
+	sdkbuilder "github.com/turbonomic/turbo-go-sdk/pkg/builder"
+	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
+)
+
+func TestBuildStorageEntityDTOs(t *testing.T) {
+	pv := &api.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-2f1e", UID: types.UID("pv-uid")},
+		Spec: api.PersistentVolumeSpec{
+			Capacity:         api.ResourceList{api.ResourceStorage: resource.MustParse("10Gi")},
+			ClaimRef:         &api.ObjectReference{Namespace: "default", Name: "data"},
+			StorageClassName: "fast",
+		},
+	}
+	volumesByClaim := map[string]*api.PersistentVolume{"default/data": pv}
+
+	var podDTOs []*proto.EntityDTO
+	for i, used := range []float64{100, 300} {
+		storage, _ := sdkbuilder.NewCommodityDTOBuilder(proto.CommodityDTO_STORAGE_AMOUNT).Used(used).Create()
+		podDTO, err := sdkbuilder.NewEntityDTOBuilder(proto.EntityDTO_CONTAINER_POD, string(rune('a'+i))).
+			Provider(sdkbuilder.CreateProvider(proto.EntityDTO_STORAGE, string(pv.UID))).
+			BuysCommodity(storage).
+			Create()
+		if err != nil {
+			t.Fatalf("Failed to build pod entityDTO: %v", err)
+		}
+		podDTOs = append(podDTOs, podDTO)
+	}
+
+	w := NewK8sStorageDiscoveryWorker(NewK8sStorageDiscoveryWorkerConfig(nil))
+	storageDTOs := w.buildStorageEntityDTOs(volumesByClaim, map[string]string{"fast": "kubernetes.io/gce-pd"}, podDTOs)
+	if len(storageDTOs) != 1 {
+		t.Fatalf("Expected 1 storage entityDTO, got %d", len(storageDTOs))
+	}
+	storageDTO := storageDTOs[0]
+	if storageDTO.GetId() != string(pv.UID) || storageDTO.GetEntityType() != proto.EntityDTO_STORAGE {
+		t.Errorf("Unexpected storage entityDTO %s of type %s", storageDTO.GetId(), storageDTO.GetEntityType())
+	}
+
+	sold := storageDTO.GetCommoditiesSold()
+	if len(sold) != 1 || sold[0].GetCommodityType() != proto.CommodityDTO_STORAGE_AMOUNT {
+		t.Fatalf("Expected storage amount sold, got %v", sold)
+	}
+	// The pods mounting the same volume see the same filesystem.
+	if sold[0].GetUsed() != 300 || sold[0].GetCapacity() != 10*1024 {
+		t.Errorf("Expected used 300 and capacity 10240, got %v and %v", sold[0].GetUsed(), sold[0].GetCapacity())
+	}
+
+	expectedProperties := map[string]string{
+		"KubernetesPersistentVolumeName":  "pvc-2f1e",
+		"KubernetesPersistentVolumeClaim": "data",
+		"KubernetesStorageClass":          "fast",
+		"KubernetesStorageProvisioner":    "kubernetes.io/gce-pd",
+	}
+	properties := make(map[string]string)
+	for _, p := range storageDTO.GetEntityProperties() {
+		properties[p.GetName()] = p.GetValue()
+	}
+	for name, value := range expectedProperties {
+		if properties[name] != value {
+			t.Errorf("Expected property %s=%s, got %q", name, value, properties[name])
+		}
+	}
+}
@@ -9,8 +9,10 @@ import (
 	client "k8s.io/client-go/kubernetes"
 
 	"github.com/turbonomic/kubeturbo/pkg/action"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
+	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/registration"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/probe"
@@ -68,10 +70,11 @@ type K8sTAPServiceConfig struct {
 	discoveryClientConfig    *discovery.DiscoveryClientConfig
 }
 
-func NewK8sTAPServiceConfig(kubeClient *client.Clientset, probeConfig *configs.ProbeConfig,
-	spec *K8sTAPServiceSpec) *K8sTAPServiceConfig {
+func NewK8sTAPServiceConfig(kubeClient *client.Clientset, clusterCache *cluster.ClusterCache, kubeletClient *kubelet.KubeletClient,
+	probeConfig *configs.ProbeConfig, spec *K8sTAPServiceSpec) *K8sTAPServiceConfig {
 	registrationClientConfig := registration.NewRegistrationClientConfig(probeConfig.StitchingPropertyType)
-	discoveryClientConfig := discovery.NewDiscoveryConfig(kubeClient, probeConfig, spec.K8sTargetConfig)
+	discoveryClientConfig := discovery.NewDiscoveryConfig(kubeClient, clusterCache, probeConfig, spec.K8sTargetConfig).
+		WithKubeletClient(kubeletClient)
 	return &K8sTAPServiceConfig{
 		spec: spec,
 		registrationClientConfig: registrationClientConfig,
@@ -28,12 +28,15 @@ func NewKubeturboService(c *Config) *KubeturboService {
 	turboScheduler := turboscheduler.NewTurboScheduler(c.Client, c.tapSpec.TurboServer,
 		c.tapSpec.OpsManagerUsername, c.tapSpec.OpsManagerPassword)
 
+	glog.V(2).Infof("Kubeturbo discovers and acts on: %v", c.tapSpec.Scope())
+
 	// Create action handler.
 	stype := c.ProbeConfig.StitchingPropertyType
-	actionHandlerConfig := action.NewActionHandlerConfig(c.Client, c.KubeletClient, c.k8sVersion, c.noneSchedulerName, stype)
+	actionHandlerConfig := action.NewActionHandlerConfig(c.Client, c.ClusterCache, c.KubeletClient, c.k8sVersion, c.noneSchedulerName, stype).
+		WithScope(c.tapSpec.Scope())
 	actionHandler := action.NewActionHandler(actionHandlerConfig)
 
-	k8sTAPServiceConfig := NewK8sTAPServiceConfig(c.Client, c.ProbeConfig, c.tapSpec)
+	k8sTAPServiceConfig := NewK8sTAPServiceConfig(c.Client, c.ClusterCache, c.KubeletClient, c.ProbeConfig, c.tapSpec)
 	k8sTAPService, err := NewKubernetesTAPService(k8sTAPServiceConfig, actionHandler)
 	if err != nil {
 		glog.Fatalf("Unexpected error while creating Kuberntes TAP service: %s", err)
@@ -8,6 +8,7 @@ import (
 	"k8s.io/client-go/tools/record"
 
 	vmtcache "github.com/turbonomic/kubeturbo/pkg/cache"
+	"github.com/turbonomic/kubeturbo/pkg/cluster"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/configs"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/monitoring/kubelet"
 	"github.com/turbonomic/kubeturbo/pkg/turbostore"
@@ -23,6 +24,9 @@ type Config struct {
 	Client        *client.Clientset
 	KubeletClient *kubelet.KubeletClient
 
+	// Shared informer cache of the cluster objects, used by both discovery and action execution.
+	ClusterCache *cluster.ClusterCache
+
 	//TODO: delete these two
 	// they were used for rescheduler, but they are useless now.
 	NodeQueue *vmtcache.HashedFIFO
@@ -63,6 +67,11 @@ func (c *Config) WithKubeletClient(client *kubelet.KubeletClient) *Config {
 	return c
 }
 
+func (c *Config) WithClusterCache(clusterCache *cluster.ClusterCache) *Config {
+	c.ClusterCache = clusterCache
+	return c
+}
+
 func (c *Config) WithProbeConfig(pconfig *configs.ProbeConfig) *Config {
 	c.ProbeConfig = pconfig
 	return c
@@ -3,6 +3,7 @@ package registration
 import (
 	"fmt"
 
+	"github.com/turbonomic/kubeturbo/pkg/discovery/dtofactory"
 	"github.com/turbonomic/kubeturbo/pkg/discovery/stitching"
 
 	"github.com/turbonomic/turbo-go-sdk/pkg/proto"
@@ -15,6 +16,11 @@ var (
 	cpuProvisionedType proto.CommodityDTO_CommodityType = proto.CommodityDTO_CPU_PROVISIONED
 	memProvisionedType proto.CommodityDTO_CommodityType = proto.CommodityDTO_MEM_PROVISIONED
 	transactionType    proto.CommodityDTO_CommodityType = proto.CommodityDTO_TRANSACTION
+	responseTimeType   proto.CommodityDTO_CommodityType = proto.CommodityDTO_RESPONSE_TIME
+	netThroughputType  proto.CommodityDTO_CommodityType = proto.CommodityDTO_NET_THROUGHPUT
+	storageAmountType  proto.CommodityDTO_CommodityType = proto.CommodityDTO_STORAGE_AMOUNT
+	cpuAllocationType  proto.CommodityDTO_CommodityType = proto.CommodityDTO_CPU_ALLOCATION
+	memAllocationType  proto.CommodityDTO_CommodityType = proto.CommodityDTO_MEM_ALLOCATION
 
 	clusterType    proto.CommodityDTO_CommodityType = proto.CommodityDTO_CLUSTER
 	appCommType    proto.CommodityDTO_CommodityType = proto.CommodityDTO_APPLICATION
@@ -26,10 +32,19 @@ var (
 	vMemTemplateComm           *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &vMemType}
 	cpuProvisionedTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &cpuProvisionedType}
 	memProvisionedTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &memProvisionedType}
+	netThroughputTemplateComm  *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &netThroughputType}
+	storageAmountTemplateComm  *proto.TemplateCommodity = &proto.TemplateCommodity{CommodityType: &storageAmountType}
 	applicationTemplateComm    *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &appCommType}
 	clusterTemplateComm        *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &clusterType}
 	transactionTemplateComm    *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &transactionType}
+	responseTimeTemplateComm   *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &responseTimeType}
 	vmpmAccessTemplateComm     *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &vmPMAccessType}
+
+	// Quota commodities are keyed by the quota of the namespace.
+	cpuAllocationTemplateComm       *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &cpuAllocationType}
+	memAllocationTemplateComm       *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &memAllocationType}
+	cpuProvisionedQuotaTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &cpuProvisionedType}
+	memProvisionedQuotaTemplateComm *proto.TemplateCommodity = &proto.TemplateCommodity{Key: &fakeKey, CommodityType: &memProvisionedType}
 )
 
 type SupplyChainFactory struct {
@@ -56,6 +71,24 @@ func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 		return nil, err
 	}
 
+	// Quota supply chain builder
+	quotaSupplyChainNodeBuilder, err := f.buildQuotaSupplyBuilder()
+	if err != nil {
+		return nil, err
+	}
+
+	// Workload controller supply chain builder
+	controllerSupplyChainNodeBuilder, err := f.buildWorkloadControllerSupplyBuilder()
+	if err != nil {
+		return nil, err
+	}
+
+	// Persistent volume supply chain builder
+	storageSupplyChainNodeBuilder, err := f.buildStorageSupplyBuilder()
+	if err != nil {
+		return nil, err
+	}
+
 	// Container suplly chain builder
 	containerSupplyChainNodeBuilder, err := f.buildContainer()
 	if err != nil {
@@ -80,6 +113,9 @@ func (f *SupplyChainFactory) createSupplyChain() ([]*proto.TemplateDTO, error) {
 	supplyChainBuilder.Entity(containerSupplyChainNodeBuilder)
 	supplyChainBuilder.Entity(podSupplyChainNodeBuilder)
 	supplyChainBuilder.Entity(nodeSupplyChainNodeBuilder)
+	supplyChainBuilder.Entity(quotaSupplyChainNodeBuilder)
+	supplyChainBuilder.Entity(controllerSupplyChainNodeBuilder)
+	supplyChainBuilder.Entity(storageSupplyChainNodeBuilder)
 
 	return supplyChainBuilder.Create()
 }
@@ -89,9 +125,10 @@ func (f *SupplyChainFactory) buildNodeSupplyBuilder() (*proto.TemplateDTO, error
 	nodeSupplyChainNodeBuilder = nodeSupplyChainNodeBuilder.
 		Sells(vCpuTemplateComm).
 		Sells(vMemTemplateComm).
-		// TODO we will re-include provisioned commodities sold by node later.
-		//Sells(cpuProvisionedTemplateComm).
-		//Sells(memProvisionedTemplateComm)
+		Sells(netThroughputTemplateComm).
+		Sells(storageAmountTemplateComm).
+		Sells(cpuProvisionedTemplateComm).
+		Sells(memProvisionedTemplateComm).
 		Sells(clusterTemplateComm)
 
 	return nodeSupplyChainNodeBuilder.Create()
@@ -103,20 +140,33 @@ func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error)
 	podSupplyChainNodeBuilder = podSupplyChainNodeBuilder.
 		Sells(vCpuTemplateComm).
 		Sells(vMemTemplateComm).
+		Sells(storageAmountTemplateComm).
+		Sells(cpuProvisionedTemplateComm).
+		Sells(memProvisionedTemplateComm).
 		Sells(vmpmAccessTemplateComm).
 		Provider(proto.EntityDTO_VIRTUAL_MACHINE, proto.Provider_HOSTING).
-		// TODO we will re-include provisioned commodities bought by pod later.
-		//Buys(cpuProvisionedTemplateComm).
-		//Buys(memProvisionedTemplateComm).
-		Buys(clusterTemplateComm)
+		Buys(netThroughputTemplateComm).
+		Buys(storageAmountTemplateComm).
+		Buys(cpuProvisionedTemplateComm).
+		Buys(memProvisionedTemplateComm).
+		Buys(clusterTemplateComm).
+		Provider(proto.EntityDTO_VIRTUAL_DATACENTER, proto.Provider_LAYERED_OVER).
+		Buys(cpuAllocationTemplateComm).
+		Buys(memAllocationTemplateComm).
+		Buys(cpuProvisionedQuotaTemplateComm).
+		Buys(memProvisionedQuotaTemplateComm).
+		Provider(proto.EntityDTO_STORAGE, proto.Provider_LAYERED_OVER).
+		Buys(storageAmountTemplateComm)
 
 	// Link from Pod to VM
 	vmPodExtLinkBuilder := supplychain.NewExternalEntityLinkBuilder()
 	vmPodExtLinkBuilder.Link(proto.EntityDTO_CONTAINER_POD, proto.EntityDTO_VIRTUAL_MACHINE, proto.Provider_HOSTING).
 		Commodity(vCpuType, false).
 		Commodity(vMemType, false).
-		//Commodity(cpuProvisionedType, false).
-		//Commodity(memProvisionedType, false).
+		Commodity(netThroughputType, false).
+		Commodity(storageAmountType, false).
+		Commodity(cpuProvisionedType, false).
+		Commodity(memProvisionedType, false).
 		Commodity(vmPMAccessType, true).
 		Commodity(clusterType, true)
 
@@ -141,14 +191,48 @@ func (f *SupplyChainFactory) buildPodSupplyBuilder() (*proto.TemplateDTO, error)
 	return podSupplyChainNodeBuilder.ConnectsTo(vmPodExternalLink).Create()
 }
 
+// The quota of a namespace, limiting the CPU and memory limits and requests of its pods.
+func (f *SupplyChainFactory) buildQuotaSupplyBuilder() (*proto.TemplateDTO, error) {
+	quotaSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_VIRTUAL_DATACENTER).
+		Sells(cpuAllocationTemplateComm).
+		Sells(memAllocationTemplateComm).
+		Sells(cpuProvisionedQuotaTemplateComm).
+		Sells(memProvisionedQuotaTemplateComm)
+
+	return quotaSupplyChainNodeBuilder.Create()
+}
+
+// The workload controller, e.g. a Deployment, layered over the pods it manages.
+func (f *SupplyChainFactory) buildWorkloadControllerSupplyBuilder() (*proto.TemplateDTO, error) {
+	controllerSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(dtofactory.WorkloadControllerEntityType).
+		Provider(proto.EntityDTO_CONTAINER_POD, proto.Provider_LAYERED_OVER).
+		Buys(vCpuTemplateComm).
+		Buys(vMemTemplateComm)
+
+	return controllerSupplyChainNodeBuilder.Create()
+}
+
+// The persistent volume, selling the storage to the pods mounting it.
+func (f *SupplyChainFactory) buildStorageSupplyBuilder() (*proto.TemplateDTO, error) {
+	storageSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_STORAGE).
+		Sells(storageAmountTemplateComm)
+
+	return storageSupplyChainNodeBuilder.Create()
+}
+
 func (f *SupplyChainFactory) buildContainer() (*proto.TemplateDTO, error) {
 	builder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_CONTAINER).
 		Sells(vCpuTemplateComm).
 		Sells(vMemTemplateComm).
+		Sells(cpuProvisionedTemplateComm).
+		Sells(memProvisionedTemplateComm).
 		Sells(applicationTemplateComm).
 		Provider(proto.EntityDTO_CONTAINER_POD, proto.Provider_HOSTING).
 		Buys(vCpuTemplateComm).
 		Buys(vMemTemplateComm).
+		Buys(cpuProvisionedTemplateComm).
+		Buys(memProvisionedTemplateComm).
+		Buys(storageAmountTemplateComm).
 		Buys(vmpmAccessTemplateComm)
 
 	return builder.Create()
@@ -159,6 +243,7 @@ func (f *SupplyChainFactory) buildApplicationSupplyBuilder() (*proto.TemplateDTO
 	appSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_APPLICATION)
 	appSupplyChainNodeBuilder = appSupplyChainNodeBuilder.
 		Sells(transactionTemplateComm).
+		Sells(responseTimeTemplateComm).
 		Provider(proto.EntityDTO_CONTAINER, proto.Provider_HOSTING).
 		Buys(vCpuTemplateComm).
 		Buys(vMemTemplateComm).
@@ -171,6 +256,7 @@ func (f *SupplyChainFactory) buildVirtualApplicationSupplyBuilder() (*proto.Temp
 	vAppSupplyChainNodeBuilder := supplychain.NewSupplyChainNodeBuilder(proto.EntityDTO_VIRTUAL_APPLICATION)
 	vAppSupplyChainNodeBuilder = vAppSupplyChainNodeBuilder.
 		Provider(proto.EntityDTO_APPLICATION, proto.Provider_LAYERED_OVER).
-		Buys(transactionTemplateComm)
+		Buys(transactionTemplateComm).
+		Buys(responseTimeTemplateComm)
 	return vAppSupplyChainNodeBuilder.Create()
 }